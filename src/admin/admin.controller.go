@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminController handles HTTP requests for administrative actions
+type AdminController struct {
+	service *AdminService
+}
+
+// NewAdminController creates a new AdminController instance
+func NewAdminController(service *AdminService) *AdminController {
+	return &AdminController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the admin routes to the Fiber app
+func (c *AdminController) RegisterRoutes(app *fiber.App) {
+	// Guard
+	app.Use("/admin/*", middlewares.RequireRoles(models.RoleNameAdmin, models.RoleNameSuperAdmin))
+
+	app.Post("/admin/users/:userId/suspend", c.SuspendUser)
+	app.Post("/admin/users/:userId/ban", c.BanUser)
+	app.Post("/admin/users/:userId/reinstate", c.ReinstateUser)
+	app.Post("/admin/users/:userId/unlock", c.UnlockUser)
+	app.Get("/admin/roles", c.ListRoles)
+	app.Get("/admin/roles/:roleName/users", c.FindUsersByRole)
+	app.Post("/admin/roles/:roleName/users", c.GrantRole)
+	app.Delete("/admin/roles/:roleName/users/:userId", c.RevokeRole)
+}
+
+// @Summary Suspend a user
+// @Description Makes a user read-only for the given number of hours and revokes their sessions. Only super admins can suspend admins.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param suspension body SuspendUserDto true "Reason and duration"
+// @Success 200 {object} UserStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users/{userId}/suspend [post]
+// @Security ApiKeyAuth
+func (c *AdminController) SuspendUser(ctx *fiber.Ctx) error {
+	var dto SuspendUserDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.SuspendUser(ctx.Params("userId"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Ban a user
+// @Description Permanently refuses a user access and revokes their sessions. Only super admins can ban admins.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param ban body BanUserDto true "Reason"
+// @Success 200 {object} UserStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users/{userId}/ban [post]
+// @Security ApiKeyAuth
+func (c *AdminController) BanUser(ctx *fiber.Ctx) error {
+	var dto BanUserDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.BanUser(ctx.Params("userId"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Reinstate a user
+// @Description Lifts a suspension or ban and revokes the user's sessions.
+// @Tags Admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users/{userId}/reinstate [post]
+// @Security ApiKeyAuth
+func (c *AdminController) ReinstateUser(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.ReinstateUser(ctx.Params("userId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Unlock a user
+// @Description Clears a user's failed login attempts and lifts a login lockout before it expires.
+// @Tags Admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserStatusResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users/{userId}/unlock [post]
+// @Security ApiKeyAuth
+func (c *AdminController) UnlockUser(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.UnlockUser(ctx.Params("userId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary List roles
+// @Description Lists the assignable roles and how many users hold each.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} RolesResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/roles [get]
+// @Security ApiKeyAuth
+func (c *AdminController) ListRoles(ctx *fiber.Ctx) error {
+	response, err := c.service.ListRoles()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary List users by role
+// @Description Lists the users holding a role, with pagination.
+// @Tags Admin
+// @Produce json
+// @Param roleName path string true "Role name" Enums(Authenticated, BusinessOwner, PaymentAgent, Admin, SuperAdmin)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/roles/{roleName}/users [get]
+// @Security ApiKeyAuth
+func (c *AdminController) FindUsersByRole(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	response, err := c.service.FindUsersByRole(ctx.Params("roleName"), page, limit)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Grant a role
+// @Description Grants a role to a user. Only super admins can grant Admin or SuperAdmin.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param roleName path string true "Role name" Enums(Authenticated, BusinessOwner, PaymentAgent, Admin, SuperAdmin)
+// @Param grant body GrantRoleDto true "User to grant the role to"
+// @Success 201 {object} RoleChangeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/roles/{roleName}/users [post]
+// @Security ApiKeyAuth
+func (c *AdminController) GrantRole(ctx *fiber.Ctx) error {
+	var dto GrantRoleDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.GrantRole(ctx.Params("roleName"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// @Summary Revoke a role
+// @Description Removes a role from a user. Only super admins can revoke Admin or SuperAdmin; the last super admin cannot be removed.
+// @Tags Admin
+// @Produce json
+// @Param roleName path string true "Role name" Enums(Authenticated, BusinessOwner, PaymentAgent, Admin, SuperAdmin)
+// @Param userId path string true "User ID"
+// @Success 200 {object} RoleChangeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/roles/{roleName}/users/{userId} [delete]
+// @Security ApiKeyAuth
+func (c *AdminController) RevokeRole(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.RevokeRole(ctx.Params("roleName"), ctx.Params("userId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
@@ -0,0 +1,43 @@
+package admin
+
+import "github.com/epsierra/phinex-blog-api/src/models"
+
+// SuspendUserDto defines the input for suspending a user
+type SuspendUserDto struct {
+	Reason        string `json:"reason" validate:"required" example:"Repeated spam"`
+	DurationHours int    `json:"durationHours" validate:"required,min=1" example:"72"`
+}
+
+// BanUserDto defines the input for banning a user
+type BanUserDto struct {
+	Reason string `json:"reason" validate:"required" example:"Fraudulent payments"`
+}
+
+// UserStatusResponse represents the response for user status changes
+type UserStatusResponse struct {
+	Message string      `json:"message"`
+	Data    models.User `json:"data"`
+}
+
+// GrantRoleDto defines the input for granting a role to a user
+type GrantRoleDto struct {
+	UserId string `json:"userId" validate:"required" example:"phiV1StGXR8_Z5jdHi6B-myT"`
+}
+
+// RoleSummary describes a role and how many users hold it
+type RoleSummary struct {
+	RoleName  models.RoleName `json:"roleName"`
+	UserCount int64           `json:"userCount"`
+}
+
+// RolesResponse represents the list of roles
+type RolesResponse struct {
+	Data []RoleSummary `json:"data"`
+}
+
+// RoleChangeResponse represents the response for role grants and revocations
+type RoleChangeResponse struct {
+	Message  string          `json:"message"`
+	UserId   string          `json:"userId"`
+	RoleName models.RoleName `json:"roleName"`
+}
@@ -0,0 +1,400 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/audit"
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// statusAuditActions maps each status an admin can set to its audit action
+var statusAuditActions = map[models.UserStatus]string{
+	models.UserStatusSuspended: audit.ActionUserSuspended,
+	models.UserStatusBanned:    audit.ActionUserBanned,
+	models.UserStatusActive:    audit.ActionUserReinstated,
+}
+
+// assignableRoles are the roles that can be granted through the API
+var assignableRoles = []models.RoleName{
+	models.RoleNameAuthenticated,
+	models.RoleNameBusinessOwner,
+	models.RoleNamePaymentAgent,
+	models.RoleNameAdmin,
+	models.RoleNameSuperAdmin,
+}
+
+// AdminService handles administrative operations on users
+type AdminService struct {
+	db          *gorm.DB
+	logger      *log.Logger
+	authService *auth.AuthService
+}
+
+// NewAdminService creates a new AdminService instance
+func NewAdminService(db *gorm.DB, authService *auth.AuthService) *AdminService {
+	return &AdminService{
+		db:          db,
+		logger:      log.New(os.Stderr, "admin-service: ", log.LstdFlags),
+		authService: authService,
+	}
+}
+
+// SuspendUser makes a user read-only for the given number of hours
+func (s *AdminService) SuspendUser(userId string, dto SuspendUserDto, currentUser models.ICurrentUser) (UserStatusResponse, error) {
+	if dto.Reason == "" {
+		return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Reason is required"}
+	}
+	if dto.DurationHours < 1 {
+		return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Duration must be at least one hour"}
+	}
+
+	suspendedUntil := time.Now().UTC().Add(time.Duration(dto.DurationHours) * time.Hour)
+	user, err := s.setUserStatus(userId, models.UserStatusSuspended, dto.Reason, &suspendedUntil, currentUser)
+	if err != nil {
+		return UserStatusResponse{}, err
+	}
+	return UserStatusResponse{Message: "User suspended successfully", Data: user}, nil
+}
+
+// BanUser permanently refuses a user access
+func (s *AdminService) BanUser(userId string, dto BanUserDto, currentUser models.ICurrentUser) (UserStatusResponse, error) {
+	if dto.Reason == "" {
+		return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Reason is required"}
+	}
+
+	user, err := s.setUserStatus(userId, models.UserStatusBanned, dto.Reason, nil, currentUser)
+	if err != nil {
+		return UserStatusResponse{}, err
+	}
+	return UserStatusResponse{Message: "User banned successfully", Data: user}, nil
+}
+
+// ReinstateUser lifts a suspension or ban
+func (s *AdminService) ReinstateUser(userId string, currentUser models.ICurrentUser) (UserStatusResponse, error) {
+	user, err := s.setUserStatus(userId, models.UserStatusActive, "", nil, currentUser)
+	if err != nil {
+		return UserStatusResponse{}, err
+	}
+	return UserStatusResponse{Message: "User reinstated successfully", Data: user}, nil
+}
+
+// UnlockUser clears a user's failed logins and lifts a login lockout early
+func (s *AdminService) UnlockUser(userId string, currentUser models.ICurrentUser) (UserStatusResponse, error) {
+	var user models.User
+	if err := s.db.Where("user_id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User with ID %s not found", userId)}
+		}
+		s.logger.Printf("Error fetching user: %v", err)
+		return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to unlock user"}
+	}
+
+	if err := s.authService.UnlockAccount(user.Email); err != nil {
+		s.logger.Printf("Error clearing login attempts: %v", err)
+		return UserStatusResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to unlock user"}
+	}
+	if err := audit.Record(s.db, currentUser, audit.ActionUserUnlocked, audit.TargetTypeUser, userId, nil); err != nil {
+		s.logger.Printf("Error recording unlock: %v", err)
+	}
+	return UserStatusResponse{Message: "User unlocked successfully", Data: user}, nil
+}
+
+// setUserStatus changes a user's status and revokes all their sessions, so the
+// change applies to every device at once.
+func (s *AdminService) setUserStatus(userId string, status models.UserStatus, reason string, suspendedUntil *time.Time, currentUser models.ICurrentUser) (models.User, error) {
+	if userId == currentUser.UserId {
+		return models.User{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "You cannot change your own status"}
+	}
+
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User with ID %s not found", userId)}
+			}
+			return err
+		}
+
+		// Only super admins may act on other admins
+		if isAdmin(user) && !currentUser.HasRole(models.RoleNameSuperAdmin) {
+			return &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: Only super admins can change the status of an admin"}
+		}
+
+		user.Status = status
+		user.StatusReason = reason
+		user.SuspendedUntil = suspendedUntil
+		err := tx.Model(&models.User{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+			"status":          status,
+			"status_reason":   reason,
+			"suspended_until": suspendedUntil,
+			"updated_at":      time.Now().UTC(),
+			"updated_by":      currentUser.FullName,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := auth.RevokeUserSessions(tx, userId, currentUser.FullName); err != nil {
+			return err
+		}
+
+		details := map[string]interface{}{"reason": reason}
+		if suspendedUntil != nil {
+			details["suspendedUntil"] = suspendedUntil
+		}
+		return audit.Record(tx, currentUser, statusAuditActions[status], audit.TargetTypeUser, userId, details)
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return models.User{}, fiberErr
+		}
+		s.logger.Printf("Error updating user status: %v", err)
+		return models.User{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to update user status"}
+	}
+	middlewares.InvalidateUser(userId)
+
+	user.UserRoles = nil
+	return user, nil
+}
+
+// ListRoles returns every assignable role with the number of users holding it
+func (s *AdminService) ListRoles() (RolesResponse, error) {
+	var counts []RoleSummary
+	err := s.db.Model(&models.UserRole{}).
+		Select("roles.role_name AS role_name, COUNT(user_roles.user_role_id) AS user_count").
+		Joins("JOIN roles ON roles.role_id = user_roles.role_id").
+		Group("roles.role_name").
+		Scan(&counts).Error
+	if err != nil {
+		s.logger.Printf("Error counting role holders: %v", err)
+		return RolesResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch roles"}
+	}
+
+	userCounts := map[models.RoleName]int64{}
+	for _, count := range counts {
+		userCounts[count.RoleName] = count.UserCount
+	}
+	roles := make([]RoleSummary, 0, len(assignableRoles))
+	for _, roleName := range assignableRoles {
+		roles = append(roles, RoleSummary{RoleName: roleName, UserCount: userCounts[roleName]})
+	}
+	return RolesResponse{Data: roles}, nil
+}
+
+// FindUsersByRole returns the users holding a role, newest grant first
+func (s *AdminService) FindUsersByRole(roleName string, page, limit int) (models.PaginatedResponse, error) {
+	role, err := parseRoleName(roleName)
+	if err != nil {
+		return models.PaginatedResponse{Data: []models.User{}}, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	query := s.db.Model(&models.User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.user_id").
+		Joins("JOIN roles ON roles.role_id = user_roles.role_id").
+		Where("roles.role_name = ?", role)
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		s.logger.Printf("Error counting users by role: %v", err)
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch users"}
+	}
+
+	var users []models.User
+	offset := (page - 1) * limit
+	if err := query.Order("user_roles.created_at DESC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		s.logger.Printf("Error fetching users by role: %v", err)
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch users"}
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+
+	return models.PaginatedResponse{
+		Data: users,
+		Metadata: models.PaginationMetadata{
+			CurrentPage:     int64(page),
+			ItemsPerPage:    int64(limit),
+			TotalItems:      totalItems,
+			TotalPages:      totalPages,
+			HasNextPage:     int64(page) < totalPages,
+			HasPreviousPage: int64(page) > 1,
+		},
+	}, nil
+}
+
+// GrantRole gives a user a role. Only super admins can grant Admin or SuperAdmin.
+func (s *AdminService) GrantRole(roleName string, dto GrantRoleDto, currentUser models.ICurrentUser) (RoleChangeResponse, error) {
+	role, err := parseRoleName(roleName)
+	if err != nil {
+		return RoleChangeResponse{}, err
+	}
+	if err := requireRoleManager(role, currentUser); err != nil {
+		return RoleChangeResponse{}, err
+	}
+	if dto.UserId == "" {
+		return RoleChangeResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "User ID is required"}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("user_id = ?", dto.UserId).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User with ID %s not found", dto.UserId)}
+			}
+			return err
+		}
+
+		roleRow, err := s.findOrCreateRole(tx, role, currentUser)
+		if err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.UserRole{}).Where("user_id = ? AND role_id = ?", user.UserId, roleRow.RoleId).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return &fiber.Error{Code: fiber.StatusConflict, Message: fmt.Sprintf("User already has the %s role", role)}
+		}
+
+		userRole := models.UserRole{
+			UserRoleId: utils.GenerateID(),
+			UserId:     user.UserId,
+			RoleId:     roleRow.RoleId,
+			CreatedAt:  time.Now().UTC(),
+			UpdatedAt:  time.Now().UTC(),
+			CreatedBy:  currentUser.FullName,
+			UpdatedBy:  currentUser.FullName,
+		}
+		if err := tx.Create(&userRole).Error; err != nil {
+			return err
+		}
+
+		return audit.Record(tx, currentUser, audit.ActionRoleGranted, audit.TargetTypeUser, user.UserId, map[string]interface{}{"role": role})
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return RoleChangeResponse{}, fiberErr
+		}
+		s.logger.Printf("Error granting role: %v", err)
+		return RoleChangeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to grant role"}
+	}
+	middlewares.InvalidateUser(dto.UserId)
+
+	return RoleChangeResponse{Message: "Role granted successfully", UserId: dto.UserId, RoleName: role}, nil
+}
+
+// RevokeRole removes a role from a user. Only super admins can revoke Admin or
+// SuperAdmin, and the last super admin cannot be removed.
+func (s *AdminService) RevokeRole(roleName string, userId string, currentUser models.ICurrentUser) (RoleChangeResponse, error) {
+	role, err := parseRoleName(roleName)
+	if err != nil {
+		return RoleChangeResponse{}, err
+	}
+	if err := requireRoleManager(role, currentUser); err != nil {
+		return RoleChangeResponse{}, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var userRole models.UserRole
+		err := tx.Joins("JOIN roles ON roles.role_id = user_roles.role_id").
+			Where("user_roles.user_id = ? AND roles.role_name = ?", userId, role).
+			First(&userRole).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User does not have the %s role", role)}
+			}
+			return err
+		}
+
+		if role == models.RoleNameSuperAdmin {
+			// Lock every super admin grant before counting, so two concurrent
+			// revokes cannot both see another super admin and remove the last two
+			var superAdmins []string
+			err := tx.Model(&models.UserRole{}).
+				Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "user_roles"}}).
+				Joins("JOIN roles ON roles.role_id = user_roles.role_id").
+				Where("roles.role_name = ?", models.RoleNameSuperAdmin).
+				Pluck("user_roles.user_role_id", &superAdmins).Error
+			if err != nil {
+				return err
+			}
+			if len(superAdmins) <= 1 {
+				return &fiber.Error{Code: fiber.StatusConflict, Message: "Cannot revoke the last super admin"}
+			}
+		}
+
+		if err := tx.Delete(&userRole).Error; err != nil {
+			return err
+		}
+
+		return audit.Record(tx, currentUser, audit.ActionRoleRevoked, audit.TargetTypeUser, userId, map[string]interface{}{"role": role})
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return RoleChangeResponse{}, fiberErr
+		}
+		s.logger.Printf("Error revoking role: %v", err)
+		return RoleChangeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to revoke role"}
+	}
+	middlewares.InvalidateUser(userId)
+
+	return RoleChangeResponse{Message: "Role revoked successfully", UserId: userId, RoleName: role}, nil
+}
+
+// findOrCreateRole returns the roles row for a role name, creating it on first use
+func (s *AdminService) findOrCreateRole(tx *gorm.DB, roleName models.RoleName, currentUser models.ICurrentUser) (models.Role, error) {
+	role := models.Role{
+		RoleId:    utils.GenerateID(),
+		RoleName:  roleName,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		CreatedBy: currentUser.FullName,
+		UpdatedBy: currentUser.FullName,
+	}
+	err := tx.Where(models.Role{RoleName: roleName}).FirstOrCreate(&role).Error
+	return role, err
+}
+
+// parseRoleName validates a role name from a request
+func parseRoleName(roleName string) (models.RoleName, error) {
+	for _, role := range assignableRoles {
+		if string(role) == roleName {
+			return role, nil
+		}
+	}
+	return "", &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Unknown role %s", roleName)}
+}
+
+// requireRoleManager only lets super admins manage the Admin and SuperAdmin roles
+func requireRoleManager(role models.RoleName, currentUser models.ICurrentUser) error {
+	if (role == models.RoleNameAdmin || role == models.RoleNameSuperAdmin) && !currentUser.HasRole(models.RoleNameSuperAdmin) {
+		return &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: Only super admins can manage the " + string(role) + " role"}
+	}
+	return nil
+}
+
+// isAdmin reports whether the user holds the Admin or SuperAdmin role
+func isAdmin(user models.User) bool {
+	for _, userRole := range user.UserRoles {
+		if userRole.Role.RoleName == models.RoleNameAdmin || userRole.Role.RoleName == models.RoleNameSuperAdmin {
+			return true
+		}
+	}
+	return false
+}
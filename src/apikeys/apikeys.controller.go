@@ -0,0 +1,94 @@
+package apikeys
+
+import (
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApiKeysController handles HTTP requests for personal API keys
+type ApiKeysController struct {
+	service *ApiKeysService
+}
+
+// NewApiKeysController creates a new ApiKeysController instance
+func NewApiKeysController(service *ApiKeysService) *ApiKeysController {
+	return &ApiKeysController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the API key routes to the Fiber app. API keys
+// themselves cannot call these routes, so a leaked key cannot mint more keys.
+func (c *ApiKeysController) RegisterRoutes(app *fiber.App) {
+	app.Post("/api-keys", middlewares.RequireAuthenticated(), c.CreateApiKey)
+	app.Get("/api-keys", middlewares.RequireAuthenticated(), c.FindApiKeys)
+	app.Delete("/api-keys/:apiKeyId", middlewares.RequireAuthenticated(), c.RevokeApiKey)
+}
+
+// @Summary Create an API key
+// @Description Creates a named API key with scopes and an optional expiry. The key is only returned in this response.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param apiKey body CreateApiKeyDto true "Name, scopes and expiry"
+// @Success 201 {object} CreateApiKeyResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api-keys [post]
+// @Security ApiKeyAuth
+func (c *ApiKeysController) CreateApiKey(ctx *fiber.Ctx) error {
+	var dto CreateApiKeyDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.Create(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// @Summary List API keys
+// @Description Lists the current user's API keys, including revoked and expired ones.
+// @Tags API Keys
+// @Produce json
+// @Success 200 {object} ApiKeysResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api-keys [get]
+// @Security ApiKeyAuth
+func (c *ApiKeysController) FindApiKeys(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.FindAll(currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Revoke an API key
+// @Description Revokes one of the current user's API keys. It stops working immediately.
+// @Tags API Keys
+// @Produce json
+// @Param apiKeyId path string true "API key ID"
+// @Success 200 {object} RevokeApiKeyResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api-keys/{apiKeyId} [delete]
+// @Security ApiKeyAuth
+func (c *ApiKeysController) RevokeApiKey(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.Revoke(ctx.Params("apiKeyId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
@@ -0,0 +1,33 @@
+package apikeys
+
+import "github.com/epsierra/phinex-blog-api/src/models"
+
+// CreateApiKeyDto defines the input for creating an API key
+type CreateApiKeyDto struct {
+	Name          string   `json:"name" validate:"required,max=80" example:"Publishing bot"`
+	Scopes        []string `json:"scopes" validate:"required" example:"blogs:read,blogs:write"`
+	ExpiresInDays int      `json:"expiresInDays,omitempty" example:"90"`
+}
+
+// ApiKeyData is an API key as returned to its owner
+type ApiKeyData struct {
+	models.ApiKey
+	Scopes []string `json:"scopes"`
+}
+
+// CreateApiKeyResponse represents a newly created API key. Key is only ever returned here.
+type CreateApiKeyResponse struct {
+	Message string     `json:"message"`
+	Key     string     `json:"key"`
+	Data    ApiKeyData `json:"data"`
+}
+
+// ApiKeysResponse represents the current user's API keys
+type ApiKeysResponse struct {
+	Data []ApiKeyData `json:"data"`
+}
+
+// RevokeApiKeyResponse represents the response for revoking an API key
+type RevokeApiKeyResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,173 @@
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// keyPrefix marks API keys so they are recognisable, e.g. in secret scanners
+const keyPrefix = "phx_"
+
+// ApiKeysService manages users' personal API keys
+type ApiKeysService struct {
+	db            *gorm.DB
+	logger        *log.Logger
+	maxActiveKeys int
+}
+
+// NewApiKeysService creates a new ApiKeysService instance
+func NewApiKeysService(db *gorm.DB) *ApiKeysService {
+	return &ApiKeysService{
+		db:            db,
+		logger:        log.New(os.Stderr, "api-keys-service: ", log.LstdFlags),
+		maxActiveKeys: utils.GetEnvInt("API_KEYS_MAX_PER_USER", 10),
+	}
+}
+
+// Create issues a new API key for the current user. The key itself is only
+// returned once; afterwards only its hash is known.
+func (s *ApiKeysService) Create(dto CreateApiKeyDto, currentUser models.ICurrentUser) (CreateApiKeyResponse, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" || len(name) > 80 {
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Name is required and must be at most 80 characters"}
+	}
+	scopes, err := normalizeScopes(dto.Scopes)
+	if err != nil {
+		return CreateApiKeyResponse{}, err
+	}
+	if dto.ExpiresInDays < 0 {
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Expiry must not be negative"}
+	}
+
+	now := time.Now().UTC()
+	var active int64
+	err = s.db.Model(&models.ApiKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", currentUser.UserId, now).
+		Count(&active).Error
+	if err != nil {
+		s.logger.Printf("Error counting API keys: %v", err)
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to create API key"}
+	}
+	if active >= int64(s.maxActiveKeys) {
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusConflict, Message: fmt.Sprintf("You can have at most %d active API keys", s.maxActiveKeys)}
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		s.logger.Printf("Error generating API key: %v", err)
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to create API key"}
+	}
+	rawKey := keyPrefix + token
+
+	key := models.ApiKey{
+		ApiKeyId:  utils.GenerateID(),
+		UserId:    currentUser.UserId,
+		Name:      name,
+		Prefix:    rawKey[:12],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: currentUser.FullName,
+		UpdatedBy: currentUser.FullName,
+	}
+	if dto.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, dto.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.db.Create(&key).Error; err != nil {
+		s.logger.Printf("Error creating API key: %v", err)
+		return CreateApiKeyResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to create API key"}
+	}
+
+	return CreateApiKeyResponse{
+		Message: "API key created successfully. Store it now, it will not be shown again.",
+		Key:     rawKey,
+		Data:    toApiKeyData(key),
+	}, nil
+}
+
+// FindAll lists the current user's API keys, newest first
+func (s *ApiKeysService) FindAll(currentUser models.ICurrentUser) (ApiKeysResponse, error) {
+	var keys []models.ApiKey
+	if err := s.db.Where("user_id = ?", currentUser.UserId).Order("created_at DESC").Find(&keys).Error; err != nil {
+		s.logger.Printf("Error fetching API keys: %v", err)
+		return ApiKeysResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch API keys"}
+	}
+
+	data := make([]ApiKeyData, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, toApiKeyData(key))
+	}
+	return ApiKeysResponse{Data: data}, nil
+}
+
+// Revoke disables one of the current user's API keys
+func (s *ApiKeysService) Revoke(apiKeyId string, currentUser models.ICurrentUser) (RevokeApiKeyResponse, error) {
+	var key models.ApiKey
+	if err := s.db.Where("api_key_id = ? AND user_id = ?", apiKeyId, currentUser.UserId).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RevokeApiKeyResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("API key with ID %s not found", apiKeyId)}
+		}
+		s.logger.Printf("Error fetching API key: %v", err)
+		return RevokeApiKeyResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to revoke API key"}
+	}
+	if key.RevokedAt != nil {
+		return RevokeApiKeyResponse{Message: "API key revoked successfully"}, nil
+	}
+
+	now := time.Now().UTC()
+	err := s.db.Model(&key).Updates(map[string]interface{}{
+		"revoked_at": now,
+		"updated_at": now,
+		"updated_by": currentUser.FullName,
+	}).Error
+	if err != nil {
+		s.logger.Printf("Error revoking API key: %v", err)
+		return RevokeApiKeyResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to revoke API key"}
+	}
+	return RevokeApiKeyResponse{Message: "API key revoked successfully"}, nil
+}
+
+// normalizeScopes validates requested scopes and removes duplicates
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, &fiber.Error{Code: fiber.StatusBadRequest, Message: "At least one scope is required"}
+	}
+
+	seen := map[string]bool{}
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return nil, &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Unknown scope %s. Valid scopes are %s", scope, strings.Join(models.ApiKeyScopes, ", "))}
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range models.ApiKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func toApiKeyData(key models.ApiKey) ApiKeyData {
+	return ApiKeyData{ApiKey: key, Scopes: key.ScopeList()}
+}
@@ -1,9 +1,20 @@
 package app
 
 import (
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/admin"
+	"github.com/epsierra/phinex-blog-api/src/apikeys"
 	"github.com/epsierra/phinex-blog-api/src/auth"
 	"github.com/epsierra/phinex-blog-api/src/blogs"
+	"github.com/epsierra/phinex-blog-api/src/feeds"
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/search"
+	"github.com/epsierra/phinex-blog-api/src/shows"
+	"github.com/epsierra/phinex-blog-api/src/sitemaps"
+	"github.com/epsierra/phinex-blog-api/src/tags"
 	"github.com/epsierra/phinex-blog-api/src/users"
+	"github.com/epsierra/phinex-blog-api/src/utils"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -11,17 +22,62 @@ import (
 func AppSetup(db *gorm.DB) *fiber.App {
 	app := fiber.New()
 
-	blogService := blogs.NewBlogsService(db)
+	// Resolve the current user once; routes opt into RequireAuthenticated or RequireRoles
+	app.Use(middlewares.Authenticate(db))
+
+	// Tags go first: their guard also covers GET /tags/:tag/blogs, which the blogs controller serves
+	tagsService := tags.NewTagsService(db)
+	tagsController := tags.NewTagsController(tagsService)
+	tagsController.RegisterRoutes(app)
+
+	authService := auth.NewAuthService(db)
+
+	blogService := blogs.NewBlogsService(db, authService)
 	blogController := blogs.NewBlogsController(blogService)
 	blogController.RegisterRoutes(app)
 
-	userService := users.NewUsersService(db)
-	userController := users.NewUsersController(userService)
-	userController.RegisterRoutes(app)
+	// Give blogs created before slugs existed their slugs
+	blogService.StartSlugBackfill()
+
+	// Publish scheduled blogs and rescore blogs in the background for as long as the app runs
+	stopScheduler := blogService.StartScheduler(utils.GetEnvDuration("BLOG_SCHEDULER_INTERVAL", time.Minute))
+	stopRanking := blogService.StartRanking(utils.GetEnvDuration("RANKING_INTERVAL", 10*time.Minute))
+	app.Hooks().OnShutdown(func() error {
+		stopScheduler()
+		stopRanking()
+		return nil
+	})
 
-	authService := auth.NewAuthService(db)
 	authController := auth.NewAuthController(authService)
 	authController.RegisterRoutes(app)
 
+	userService := users.NewUsersService(db, authService)
+	userController := users.NewUsersController(userService)
+	userController.RegisterRoutes(app)
+
+	adminService := admin.NewAdminService(db, authService)
+	adminController := admin.NewAdminController(adminService)
+	adminController.RegisterRoutes(app)
+
+	apiKeysService := apikeys.NewApiKeysService(db)
+	apiKeysController := apikeys.NewApiKeysController(apiKeysService)
+	apiKeysController.RegisterRoutes(app)
+
+	searchService := search.NewSearchService(db)
+	searchController := search.NewSearchController(searchService)
+	searchController.RegisterRoutes(app)
+
+	feedsService := feeds.NewFeedsService(db, blogService)
+	feedsController := feeds.NewFeedsController(feedsService)
+	feedsController.RegisterRoutes(app)
+
+	showsService := shows.NewShowsService(db)
+	showsController := shows.NewShowsController(showsService)
+	showsController.RegisterRoutes(app)
+
+	sitemapsService := sitemaps.NewSitemapsService(db)
+	sitemapsController := sitemaps.NewSitemapsController(sitemapsService)
+	sitemapsController.RegisterRoutes(app)
+
 	return app
 }
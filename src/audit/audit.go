@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	ActionRoleGranted    = "role.granted"
+	ActionRoleRevoked    = "role.revoked"
+	ActionUserSuspended  = "user.suspended"
+	ActionUserBanned     = "user.banned"
+	ActionUserReinstated = "user.reinstated"
+	ActionUserUnlocked   = "user.unlocked"
+	ActionLoginLockedOut = "login.locked_out"
+)
+
+// Audited target types
+const (
+	TargetTypeUser    = "user"
+	TargetTypeAccount = "account" // a login email
+	TargetTypeIP      = "ip"
+)
+
+// Record writes an audit log entry in the given transaction, so the record is
+// only kept if the change it describes is committed. A zero actor is recorded
+// as the system.
+func Record(tx *gorm.DB, actor models.ICurrentUser, action, targetType, targetId string, details map[string]interface{}) error {
+	detailsJSON := ""
+	if len(details) > 0 {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		detailsJSON = string(encoded)
+	}
+
+	actorName := actor.FullName
+	if actorName == "" {
+		actorName = "system"
+	}
+
+	now := time.Now().UTC()
+	entry := models.AuditLog{
+		AuditLogId: utils.GenerateID(),
+		ActorId:    actor.UserId,
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		IP:         actor.IP,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		CreatedBy:  actorName,
+		UpdatedBy:  actorName,
+	}
+	// Leave details NULL rather than storing an invalid empty JSON document
+	if detailsJSON == "" {
+		return tx.Omit("details").Create(&entry).Error
+	}
+	entry.Details = detailsJSON
+	return tx.Create(&entry).Error
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -19,10 +21,41 @@ func NewAuthController(service *AuthService) *AuthController {
 // RegisterRoutes registers the authentication-related routes to the Fiber app
 func (c *AuthController) RegisterRoutes(app *fiber.App) {
 	app.Post("/auth/token", c.GetTokenByEmail)
+	app.Post("/auth/login", c.Login)
+	app.Post("/auth/refresh", c.Refresh)
+	app.Post("/auth/logout", middlewares.RequireAuthenticated(), c.Logout)
+	app.Post("/auth/logout-all", middlewares.RequireAuthenticated(), c.LogoutAll)
+	app.Post("/auth/verify-email", c.VerifyEmail)
+	app.Post("/auth/verify-email/resend", c.ResendEmailVerification)
+	app.Post("/auth/forgot-password", c.ForgotPassword)
+	app.Post("/auth/reset-password", c.ResetPassword)
+	app.Post("/auth/change-password", middlewares.RequireAuthenticated(), c.ChangePassword)
+	app.Post("/auth/phone", middlewares.RequireAuthenticated(), c.AddPhoneNumber)
+	app.Post("/auth/phone/verify", middlewares.RequireAuthenticated(), c.VerifyPhoneNumber)
+	app.Post("/auth/2fa/enroll", middlewares.RequireAuthenticated(), c.EnrollMfa)
+	app.Post("/auth/2fa/confirm", middlewares.RequireAuthenticated(), c.ConfirmMfa)
+	app.Post("/auth/2fa/disable", middlewares.RequireAuthenticated(), c.DisableMfa)
+	app.Post("/auth/2fa/verify", c.VerifyMfa)
+	app.Get("/auth/oidc/authorize", c.StartOidcLogin)
+	app.Get("/auth/oidc/callback", c.CompleteOidcLogin)
+	app.Post("/auth/oidc/callback", c.CompleteOidcLogin)
+	app.Get("/.well-known/jwks.json", c.GetJWKS)
+}
+
+// clientInfo extracts the details of the client a session is issued to
+func clientInfo(ctx *fiber.Ctx) ClientInfo {
+	ip := ctx.IP()
+	if currentUser, ok := ctx.Locals("user").(models.ICurrentUser); ok && currentUser.IP != "" {
+		ip = currentUser.IP
+	}
+	return ClientInfo{
+		IP:        ip,
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
 }
 
 // @Summary Get JWT token by email
-// @Description Generates a JWT token for a verified user by email.
+// @Description Generates a JWT token for a verified user by email. Only available in development/test mode.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -31,6 +64,7 @@ func (c *AuthController) RegisterRoutes(app *fiber.App) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /auth/token [post]
 func (c *AuthController) GetTokenByEmail(ctx *fiber.Ctx) error {
@@ -39,7 +73,425 @@ func (c *AuthController) GetTokenByEmail(ctx *fiber.Ctx) error {
 		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
 	}
 
-	response, err := c.service.GetTokenByEmail(dto.Email)
+	response, err := c.service.RequestTokenByEmail(dto, clientInfo(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Login with email and password
+// @Description Authenticates a verified user by email and password and returns an access token and a refresh token. Users with two-factor authentication get a challenge token instead, to complete at /auth/2fa/verify.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginDto true "User email and password"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/login [post]
+func (c *AuthController) Login(ctx *fiber.Ctx) error {
+	var dto LoginDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.Login(dto, clientInfo(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access/refresh token pair. Reusing an already rotated refresh token revokes the whole session.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refreshToken body RefreshTokenDto true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
+	var dto RefreshTokenDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.Refresh(dto, clientInfo(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Logout
+// @Description Revokes the current session and its refresh tokens.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/logout [post]
+// @Security ApiKeyAuth
+func (c *AuthController) Logout(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.Logout(currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Logout everywhere
+// @Description Revokes every session of the current user.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/logout-all [post]
+// @Security ApiKeyAuth
+func (c *AuthController) LogoutAll(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.LogoutAll(currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Verify email
+// @Description Consumes a verification token sent by email and marks the account as verified.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body VerifyEmailDto true "Verification token"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/verify-email [post]
+func (c *AuthController) VerifyEmail(ctx *fiber.Ctx) error {
+	var dto VerifyEmailDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.VerifyEmail(dto)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Resend verification email
+// @Description Sends a new verification email to an unverified account. The response does not reveal whether the email is registered.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body ResendEmailVerificationDto true "Account email"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/verify-email/resend [post]
+func (c *AuthController) ResendEmailVerification(ctx *fiber.Ctx) error {
+	var dto ResendEmailVerificationDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.ResendEmailVerification(dto)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Forgot password
+// @Description Emails a single-use password reset token. The response does not reveal whether the email is registered.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body ForgotPasswordDto true "Account email"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/forgot-password [post]
+func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
+	var dto ForgotPasswordDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.ForgotPassword(dto)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Reset password
+// @Description Sets a new password using a password reset token. All sessions of the user are revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param reset body ResetPasswordDto true "Reset token and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/reset-password [post]
+func (c *AuthController) ResetPassword(ctx *fiber.Ctx) error {
+	var dto ResetPasswordDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.ResetPassword(dto)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Change password
+// @Description Changes the current user's password. Requires the current password; every other session is revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param passwords body ChangePasswordDto true "Current and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/change-password [post]
+// @Security ApiKeyAuth
+func (c *AuthController) ChangePassword(ctx *fiber.Ctx) error {
+	var dto ChangePasswordDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.ChangePassword(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Add phone number
+// @Description Sets the current user's phone number and texts it a 6-digit verification code.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param phone body AddPhoneNumberDto true "Phone number in E.164 format"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/phone [post]
+// @Security ApiKeyAuth
+func (c *AuthController) AddPhoneNumber(ctx *fiber.Ctx) error {
+	var dto AddPhoneNumberDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.AddPhoneNumber(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Verify phone number
+// @Description Verifies the current user's phone number with the code sent by SMS.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param code body VerifyPhoneNumberDto true "Verification code"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/phone/verify [post]
+// @Security ApiKeyAuth
+func (c *AuthController) VerifyPhoneNumber(ctx *fiber.Ctx) error {
+	var dto VerifyPhoneNumberDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.VerifyPhoneNumber(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Start two-factor enrollment
+// @Description Generates a TOTP secret and otpauth URI for the current user. Two-factor authentication is enabled once a code is confirmed.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} MfaEnrollResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/2fa/enroll [post]
+// @Security ApiKeyAuth
+func (c *AuthController) EnrollMfa(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.EnrollMfa(currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Confirm two-factor enrollment
+// @Description Enables two-factor authentication with a first code from the authenticator app and returns single-use recovery codes.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param code body MfaCodeDto true "TOTP code"
+// @Success 200 {object} MfaRecoveryCodesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/2fa/confirm [post]
+// @Security ApiKeyAuth
+func (c *AuthController) ConfirmMfa(ctx *fiber.Ctx) error {
+	var dto MfaCodeDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.ConfirmMfa(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turns off two-factor authentication. Requires the password and a TOTP or recovery code.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body DisableMfaDto true "Password and two-factor code"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/2fa/disable [post]
+// @Security ApiKeyAuth
+func (c *AuthController) DisableMfa(ctx *fiber.Ctx) error {
+	var dto DisableMfaDto
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.DisableMfa(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Complete two-factor login
+// @Description Exchanges the challenge token returned by /auth/login and a TOTP or recovery code for an access token and a refresh token.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param challenge body VerifyMfaDto true "Challenge token and two-factor code"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/2fa/verify [post]
+func (c *AuthController) VerifyMfa(ctx *fiber.Ctx) error {
+	var dto VerifyMfaDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.VerifyMfa(dto, clientInfo(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Get token signing keys
+// @Description Publishes the public keys access tokens are signed with, as a JSON Web Key Set. Empty when tokens are signed with a shared HS256 secret.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} JWKSResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /.well-known/jwks.json [get]
+func (c *AuthController) GetJWKS(ctx *fiber.Ctx) error {
+	response, err := c.service.GetJWKS()
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(response)
+}
+
+// @Summary Start OIDC login
+// @Description Starts a login with the configured OpenID Connect provider (authorization code flow with PKCE). Send the user to the returned authorizationUrl.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} OidcAuthorizeResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Router /auth/oidc/authorize [get]
+func (c *AuthController) StartOidcLogin(ctx *fiber.Ctx) error {
+	response, err := c.service.StartOidcLogin(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Complete OIDC login
+// @Description Completes a login with the OpenID Connect provider. Accepts the code and state the provider redirected with, as query parameters or a JSON body, and returns the same response as /auth/login.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param code query string false "Authorization code"
+// @Param state query string false "State from /auth/oidc/authorize"
+// @Param callback body OidcCallbackDto false "Code and state"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/oidc/callback [get]
+// @Router /auth/oidc/callback [post]
+func (c *AuthController) CompleteOidcLogin(ctx *fiber.Ctx) error {
+	var dto OidcCallbackDto
+	if ctx.Method() == fiber.MethodPost {
+		if err := ctx.BodyParser(&dto); err != nil {
+			return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+		}
+	} else if err := ctx.QueryParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid query parameters"}
+	}
+
+	response, err := c.service.CompleteOidcLogin(ctx.UserContext(), dto, clientInfo(ctx))
 	if err != nil {
 		return err
 	}
@@ -1,20 +1,132 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
 )
 
 type GetTokenDto struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+// TokenResponse carries a token pair, or only a challenge token when MfaRequired
+// is set and the login must be completed at /auth/2fa/verify.
 type TokenResponse struct {
-	Message string      `json:"message"`
-	Token   string      `json:"token"`
-	User    models.User `json:"user"`
+	Message          string      `json:"message"`
+	Token            string      `json:"token,omitempty"`
+	ExpiresIn        int64       `json:"expiresIn,omitempty"`
+	RefreshToken     string      `json:"refreshToken,omitempty"`
+	RefreshExpiresAt *time.Time  `json:"refreshExpiresAt,omitempty"`
+	User             models.User `json:"user"`
+	MfaRequired      bool        `json:"mfaRequired,omitempty"`
+	ChallengeToken   string      `json:"challengeToken,omitempty"`
 }
 
 type LoginDto struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
-}
\ No newline at end of file
+	Email    string `json:"email" validate:"required,email" example:"john.doe@example.com"`
+	Password string `json:"password" validate:"required" example:"password123"`
+}
+
+// RefreshTokenDto defines the input for rotating a refresh token
+type RefreshTokenDto struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// VerifyEmailDto defines the input for verifying an email address
+type VerifyEmailDto struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendEmailVerificationDto defines the input for requesting a new verification email
+type ResendEmailVerificationDto struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"`
+}
+
+// ForgotPasswordDto defines the input for requesting a password reset email
+type ForgotPasswordDto struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"`
+}
+
+// ResetPasswordDto defines the input for setting a new password with a reset token
+type ResetPasswordDto struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=6" example:"newpassword123"`
+}
+
+// ChangePasswordDto defines the input for changing the current user's password
+type ChangePasswordDto struct {
+	CurrentPassword string `json:"currentPassword" validate:"required" example:"password123"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6" example:"newpassword123"`
+}
+
+// AddPhoneNumberDto defines the input for attaching a phone number
+type AddPhoneNumberDto struct {
+	PhoneNumber string `json:"phoneNumber" validate:"required,e164" example:"+23276123456"`
+}
+
+// VerifyPhoneNumberDto defines the input for verifying a phone number
+type VerifyPhoneNumberDto struct {
+	Code string `json:"code" validate:"required,len=6" example:"123456"`
+}
+
+// MfaCodeDto carries a TOTP code from an authenticator app
+type MfaCodeDto struct {
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+// DisableMfaDto defines the input for turning off two-factor authentication
+type DisableMfaDto struct {
+	Password string `json:"password" validate:"required" example:"password123"`
+	Code     string `json:"code" validate:"required" example:"123456"`
+}
+
+// VerifyMfaDto defines the input for completing a two-factor login
+type VerifyMfaDto struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required" example:"123456"`
+}
+
+// MfaEnrollResponse carries a new TOTP secret and its otpauth URI
+type MfaEnrollResponse struct {
+	Message    string `json:"message"`
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+// MfaRecoveryCodesResponse carries freshly issued recovery codes
+type MfaRecoveryCodesResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MessageResponse represents a response that only carries a message
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// OidcAuthorizeResponse carries the provider URL to send the user to
+type OidcAuthorizeResponse struct {
+	AuthorizationUrl string `json:"authorizationUrl"`
+	State            string `json:"state"`
+}
+
+// OidcCallbackDto is what the provider sends back to the redirect URL
+type OidcCallbackDto struct {
+	Code             string `json:"code" query:"code" validate:"required"`
+	State            string `json:"state" query:"state" validate:"required"`
+	Error            string `json:"error,omitempty" query:"error"`
+	ErrorDescription string `json:"error_description,omitempty" query:"error_description"`
+}
+
+// ClientInfo describes the client a session is issued to
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// JWKSResponse is a JSON Web Key Set of the public token signing keys
+type JWKSResponse struct {
+	Keys []utils.JWK `json:"keys"`
+}
@@ -1,38 +1,207 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/epsierra/phinex-blog-api/src/audit"
+	"github.com/epsierra/phinex-blog-api/src/mailer"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/oidc"
+	"github.com/epsierra/phinex-blog-api/src/sms"
+	"github.com/epsierra/phinex-blog-api/src/throttle"
 	"github.com/epsierra/phinex-blog-api/src/utils"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errInvalidCredentials is returned for every failed password login so callers
+// cannot tell whether an email is registered.
+var errInvalidCredentials = &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid email or password"}
+
+// minPasswordLength matches the validation on CreateUserDto
+const minPasswordLength = 6
+
+// phoneNumberPattern accepts E.164 numbers such as +23276123456
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+const (
+	// mfaRecoveryCodeCount is how many recovery codes are issued when 2FA is enabled
+	mfaRecoveryCodeCount = 10
+	// mfaChallengeMaxAttempts is how many wrong codes a login challenge survives
+	mfaChallengeMaxAttempts = 5
+	// phoneCodeDigits is the length of phone verification codes
+	phoneCodeDigits = 6
+)
+
+// errAccountBanned is returned when a banned user tries to obtain tokens.
+var errAccountBanned = &fiber.Error{Code: fiber.StatusForbidden, Message: "Account is banned"}
+
+// errRefreshTokenReused is returned inside Refresh when the presented token
+// was rotated by someone else first.
+var errRefreshTokenReused = errors.New("refresh token already rotated")
+
+// errInvalidMfaCode is returned when a TOTP or recovery code does not match.
+var errInvalidMfaCode = &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid two-factor code"}
+
+// errOneTimeTokenInvalid is returned when a one-time token is unknown, used or expired.
+var errOneTimeTokenInvalid = errors.New("invalid one-time token")
+
+// issuedRefreshToken pairs a stored refresh token with its plaintext value,
+// which is only known at issue time.
+type issuedRefreshToken struct {
+	row   models.RefreshToken
+	token string
+}
+
 // AuthService handles authentication-related operations
 type AuthService struct {
-	db        *gorm.DB
-	logger    *log.Logger
-	jwtSecret string
+	db                *gorm.DB
+	logger            *log.Logger
+	emailTokenEnabled bool
+	dummyPassword     string
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	mailer            mailer.Mailer
+	verification      oneTimeTokenPolicy
+	verificationURL   string
+	passwordReset     oneTimeTokenPolicy
+	passwordResetURL  string
+	mfaIssuer         string
+	mfaChallengeTTL   time.Duration
+	smsSender         sms.Sender
+	phoneVerification oneTimeTokenPolicy
+	phoneMaxAttempts  int
+	oidcProvider      *oidc.Provider
+	oidcStateTTL      time.Duration
+	accountLimiter    *throttle.Limiter
+	ipLimiter         *throttle.Limiter
+}
+
+// oneTimeTokenPolicy sets how long one-time tokens of a purpose live and how
+// often they may be sent to the same user.
+type oneTimeTokenPolicy struct {
+	ttl            time.Duration
+	resendInterval time.Duration
+	maxPerHour     int
 }
 
 // NewAuthService creates a new AuthService instance
 func NewAuthService(db *gorm.DB) *AuthService {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable not set")
+	logger := log.New(os.Stderr, "auth-service: ", log.LstdFlags)
+	if err := utils.LoadSigningKeys(); err != nil {
+		log.Fatal("Error loading JWT signing keys: ", err)
+	}
+
+	// The email-only token path issues a JWT without any credential, so it is
+	// only available when explicitly enabled in a development or test environment.
+	emailTokenEnabled := os.Getenv("AUTH_EMAIL_TOKEN_ENABLED") == "true" && utils.IsDevelopmentEnv()
+	if emailTokenEnabled {
+		logger.Printf("WARNING: POST /auth/token is enabled (NODE_ENV=%s); never enable it in production", os.Getenv("NODE_ENV"))
+	}
+
+	// Hash compared against when the email is unknown, so a failed login takes
+	// the same time whether or not the account exists.
+	dummyPassword, err := utils.HashData(utils.GenerateID())
+	if err != nil {
+		log.Fatal("Error preparing password hashing: ", err)
+	}
+
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = "Phinex"
+	}
+
+	emailSender, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring mailer: ", err)
+	}
+
+	smsSender, err := sms.NewSenderFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring SMS sender: ", err)
+	}
+
+	// Both limiters share one store; replace it to share counters between replicas
+	loginAttempts := throttle.NewMemoryStore()
+	accountPolicy := throttle.PolicyFromEnv("LOGIN_ACCOUNT", throttle.Policy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		Window:           time.Hour,
+	})
+	ipPolicy := throttle.PolicyFromEnv("LOGIN_IP", throttle.Policy{
+		FreeAttempts:     20,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 100,
+		LockoutDuration:  15 * time.Minute,
+		Window:           time.Hour,
+	})
+
+	var oidcProvider *oidc.Provider
+	oidcConfig, oidcEnabled, err := oidc.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring OIDC login: ", err)
 	}
+	if oidcEnabled {
+		oidcProvider = oidc.NewProvider(oidcConfig)
+	}
+
 	return &AuthService{
-		db:        db,
-		logger:    log.New(os.Stderr, "auth-service: ", log.LstdFlags),
-		jwtSecret: jwtSecret,
+		db:                db,
+		logger:            logger,
+		emailTokenEnabled: emailTokenEnabled,
+		dummyPassword:     dummyPassword,
+		accessTokenTTL:    utils.GetEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		refreshTokenTTL:   utils.GetEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		mailer:            emailSender,
+		verification: oneTimeTokenPolicy{
+			ttl:            utils.GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			resendInterval: utils.GetEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+			maxPerHour:     utils.GetEnvInt("EMAIL_VERIFICATION_MAX_PER_HOUR", 5),
+		},
+		verificationURL: os.Getenv("EMAIL_VERIFICATION_URL"),
+		passwordReset: oneTimeTokenPolicy{
+			ttl:            utils.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			resendInterval: utils.GetEnvDuration("PASSWORD_RESET_RESEND_INTERVAL", time.Minute),
+			maxPerHour:     utils.GetEnvInt("PASSWORD_RESET_MAX_PER_HOUR", 5),
+		},
+		passwordResetURL: os.Getenv("PASSWORD_RESET_URL"),
+		mfaIssuer:        mfaIssuer,
+		mfaChallengeTTL:  utils.GetEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		smsSender:        smsSender,
+		phoneVerification: oneTimeTokenPolicy{
+			ttl:            utils.GetEnvDuration("PHONE_OTP_TTL", 10*time.Minute),
+			resendInterval: utils.GetEnvDuration("PHONE_OTP_RESEND_INTERVAL", time.Minute),
+			maxPerHour:     utils.GetEnvInt("PHONE_OTP_MAX_PER_HOUR", 5),
+		},
+		phoneMaxAttempts: utils.GetEnvInt("PHONE_OTP_MAX_ATTEMPTS", 5),
+		oidcProvider:     oidcProvider,
+		oidcStateTTL:     utils.GetEnvDuration("OIDC_STATE_TTL", 10*time.Minute),
+		accountLimiter:   throttle.NewLimiter(loginAttempts, accountPolicy, "account:"),
+		ipLimiter:        throttle.NewLimiter(loginAttempts, ipPolicy, "ip:"),
 	}
 }
 
 // GetTokenByEmail generates JWT token for a verified user by email.
+// It is only available in development/test mode (see AUTH_EMAIL_TOKEN_ENABLED).
 func (s *AuthService) GetTokenByEmail(email string) (TokenResponse, error) {
+	if !s.emailTokenEnabled {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "Email token login is disabled"}
+	}
+
 	var user models.User
 	err := s.db.Preload("UserRoles.Role").Where(&models.User{Email: email}).First(&user).Error
 	if err != nil {
@@ -46,7 +215,149 @@ func (s *AuthService) GetTokenByEmail(email string) (TokenResponse, error) {
 	if !user.Verified {
 		return TokenResponse{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "User is not verified"}
 	}
+	if user.EffectiveStatus() == models.UserStatusBanned {
+		return TokenResponse{}, errAccountBanned
+	}
+
+	return s.issueTokens(user, ClientInfo{})
+}
+
+// RequestTokenByEmail is GetTokenByEmail for requests to POST /auth/token,
+// throttled per client IP and per account like a password login.
+func (s *AuthService) RequestTokenByEmail(dto GetTokenDto, client ClientInfo) (TokenResponse, error) {
+	if !s.emailTokenEnabled {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "Email token login is disabled"}
+	}
+	return s.guardLogin(client, dto.Email, func() (TokenResponse, error) {
+		return s.GetTokenByEmail(dto.Email)
+	})
+}
+
+// Login authenticates a user by email and password and returns an access/refresh token pair.
+func (s *AuthService) Login(dto LoginDto, client ClientInfo) (TokenResponse, error) {
+	if dto.Email == "" || dto.Password == "" {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Email and password are required"}
+	}
+	return s.guardLogin(client, dto.Email, func() (TokenResponse, error) {
+		return s.login(dto, client)
+	})
+}
+
+// login checks the credentials for Login
+func (s *AuthService) login(dto LoginDto, client ClientInfo) (TokenResponse, error) {
+	var user models.User
+	err := s.db.Preload("UserRoles.Role").Where(&models.User{Email: dto.Email}).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Burn the same bcrypt cost as a real comparison
+			utils.MatchWithHashedData(dto.Password, s.dummyPassword)
+			return TokenResponse{}, errInvalidCredentials
+		}
+		s.logger.Printf("Error fetching user by email: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+
+	hashedPassword, err := s.findPasswordHash(user.UserId)
+	if err != nil {
+		s.logger.Printf("Error fetching password hash: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+
+	if ok, _ := utils.MatchWithHashedData(dto.Password, hashedPassword); !ok {
+		return TokenResponse{}, errInvalidCredentials
+	}
+
+	if !user.Verified {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "User is not verified"}
+	}
+	if user.EffectiveStatus() == models.UserStatusBanned {
+		return TokenResponse{}, errAccountBanned
+	}
 
+	mfaEnabled, err := userHasMfa(s.db, user.UserId)
+	if err != nil {
+		s.logger.Printf("Error fetching two-factor settings: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+	if mfaEnabled {
+		return s.mfaChallenge(user)
+	}
+
+	return s.issueTokens(user, client)
+}
+
+// mfaChallenge starts the second login step for a user with 2FA. The returned
+// challenge token is exchanged for real tokens at /auth/2fa/verify.
+func (s *AuthService) mfaChallenge(user models.User) (TokenResponse, error) {
+	challengeToken, err := s.createOneTimeToken(user, models.OneTimeTokenPurposeMfaChallenge, user.Email, s.mfaChallengeTTL)
+	if err != nil {
+		s.logger.Printf("Error creating two-factor challenge: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+	return TokenResponse{
+		Message:        "Two-factor authentication required",
+		MfaRequired:    true,
+		ChallengeToken: challengeToken,
+	}, nil
+}
+
+// findPasswordHash reads the stored password hash directly, since the User
+// AfterFind hook blanks the password on every model load.
+func (s *AuthService) findPasswordHash(userId string) (string, error) {
+	var hashedPassword string
+	err := s.db.Model(&models.User{}).Select("password").Where("user_id = ?", userId).Row().Scan(&hashedPassword)
+	return hashedPassword, err
+}
+
+// issueTokens starts a new session for the given user, who must have
+// UserRoles.Role preloaded, and returns its first access/refresh token pair.
+func (s *AuthService) issueTokens(user models.User, client ClientInfo) (TokenResponse, error) {
+	var response TokenResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		refreshToken, err := s.createRefreshToken(tx, user, utils.GenerateID(), client)
+		if err != nil {
+			return err
+		}
+		response, err = s.buildTokenResponse(user, refreshToken)
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error issuing tokens: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to generate token"}
+	}
+	return response, nil
+}
+
+// createRefreshToken stores a new refresh token in the given family. Only its
+// hash is persisted; the plaintext is returned so it can be handed out once.
+func (s *AuthService) createRefreshToken(tx *gorm.DB, user models.User, familyId string, client ClientInfo) (issuedRefreshToken, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return issuedRefreshToken{}, err
+	}
+
+	now := time.Now().UTC()
+	row := models.RefreshToken{
+		RefreshTokenId: utils.GenerateID(),
+		UserId:         user.UserId,
+		FamilyId:       familyId,
+		TokenHash:      utils.HashToken(token),
+		ExpiresAt:      now.Add(s.refreshTokenTTL),
+		IP:             client.IP,
+		UserAgent:      client.UserAgent,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		CreatedBy:      user.FullName,
+		UpdatedBy:      user.FullName,
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return issuedRefreshToken{}, err
+	}
+	return issuedRefreshToken{row: row, token: token}, nil
+}
+
+// buildTokenResponse signs a short-lived access token bound to the refresh token's session.
+func (s *AuthService) buildTokenResponse(user models.User, refreshToken issuedRefreshToken) (TokenResponse, error) {
 	// Prepare claims
 	roles := []string{}
 	for _, userRole := range user.UserRoles {
@@ -58,18 +369,1341 @@ func (s *AuthService) GetTokenByEmail(email string) (TokenResponse, error) {
 		"isAuthenticated": true,
 		"email":           user.Email,
 		"roles":           roles,
-	})
+		"sid":             refreshToken.row.FamilyId,
+		"typ":             utils.TokenTypeAccess,
+	}, s.accessTokenTTL)
 	if err != nil {
-		s.logger.Printf("Error signing token: %v", err)
-		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to generate token"}
+		return TokenResponse{}, err
 	}
 
 	// Exclude password from user object
 	user.Password = ""
 
 	return TokenResponse{
-		Message: "Token generated successfully",
-		Token:   tokenString,
-		User:    user,
+		Message:          "Token generated successfully",
+		Token:            tokenString,
+		ExpiresIn:        int64(s.accessTokenTTL.Seconds()),
+		RefreshToken:     refreshToken.token,
+		RefreshExpiresAt: &refreshToken.row.ExpiresAt,
+		User:             user,
 	}, nil
 }
+
+// Refresh exchanges a refresh token for a new token pair. The presented token is
+// revoked; presenting an already rotated token revokes its whole family, since
+// that means the token was copied. That includes losing a race to rotate it:
+// of two concurrent refreshes with one token, only the first gets new tokens
+// and the session is then ended for both.
+func (s *AuthService) Refresh(dto RefreshTokenDto, client ClientInfo) (TokenResponse, error) {
+	invalidToken := &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid or expired refresh token"}
+	if dto.RefreshToken == "" {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Refresh token is required"}
+	}
+
+	var current models.RefreshToken
+	if err := s.db.Where("token_hash = ?", utils.HashToken(dto.RefreshToken)).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return TokenResponse{}, invalidToken
+		}
+		s.logger.Printf("Error fetching refresh token: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to refresh token"}
+	}
+
+	if current.RevokedAt != nil {
+		s.revokeReusedSession(current)
+		return TokenResponse{}, invalidToken
+	}
+	if time.Now().UTC().After(current.ExpiresAt) {
+		return TokenResponse{}, invalidToken
+	}
+
+	var response TokenResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Preload("UserRoles.Role").Where("user_id = ?", current.UserId).First(&user).Error; err != nil {
+			return err
+		}
+		if user.EffectiveStatus() == models.UserStatusBanned {
+			return errAccountBanned
+		}
+
+		next, err := s.createRefreshToken(tx, user, current.FamilyId, client)
+		if err != nil {
+			return err
+		}
+
+		// Only one concurrent refresh may win the rotation
+		result := tx.Model(&models.RefreshToken{}).
+			Where("refresh_token_id = ? AND revoked_at IS NULL", current.RefreshTokenId).
+			Updates(map[string]interface{}{
+				"revoked_at":  time.Now().UTC(),
+				"replaced_by": next.row.RefreshTokenId,
+				"updated_at":  time.Now().UTC(),
+				"updated_by":  user.FullName,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		response, err = s.buildTokenResponse(user, next)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errRefreshTokenReused) {
+			s.revokeReusedSession(current)
+			return TokenResponse{}, invalidToken
+		}
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return TokenResponse{}, fiberErr
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return TokenResponse{}, invalidToken
+		}
+		s.logger.Printf("Error refreshing token: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to refresh token"}
+	}
+
+	response.Message = "Token refreshed successfully"
+	return response, nil
+}
+
+// revokeReusedSession ends the session of a refresh token that was presented
+// after it had been rotated. Errors are only logged.
+func (s *AuthService) revokeReusedSession(token models.RefreshToken) {
+	s.logger.Printf("Refresh token reuse detected for user %s, revoking session %s", token.UserId, token.FamilyId)
+	if err := revokeSession(s.db, token.FamilyId, "system"); err != nil {
+		s.logger.Printf("Error revoking session: %v", err)
+	}
+}
+
+// SendEmailVerification emails the user a single-use verification token. Earlier
+// unused tokens are invalidated, so only the latest email can be used.
+func (s *AuthService) SendEmailVerification(user models.User) error {
+	token, err := s.createOneTimeToken(user, models.OneTimeTokenPurposeEmailVerification, user.Email, s.verification.ttl)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nVerification token: %s\n", user.FullName, token)
+	if s.verificationURL != "" {
+		body += fmt.Sprintf("\nOr open this link to verify your email:\n%s?token=%s\n", s.verificationURL, token)
+	}
+	body += fmt.Sprintf("\nThe token expires in %s. If you did not create an account, ignore this email.\n", s.verification.ttl)
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    body,
+	})
+}
+
+// VerifyEmail consumes an email verification token and marks its user as verified.
+func (s *AuthService) VerifyEmail(dto VerifyEmailDto) (MessageResponse, error) {
+	invalidToken := &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid or expired verification token"}
+	if dto.Token == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Verification token is required"}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		row, err := consumeOneTimeToken(tx, models.OneTimeTokenPurposeEmailVerification, dto.Token)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.Where("user_id = ?", row.UserId).First(&user).Error; err != nil {
+			return err
+		}
+		// The token only proves ownership of the address it was sent to
+		if user.Email != row.Target {
+			return errOneTimeTokenInvalid
+		}
+
+		return tx.Model(&models.User{}).Where("user_id = ?", user.UserId).Updates(map[string]interface{}{
+			"verified":          true,
+			"email_is_verified": true,
+			"updated_at":        time.Now().UTC(),
+			"updated_by":        user.FullName,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errOneTimeTokenInvalid) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return MessageResponse{}, invalidToken
+		}
+		s.logger.Printf("Error verifying email: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify email"}
+	}
+
+	return MessageResponse{Message: "Email verified successfully"}, nil
+}
+
+// ResendEmailVerification sends a new verification email. The response is the
+// same whether or not the email is registered, verified or rate limited.
+func (s *AuthService) ResendEmailVerification(dto ResendEmailVerificationDto) (MessageResponse, error) {
+	response := MessageResponse{Message: "If the email belongs to an unverified account, a verification email has been sent"}
+	if dto.Email == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Email is required"}
+	}
+
+	var user models.User
+	if err := s.db.Where(&models.User{Email: dto.Email}).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Printf("Error fetching user by email: %v", err)
+		}
+		return response, nil
+	}
+	if user.Verified && user.EmailIsVerified {
+		return response, nil
+	}
+
+	throttled, err := s.oneTimeTokenThrottled(user.UserId, models.OneTimeTokenPurposeEmailVerification, s.verification)
+	if err != nil {
+		s.logger.Printf("Error checking verification rate limit: %v", err)
+		return response, nil
+	}
+	if throttled {
+		s.logger.Printf("Verification email rate limit reached for user %s", user.UserId)
+		return response, nil
+	}
+
+	if err := s.SendEmailVerification(user); err != nil {
+		s.logger.Printf("Error sending verification email: %v", err)
+	}
+	return response, nil
+}
+
+// ForgotPassword emails a single-use password reset token. The response is the
+// same whether or not the email is registered or rate limited.
+func (s *AuthService) ForgotPassword(dto ForgotPasswordDto) (MessageResponse, error) {
+	response := MessageResponse{Message: "If the email is registered, a password reset email has been sent"}
+	if dto.Email == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Email is required"}
+	}
+
+	var user models.User
+	if err := s.db.Where(&models.User{Email: dto.Email}).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Printf("Error fetching user by email: %v", err)
+		}
+		return response, nil
+	}
+
+	throttled, err := s.oneTimeTokenThrottled(user.UserId, models.OneTimeTokenPurposePasswordReset, s.passwordReset)
+	if err != nil {
+		s.logger.Printf("Error checking password reset rate limit: %v", err)
+		return response, nil
+	}
+	if throttled {
+		s.logger.Printf("Password reset rate limit reached for user %s", user.UserId)
+		return response, nil
+	}
+
+	token, err := s.createOneTimeToken(user, models.OneTimeTokenPurposePasswordReset, user.Email, s.passwordReset.ttl)
+	if err != nil {
+		s.logger.Printf("Error creating password reset token: %v", err)
+		return response, nil
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nPassword reset token: %s\n", user.FullName, token)
+	if s.passwordResetURL != "" {
+		body += fmt.Sprintf("\nOr open this link to choose a new password:\n%s?token=%s\n", s.passwordResetURL, token)
+	}
+	body += fmt.Sprintf("\nThe token expires in %s. If you did not ask to reset your password, ignore this email.\n", s.passwordReset.ttl)
+
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+	if err != nil {
+		s.logger.Printf("Error sending password reset email: %v", err)
+	}
+	return response, nil
+}
+
+// ResetPassword sets a new password using a password reset token and revokes
+// every session of the user.
+func (s *AuthService) ResetPassword(dto ResetPasswordDto) (MessageResponse, error) {
+	if dto.Token == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Reset token is required"}
+	}
+	if len(dto.NewPassword) < minPasswordLength {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Password must be at least %d characters", minPasswordLength)}
+	}
+
+	hashedPassword, err := utils.HashData(dto.NewPassword)
+	if err != nil {
+		s.logger.Printf("Error hashing password: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to hash password"}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		row, err := consumeOneTimeToken(tx, models.OneTimeTokenPurposePasswordReset, dto.Token)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.Where("user_id = ?", row.UserId).First(&user).Error; err != nil {
+			return err
+		}
+		if user.Email != row.Target {
+			return errOneTimeTokenInvalid
+		}
+
+		if err := s.setPassword(tx, user, hashedPassword); err != nil {
+			return err
+		}
+		return RevokeUserSessions(tx, user.UserId, user.FullName)
+	})
+	if err != nil {
+		if errors.Is(err, errOneTimeTokenInvalid) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid or expired reset token"}
+		}
+		s.logger.Printf("Error resetting password: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to reset password"}
+	}
+
+	return MessageResponse{Message: "Password reset successfully"}, nil
+}
+
+// ChangePassword replaces the current user's password after checking the
+// current one. Every other session of the user is revoked.
+func (s *AuthService) ChangePassword(dto ChangePasswordDto, currentUser models.ICurrentUser) (MessageResponse, error) {
+	if dto.CurrentPassword == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Current password is required"}
+	}
+	if len(dto.NewPassword) < minPasswordLength {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Password must be at least %d characters", minPasswordLength)}
+	}
+
+	currentHash, err := s.findPasswordHash(currentUser.UserId)
+	if err != nil {
+		s.logger.Printf("Error fetching password hash: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to change password"}
+	}
+	if ok, _ := utils.MatchWithHashedData(dto.CurrentPassword, currentHash); !ok {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Current password is incorrect"}
+	}
+
+	hashedPassword, err := utils.HashData(dto.NewPassword)
+	if err != nil {
+		s.logger.Printf("Error hashing password: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to hash password"}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("user_id = ?", currentUser.UserId).First(&user).Error; err != nil {
+			return err
+		}
+		if err := s.setPassword(tx, user, hashedPassword); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", user.UserId, currentUser.SessionId).
+			Updates(map[string]interface{}{
+				"revoked_at": time.Now().UTC(),
+				"updated_at": time.Now().UTC(),
+				"updated_by": user.FullName,
+			}).Error
+	})
+	if err != nil {
+		s.logger.Printf("Error changing password: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to change password"}
+	}
+
+	return MessageResponse{Message: "Password changed successfully"}, nil
+}
+
+// setPassword stores a new password hash and invalidates any pending reset tokens.
+func (s *AuthService) setPassword(tx *gorm.DB, user models.User, hashedPassword string) error {
+	now := time.Now().UTC()
+	err := tx.Model(&models.User{}).Where("user_id = ?", user.UserId).Updates(map[string]interface{}{
+		"password":   hashedPassword,
+		"updated_at": now,
+		"updated_by": user.FullName,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", user.UserId, models.OneTimeTokenPurposePasswordReset).
+		Updates(map[string]interface{}{"consumed_at": now, "updated_at": now, "updated_by": user.FullName}).Error
+}
+
+// AddPhoneNumber sets the current user's phone number as unverified and texts
+// it a verification code.
+func (s *AuthService) AddPhoneNumber(dto AddPhoneNumberDto, currentUser models.ICurrentUser) (MessageResponse, error) {
+	phoneNumber := strings.ReplaceAll(strings.TrimSpace(dto.PhoneNumber), " ", "")
+	if !phoneNumberPattern.MatchString(phoneNumber) {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Phone number must be in international format, e.g. +23276123456"}
+	}
+
+	var taken int64
+	err := s.db.Model(&models.User{}).
+		Where("phone_number = ? AND phone_number_is_verified = ? AND user_id <> ?", phoneNumber, true, currentUser.UserId).
+		Count(&taken).Error
+	if err != nil {
+		s.logger.Printf("Error checking phone number: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+	if taken > 0 {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusConflict, Message: "Phone number is already in use"}
+	}
+
+	throttled, err := s.oneTimeTokenThrottled(currentUser.UserId, models.OneTimeTokenPurposePhoneVerification, s.phoneVerification)
+	if err != nil {
+		s.logger.Printf("Error checking phone verification rate limit: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+	if throttled {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusTooManyRequests, Message: "Too many verification codes requested, try again later"}
+	}
+
+	var user models.User
+	if err := s.db.Where("user_id = ?", currentUser.UserId).First(&user).Error; err != nil {
+		s.logger.Printf("Error fetching user: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+
+	code, err := utils.GenerateNumericCode(phoneCodeDigits)
+	if err != nil {
+		s.logger.Printf("Error generating verification code: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+	codeHash, err := utils.HashData(code)
+	if err != nil {
+		s.logger.Printf("Error hashing verification code: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+
+	err = s.db.Model(&models.User{}).Where("user_id = ?", user.UserId).Updates(map[string]interface{}{
+		"phone_number":             phoneNumber,
+		"phone_number_is_verified": false,
+		"updated_at":               time.Now().UTC(),
+		"updated_by":               currentUser.FullName,
+	}).Error
+	if err != nil {
+		s.logger.Printf("Error updating phone number: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+	if err := s.storeOneTimeToken(user, models.OneTimeTokenPurposePhoneVerification, phoneNumber, codeHash, s.phoneVerification.ttl); err != nil {
+		s.logger.Printf("Error storing verification code: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add phone number"}
+	}
+
+	err = s.smsSender.Send(sms.Message{
+		To:   phoneNumber,
+		Body: fmt.Sprintf("Your Phinex verification code is %s. It expires in %s.", code, s.phoneVerification.ttl),
+	})
+	if err != nil {
+		s.logger.Printf("Error sending verification code: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadGateway, Message: "Failed to send verification code"}
+	}
+
+	return MessageResponse{Message: "Verification code sent"}, nil
+}
+
+// VerifyPhoneNumber checks the code texted by AddPhoneNumber. A code survives a
+// limited number of wrong guesses.
+func (s *AuthService) VerifyPhoneNumber(dto VerifyPhoneNumberDto, currentUser models.ICurrentUser) (MessageResponse, error) {
+	invalidCode := &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid or expired verification code"}
+	if dto.Code == "" {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Verification code is required"}
+	}
+
+	var row models.OneTimeToken
+	err := s.db.Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", currentUser.UserId, models.OneTimeTokenPurposePhoneVerification).
+		Order("created_at DESC").
+		First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return MessageResponse{}, invalidCode
+		}
+		s.logger.Printf("Error fetching verification code: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify phone number"}
+	}
+	if time.Now().UTC().After(row.ExpiresAt) || row.Attempts >= s.phoneMaxAttempts {
+		return MessageResponse{}, invalidCode
+	}
+
+	if ok, _ := utils.MatchWithHashedData(strings.TrimSpace(dto.Code), row.TokenHash); !ok {
+		err := s.db.Model(&models.OneTimeToken{}).
+			Where("one_time_token_id = ?", row.OneTimeTokenId).
+			Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "updated_at": time.Now().UTC()}).Error
+		if err != nil {
+			s.logger.Printf("Error recording verification attempt: %v", err)
+		}
+		return MessageResponse{}, invalidCode
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		result := tx.Model(&models.OneTimeToken{}).
+			Where("one_time_token_id = ? AND consumed_at IS NULL", row.OneTimeTokenId).
+			Updates(map[string]interface{}{"consumed_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOneTimeTokenInvalid
+		}
+
+		// The code only proves ownership of the number it was sent to
+		result = tx.Model(&models.User{}).
+			Where("user_id = ? AND phone_number = ?", currentUser.UserId, row.Target).
+			Updates(map[string]interface{}{
+				"phone_number_is_verified": true,
+				"updated_at":               now,
+				"updated_by":               currentUser.FullName,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOneTimeTokenInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errOneTimeTokenInvalid) {
+			return MessageResponse{}, invalidCode
+		}
+		s.logger.Printf("Error verifying phone number: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify phone number"}
+	}
+
+	return MessageResponse{Message: "Phone number verified successfully"}, nil
+}
+
+// EnrollMfa generates a new TOTP secret for the current user. It only takes
+// effect once confirmed with a code from the authenticator app.
+func (s *AuthService) EnrollMfa(currentUser models.ICurrentUser) (MfaEnrollResponse, error) {
+	var mfa models.UserMfa
+	err := s.db.Where("user_id = ?", currentUser.UserId).First(&mfa).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("Error fetching two-factor settings: %v", err)
+		return MfaEnrollResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to enroll two-factor authentication"}
+	}
+	if err == nil && mfa.Enabled {
+		return MfaEnrollResponse{}, &fiber.Error{Code: fiber.StatusConflict, Message: "Two-factor authentication is already enabled"}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.Printf("Error generating TOTP secret: %v", err)
+		return MfaEnrollResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to enroll two-factor authentication"}
+	}
+
+	now := time.Now().UTC()
+	if mfa.UserMfaId == "" {
+		mfa = models.UserMfa{
+			UserMfaId: utils.GenerateID(),
+			UserId:    currentUser.UserId,
+			CreatedAt: now,
+			CreatedBy: currentUser.FullName,
+		}
+	}
+	mfa.Secret = secret
+	mfa.LastUsedStep = 0
+	mfa.UpdatedAt = now
+	mfa.UpdatedBy = currentUser.FullName
+	if err := s.db.Save(&mfa).Error; err != nil {
+		s.logger.Printf("Error saving two-factor settings: %v", err)
+		return MfaEnrollResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to enroll two-factor authentication"}
+	}
+
+	return MfaEnrollResponse{
+		Message:    "Scan the QR code with your authenticator app, then confirm with a code",
+		Secret:     secret,
+		OtpauthURI: utils.TOTPURI(s.mfaIssuer, currentUser.Email, secret),
+	}, nil
+}
+
+// ConfirmMfa enables 2FA after checking a first code against the pending
+// secret, and returns the recovery codes. They are only shown this once.
+func (s *AuthService) ConfirmMfa(dto MfaCodeDto, currentUser models.ICurrentUser) (MfaRecoveryCodesResponse, error) {
+	var mfa models.UserMfa
+	if err := s.db.Where("user_id = ? AND enabled = ?", currentUser.UserId, false).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return MfaRecoveryCodesResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "No pending two-factor enrollment"}
+		}
+		s.logger.Printf("Error fetching two-factor settings: %v", err)
+		return MfaRecoveryCodesResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to confirm two-factor authentication"}
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		ok, err := useTOTPCode(tx, mfa, dto.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidMfaCode
+		}
+
+		now := time.Now().UTC()
+		err = tx.Model(&models.UserMfa{}).Where("user_mfa_id = ?", mfa.UserMfaId).Updates(map[string]interface{}{
+			"enabled":      true,
+			"confirmed_at": now,
+			"updated_at":   now,
+			"updated_by":   currentUser.FullName,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, currentUser)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidMfaCode) {
+			return MfaRecoveryCodesResponse{}, errInvalidMfaCode
+		}
+		s.logger.Printf("Error confirming two-factor authentication: %v", err)
+		return MfaRecoveryCodesResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to confirm two-factor authentication"}
+	}
+
+	return MfaRecoveryCodesResponse{
+		Message:       "Two-factor authentication enabled. Store these recovery codes somewhere safe",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// DisableMfa turns 2FA off. It requires both the password and a current code
+// or recovery code, so a stolen session alone cannot remove it.
+func (s *AuthService) DisableMfa(dto DisableMfaDto, currentUser models.ICurrentUser) (MessageResponse, error) {
+	hashedPassword, err := s.findPasswordHash(currentUser.UserId)
+	if err != nil {
+		s.logger.Printf("Error fetching password hash: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to disable two-factor authentication"}
+	}
+	if ok, _ := utils.MatchWithHashedData(dto.Password, hashedPassword); !ok {
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Password is incorrect"}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.RequireSecondFactor(tx, currentUser, dto.Code); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", currentUser.UserId).Delete(&models.MfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", currentUser.UserId).Delete(&models.UserMfa{}).Error
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MessageResponse{}, fiberErr
+		}
+		s.logger.Printf("Error disabling two-factor authentication: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to disable two-factor authentication"}
+	}
+
+	return MessageResponse{Message: "Two-factor authentication disabled"}, nil
+}
+
+// VerifyMfa completes a login started by Login for a user with 2FA, exchanging
+// the challenge token and a TOTP or recovery code for a token pair. Wrong codes
+// count towards the lockout of the challenge's account as well as the client IP,
+// so a fresh challenge from another password login does not buy more guesses.
+func (s *AuthService) VerifyMfa(dto VerifyMfaDto, client ClientInfo) (TokenResponse, error) {
+	if dto.ChallengeToken == "" || dto.Code == "" {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Challenge token and code are required"}
+	}
+	account, err := s.mfaChallengeAccount(dto.ChallengeToken)
+	if err != nil {
+		s.logger.Printf("Error fetching two-factor challenge: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify two-factor code"}
+	}
+	return s.guardLogin(client, account, func() (TokenResponse, error) {
+		return s.verifyMfa(dto, client)
+	})
+}
+
+// mfaChallengeAccount returns the email of the user a challenge was issued to,
+// or an empty string for an unknown challenge
+func (s *AuthService) mfaChallengeAccount(challengeToken string) (string, error) {
+	var emails []string
+	err := s.db.Model(&models.OneTimeToken{}).
+		Joins("JOIN users ON users.user_id = one_time_tokens.user_id").
+		Where("one_time_tokens.token_hash = ? AND one_time_tokens.purpose = ?", utils.HashToken(challengeToken), models.OneTimeTokenPurposeMfaChallenge).
+		Limit(1).Pluck("users.email", &emails).Error
+	if err != nil || len(emails) == 0 {
+		return "", err
+	}
+	return emails[0], nil
+}
+
+// verifyMfa checks the challenge and code for VerifyMfa
+func (s *AuthService) verifyMfa(dto VerifyMfaDto, client ClientInfo) (TokenResponse, error) {
+	invalidChallenge := &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid or expired two-factor challenge"}
+
+	// Each guess claims one of the challenge's attempts before the code is
+	// checked. The claim locks the challenge until the transaction ends, so
+	// concurrent guesses queue up and cannot exceed the cap, and the code and
+	// the challenge are used up together.
+	var challenge models.OneTimeToken
+	var valid bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		claimed := tx.Model(&challenge).
+			Clauses(clause.Returning{}).
+			Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ? AND attempts < ?",
+				utils.HashToken(dto.ChallengeToken), models.OneTimeTokenPurposeMfaChallenge, now, mfaChallengeMaxAttempts).
+			Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "updated_at": now})
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			return errOneTimeTokenInvalid
+		}
+
+		var err error
+		valid, err = verifySecondFactor(tx, challenge.UserId, dto.Code)
+		if err != nil || !valid {
+			return err
+		}
+		_, err = consumeOneTimeToken(tx, models.OneTimeTokenPurposeMfaChallenge, dto.ChallengeToken)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errOneTimeTokenInvalid) {
+			return TokenResponse{}, invalidChallenge
+		}
+		s.logger.Printf("Error verifying two-factor code: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify two-factor code"}
+	}
+	if !valid {
+		return TokenResponse{}, errInvalidMfaCode
+	}
+
+	var user models.User
+	if err := s.db.Preload("UserRoles.Role").Where("user_id = ?", challenge.UserId).First(&user).Error; err != nil {
+		s.logger.Printf("Error fetching user: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to verify two-factor code"}
+	}
+	if user.EffectiveStatus() == models.UserStatusBanned {
+		return TokenResponse{}, errAccountBanned
+	}
+	return s.issueTokens(user, client)
+}
+
+// guardLogin runs a login attempt unless the client IP or the account is backing
+// off or locked out. The attempt is counted as a failure for both before it
+// runs, so parallel attempts cannot all slip past the same check; the count is
+// taken back unless the credentials turn out to be wrong. Only an attempt that
+// issues tokens clears the account's failures: a correct password that still
+// needs a second factor leaves them in place. An empty account throttles the
+// IP only.
+func (s *AuthService) guardLogin(client ClientInfo, account string, attempt func() (TokenResponse, error)) (TokenResponse, error) {
+	account = strings.ToLower(strings.TrimSpace(account))
+	now := time.Now().UTC()
+
+	limits := s.loginLimits(client, account)
+	for i, limit := range limits {
+		wait, locked, err := limit.limiter.Attempt(limit.key, now)
+		if err != nil {
+			s.cancelLoginAttempts(limits[:i])
+			s.logger.Printf("Error checking login attempts: %v", err)
+			return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+		}
+		if wait > 0 {
+			s.cancelLoginAttempts(limits[:i])
+			return TokenResponse{}, tooManyLoginAttempts(wait, locked)
+		}
+	}
+
+	response, err := attempt()
+	if fiberErr, ok := err.(*fiber.Error); ok && isFailedLogin(fiberErr) {
+		for _, limit := range limits {
+			s.countLoginFailure(limit, client, now)
+		}
+		return response, err
+	}
+	s.cancelLoginAttempts(limits)
+	if err == nil && !response.MfaRequired && account != "" {
+		if err := s.accountLimiter.Reset(account); err != nil {
+			s.logger.Printf("Error clearing login attempts: %v", err)
+		}
+	}
+	return response, err
+}
+
+// loginLimit is one limiter and the key a login attempt is counted under
+type loginLimit struct {
+	limiter    *throttle.Limiter
+	key        string
+	targetType string
+}
+
+// loginLimits returns the limits that apply to a login attempt
+func (s *AuthService) loginLimits(client ClientInfo, account string) []loginLimit {
+	var limits []loginLimit
+	if client.IP != "" {
+		limits = append(limits, loginLimit{limiter: s.ipLimiter, key: client.IP, targetType: audit.TargetTypeIP})
+	}
+	if account != "" {
+		limits = append(limits, loginLimit{limiter: s.accountLimiter, key: account, targetType: audit.TargetTypeAccount})
+	}
+	return limits
+}
+
+// cancelLoginAttempts takes back the failures counted up front for a login
+// that did not fail. Errors are only logged.
+func (s *AuthService) cancelLoginAttempts(limits []loginLimit) {
+	for _, limit := range limits {
+		if err := limit.limiter.Cancel(limit.key); err != nil {
+			s.logger.Printf("Error cancelling login attempt: %v", err)
+		}
+	}
+}
+
+// countLoginFailure settles a failed login and records a lockout when it
+// triggers one. Errors are only logged so the caller still sees the failure.
+func (s *AuthService) countLoginFailure(limit loginLimit, client ClientInfo, now time.Time) {
+	record, locked, err := limit.limiter.Fail(limit.key, now)
+	if err != nil {
+		s.logger.Printf("Error counting failed login: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	s.logger.Printf("Locked out %s %s after %d failed logins", limit.targetType, limit.key, record.Failures)
+	details := map[string]interface{}{
+		"failures":    record.Failures,
+		"lockedUntil": record.LockedUntil,
+	}
+	if err := audit.Record(s.db, models.ICurrentUser{IP: client.IP}, audit.ActionLoginLockedOut, limit.targetType, limit.key, details); err != nil {
+		s.logger.Printf("Error recording lockout: %v", err)
+	}
+}
+
+// isFailedLogin reports whether an error means the credentials were wrong, as
+// opposed to a bad request or a server error
+func isFailedLogin(err *fiber.Error) bool {
+	return err.Code == fiber.StatusUnauthorized || err.Code == fiber.StatusNotFound
+}
+
+// tooManyLoginAttempts is returned while a login is backing off or locked out
+func tooManyLoginAttempts(wait time.Duration, locked bool) *fiber.Error {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if locked {
+		return &fiber.Error{Code: fiber.StatusTooManyRequests, Message: fmt.Sprintf("Too many failed login attempts, account locked. Try again in %d seconds", seconds)}
+	}
+	return &fiber.Error{Code: fiber.StatusTooManyRequests, Message: fmt.Sprintf("Too many failed login attempts, try again in %d seconds", seconds)}
+}
+
+// UnlockAccount clears the failed logins and any lockout of an account
+func (s *AuthService) UnlockAccount(email string) error {
+	return s.accountLimiter.Reset(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// RequireSecondFactor checks a fresh TOTP or recovery code for sensitive
+// actions. Users without 2FA enabled pass without a code. Codes are throttled
+// like logins, per client IP and per account, so a stolen session cannot guess
+// them without running into the account's lockout.
+func (s *AuthService) RequireSecondFactor(tx *gorm.DB, currentUser models.ICurrentUser, code string) error {
+	enabled, err := userHasMfa(tx, currentUser.UserId)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	if code == "" {
+		return &fiber.Error{Code: fiber.StatusForbidden, Message: "A two-factor code is required for this action"}
+	}
+
+	_, err = s.guardLogin(ClientInfo{IP: currentUser.IP}, currentUser.Email, func() (TokenResponse, error) {
+		valid, err := verifySecondFactor(tx, currentUser.UserId, code)
+		if err != nil {
+			return TokenResponse{}, err
+		}
+		if !valid {
+			return TokenResponse{}, errInvalidMfaCode
+		}
+		return TokenResponse{}, nil
+	})
+	return err
+}
+
+// userHasMfa reports whether the user has confirmed 2FA.
+func userHasMfa(tx *gorm.DB, userId string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.UserMfa{}).Where("user_id = ? AND enabled = ?", userId, true).Count(&count).Error
+	return count > 0, err
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func verifySecondFactor(tx *gorm.DB, userId string, code string) (bool, error) {
+	var mfa models.UserMfa
+	if err := tx.Where("user_id = ? AND enabled = ?", userId, true).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	ok, err := useTOTPCode(tx, mfa, code)
+	if err != nil || ok {
+		return ok, err
+	}
+	return useRecoveryCode(tx, userId, code)
+}
+
+// useTOTPCode validates a TOTP code and records its time step, so the same
+// code cannot be replayed while it is still valid.
+func useTOTPCode(tx *gorm.DB, mfa models.UserMfa, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+	if !ok || step <= mfa.LastUsedStep {
+		return false, nil
+	}
+
+	result := tx.Model(&models.UserMfa{}).
+		Where("user_mfa_id = ? AND last_used_step < ?", mfa.UserMfaId, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// useRecoveryCode marks a matching unused recovery code as used.
+func useRecoveryCode(tx *gorm.DB, userId string, code string) (bool, error) {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false, nil
+	}
+
+	var recoveryCodes []models.MfaRecoveryCode
+	if err := tx.Where("user_id = ? AND used_at IS NULL", userId).Find(&recoveryCodes).Error; err != nil {
+		return false, err
+	}
+	for _, recoveryCode := range recoveryCodes {
+		if ok, _ := utils.MatchWithHashedData(code, recoveryCode.CodeHash); !ok {
+			continue
+		}
+		result := tx.Model(&models.MfaRecoveryCode{}).
+			Where("mfa_recovery_code_id = ? AND used_at IS NULL", recoveryCode.MfaRecoveryCodeId).
+			Updates(map[string]interface{}{"used_at": time.Now().UTC(), "updated_at": time.Now().UTC()})
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and stores a new set,
+// returning them in plaintext.
+func replaceRecoveryCodes(tx *gorm.DB, currentUser models.ICurrentUser) ([]string, error) {
+	if err := tx.Where("user_id = ?", currentUser.UserId).Delete(&models.MfaRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+		codeHash, err := utils.HashData(code)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.Create(&models.MfaRecoveryCode{
+			MfaRecoveryCodeId: utils.GenerateID(),
+			UserId:            currentUser.UserId,
+			CodeHash:          codeHash,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			CreatedBy:         currentUser.FullName,
+			UpdatedBy:         currentUser.FullName,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode drops the separator and case from a typed recovery code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// createOneTimeToken stores a new one-time token for the user and returns its
+// plaintext. Unused tokens of the same purpose are invalidated.
+func (s *AuthService) createOneTimeToken(user models.User, purpose models.OneTimeTokenPurpose, target string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.storeOneTimeToken(user, purpose, target, utils.HashToken(token), ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// storeOneTimeToken stores a hashed one-time token for the user, invalidating
+// unused tokens of the same purpose.
+func (s *AuthService) storeOneTimeToken(user models.User, purpose models.OneTimeTokenPurpose, target string, tokenHash string, ttl time.Duration) error {
+	now := time.Now().UTC()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.OneTimeToken{}).
+			Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", user.UserId, purpose).
+			Updates(map[string]interface{}{"consumed_at": now, "updated_at": now, "updated_by": user.FullName}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.OneTimeToken{
+			OneTimeTokenId: utils.GenerateID(),
+			UserId:         user.UserId,
+			Purpose:        purpose,
+			TokenHash:      tokenHash,
+			Target:         target,
+			ExpiresAt:      now.Add(ttl),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			CreatedBy:      user.FullName,
+			UpdatedBy:      user.FullName,
+		}).Error
+	})
+}
+
+// consumeOneTimeToken marks an unused, unexpired token as used and returns it.
+// Concurrent attempts to use the same token succeed at most once.
+func consumeOneTimeToken(tx *gorm.DB, purpose models.OneTimeTokenPurpose, token string) (models.OneTimeToken, error) {
+	var row models.OneTimeToken
+	err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.OneTimeToken{}, errOneTimeTokenInvalid
+		}
+		return models.OneTimeToken{}, err
+	}
+
+	now := time.Now().UTC()
+	if row.ConsumedAt != nil || now.After(row.ExpiresAt) {
+		return models.OneTimeToken{}, errOneTimeTokenInvalid
+	}
+
+	result := tx.Model(&models.OneTimeToken{}).
+		Where("one_time_token_id = ? AND consumed_at IS NULL", row.OneTimeTokenId).
+		Updates(map[string]interface{}{"consumed_at": now, "updated_at": now})
+	if result.Error != nil {
+		return models.OneTimeToken{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.OneTimeToken{}, errOneTimeTokenInvalid
+	}
+	return row, nil
+}
+
+// oneTimeTokenThrottled reports whether another token of the purpose may not be
+// sent to the user yet, either because the last one is too recent or because the
+// hourly limit is reached.
+func (s *AuthService) oneTimeTokenThrottled(userId string, purpose models.OneTimeTokenPurpose, policy oneTimeTokenPolicy) (bool, error) {
+	now := time.Now().UTC()
+
+	var recent int64
+	err := s.db.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userId, purpose, now.Add(-time.Hour)).
+		Count(&recent).Error
+	if err != nil {
+		return false, err
+	}
+	if recent >= int64(policy.maxPerHour) {
+		return true, nil
+	}
+
+	var tooSoon int64
+	err = s.db.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userId, purpose, now.Add(-policy.resendInterval)).
+		Count(&tooSoon).Error
+	return tooSoon > 0, err
+}
+
+// errOidcLoginFailed is returned for every OIDC callback that cannot be
+// completed, so the cause is only logged
+var errOidcLoginFailed = &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Login with the identity provider failed"}
+
+// StartOidcLogin begins an authorization-code login with PKCE. The client
+// sends the user to the returned URL; the provider redirects back to
+// OIDC_REDIRECT_URL with a code and the state.
+func (s *AuthService) StartOidcLogin(ctx context.Context) (OidcAuthorizeResponse, error) {
+	if s.oidcProvider == nil {
+		return OidcAuthorizeResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "OIDC login is not configured"}
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := utils.GenerateToken()
+		if err != nil {
+			s.logger.Printf("Error generating OIDC state: %v", err)
+			return OidcAuthorizeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to start login"}
+		}
+		secrets[i] = secret
+	}
+	state, nonce, codeVerifier := secrets[0], secrets[1], secrets[2]
+
+	authorizationUrl, err := s.oidcProvider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		s.logger.Printf("Error building OIDC authorization URL: %v", err)
+		return OidcAuthorizeResponse{}, &fiber.Error{Code: fiber.StatusBadGateway, Message: "Identity provider is unavailable"}
+	}
+
+	now := time.Now().UTC()
+	err = s.db.Create(&models.OidcLoginState{
+		OidcLoginStateId: utils.GenerateID(),
+		StateHash:        utils.HashToken(state),
+		CodeVerifier:     codeVerifier,
+		Nonce:            nonce,
+		ExpiresAt:        now.Add(s.oidcStateTTL),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		CreatedBy:        "system",
+		UpdatedBy:        "system",
+	}).Error
+	if err != nil {
+		s.logger.Printf("Error storing OIDC state: %v", err)
+		return OidcAuthorizeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to start login"}
+	}
+
+	return OidcAuthorizeResponse{AuthorizationUrl: authorizationUrl, State: state}, nil
+}
+
+// CompleteOidcLogin exchanges the provider's code for a verified ID token,
+// finds or creates the linked local user and logs them in like Login does.
+func (s *AuthService) CompleteOidcLogin(ctx context.Context, dto OidcCallbackDto, client ClientInfo) (TokenResponse, error) {
+	if s.oidcProvider == nil {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "OIDC login is not configured"}
+	}
+	if dto.Error != "" {
+		s.logger.Printf("OIDC provider returned an error: %s %s", dto.Error, dto.ErrorDescription)
+		return TokenResponse{}, errOidcLoginFailed
+	}
+	if dto.Code == "" || dto.State == "" {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Code and state are required"}
+	}
+
+	// Consume the state first so a callback can only be used once
+	var loginState models.OidcLoginState
+	now := time.Now().UTC()
+	result := s.db.Model(&loginState).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ? AND consumed_at IS NULL AND expires_at > ?", utils.HashToken(dto.State), now).
+		Updates(map[string]interface{}{"consumed_at": now, "updated_at": now})
+	if result.Error != nil {
+		s.logger.Printf("Error consuming OIDC state: %v", result.Error)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+	if result.RowsAffected == 0 {
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid or expired login state"}
+	}
+
+	claims, err := s.oidcProvider.Exchange(ctx, dto.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.logger.Printf("Error completing OIDC login: %v", err)
+		return TokenResponse{}, errOidcLoginFailed
+	}
+
+	user, err := s.findOrCreateOidcUser(claims)
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return TokenResponse{}, fiberErr
+		}
+		s.logger.Printf("Error linking OIDC identity: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+
+	if user.EffectiveStatus() == models.UserStatusBanned {
+		return TokenResponse{}, errAccountBanned
+	}
+
+	mfaEnabled, err := userHasMfa(s.db, user.UserId)
+	if err != nil {
+		s.logger.Printf("Error fetching two-factor settings: %v", err)
+		return TokenResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to login"}
+	}
+	if mfaEnabled {
+		return s.mfaChallenge(user)
+	}
+
+	return s.issueTokens(user, client)
+}
+
+// findOrCreateOidcUser returns the user linked to the provider identity. An
+// unlinked identity is linked to the local account with the same email, or to
+// a new account, but only when the provider has verified that email.
+func (s *AuthService) findOrCreateOidcUser(claims oidc.Claims) (models.User, error) {
+	issuer := s.oidcProvider.Issuer()
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var identity models.ExternalIdentity
+		err := tx.Where("issuer = ? AND subject = ?", issuer, claims.Subject).First(&identity).Error
+		if err == nil {
+			return tx.Preload("UserRoles.Role").Where("user_id = ?", identity.UserId).First(&user).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if claims.Email == "" || !claims.EmailVerified {
+			return &fiber.Error{Code: fiber.StatusForbidden, Message: "The identity provider did not confirm a verified email address"}
+		}
+
+		now := time.Now().UTC()
+		err = tx.Preload("UserRoles.Role").Where("email = ?", claims.Email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user, err = createOidcUser(tx, claims, now)
+		} else if err == nil && !user.Verified {
+			err = claimUnverifiedUser(tx, &user, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ExternalIdentity{
+			ExternalIdentityId: utils.GenerateID(),
+			UserId:             user.UserId,
+			Issuer:             issuer,
+			Subject:            claims.Subject,
+			Email:              claims.Email,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+			CreatedBy:          user.FullName,
+			UpdatedBy:          user.FullName,
+		}).Error
+	})
+	return user, err
+}
+
+// claimUnverifiedUser hands an unverified account over to the provider
+// identity, which has proven ownership of its email. Whoever registered it may
+// not own the address, so the password they chose is replaced with a random one
+// and any session they hold is revoked; the owner can reset the password later.
+func claimUnverifiedUser(tx *gorm.DB, user *models.User, now time.Time) error {
+	hashedPassword, err := utils.HashData(utils.GenerateID() + utils.GenerateID())
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&models.User{}).Where("user_id = ?", user.UserId).
+		Updates(map[string]interface{}{
+			"password":          hashedPassword,
+			"verified":          true,
+			"email_is_verified": true,
+			"updated_at":        now,
+			"updated_by":        user.FullName,
+		}).Error
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	user.Verified = true
+	user.EmailIsVerified = true
+	return RevokeUserSessions(tx, user.UserId, user.FullName)
+}
+
+// createOidcUser creates a verified account for a new provider identity. It
+// gets a random password, so password login needs a reset first.
+func createOidcUser(tx *gorm.DB, claims oidc.Claims, now time.Time) (models.User, error) {
+	hashedPassword, err := utils.HashData(utils.GenerateID() + utils.GenerateID())
+	if err != nil {
+		return models.User{}, err
+	}
+
+	fullName := claims.Name
+	if fullName == "" {
+		fullName = strings.Split(claims.Email, "@")[0]
+	}
+	user := models.User{
+		UserId:          utils.GenerateID(),
+		FullName:        fullName,
+		Email:           claims.Email,
+		ProfileImage:    claims.Picture,
+		Password:        hashedPassword,
+		Verified:        true,
+		EmailIsVerified: true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CreatedBy:       fullName,
+		UpdatedBy:       fullName,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return models.User{}, err
+	}
+
+	err = tx.Create(&models.UsersStats{
+		UserStatsID: utils.GenerateID(),
+		UserID:      user.UserId,
+		CreatedBy:   fullName,
+		UpdatedBy:   fullName,
+	}).Error
+	user.Password = ""
+	return user, err
+}
+
+// GetJWKS returns the public keys tokens may be verified with.
+func (s *AuthService) GetJWKS() (JWKSResponse, error) {
+	keys, err := utils.PublicJWKS()
+	if err != nil {
+		s.logger.Printf("Error building JWKS: %v", err)
+		return JWKSResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to load signing keys"}
+	}
+	return JWKSResponse{Keys: keys}, nil
+}
+
+// Logout revokes the session the current access token belongs to.
+func (s *AuthService) Logout(currentUser models.ICurrentUser) (MessageResponse, error) {
+	if err := revokeSession(s.db, currentUser.SessionId, currentUser.FullName); err != nil {
+		s.logger.Printf("Error revoking session: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to logout"}
+	}
+	return MessageResponse{Message: "Logged out successfully"}, nil
+}
+
+// LogoutAll revokes every session of the current user.
+func (s *AuthService) LogoutAll(currentUser models.ICurrentUser) (MessageResponse, error) {
+	if err := RevokeUserSessions(s.db, currentUser.UserId, currentUser.FullName); err != nil {
+		s.logger.Printf("Error revoking sessions: %v", err)
+		return MessageResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to logout"}
+	}
+	return MessageResponse{Message: "Logged out of all sessions successfully"}, nil
+}
+
+// RevokeUserSessions revokes all refresh tokens of a user, which also
+// invalidates every access token issued for those sessions.
+func RevokeUserSessions(tx *gorm.DB, userId string, revokedBy string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now().UTC(),
+			"updated_at": time.Now().UTC(),
+			"updated_by": revokedBy,
+		}).Error
+}
+
+// revokeSession revokes all refresh tokens of one token family.
+func revokeSession(tx *gorm.DB, familyId string, revokedBy string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now().UTC(),
+			"updated_at": time.Now().UTC(),
+			"updated_by": revokedBy,
+		}).Error
+}
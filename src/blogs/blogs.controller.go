@@ -5,6 +5,7 @@ import (
 
 	"github.com/epsierra/phinex-blog-api/src/middlewares"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,16 +23,23 @@ func NewBlogsController(service *BlogsService) *BlogsController {
 
 // RegisterRoutes registers the blog-related routes to the Fiber app
 func (c *BlogsController) RegisterRoutes(app *fiber.App) {
+	// Public, so link previews and crawlers can read it; registered before the guard so it is not covered
+	app.Get("/blogs/:blogId/meta", c.FindBlogMeta) // Get a blog post's OpenGraph and Twitter card fields
+
 	// Guard
-	app.Use("/blogs/*", middlewares.AuthenticatedGuard(c.service.db))
-	app.Use("/users/*", middlewares.AuthenticatedGuard(c.service.db))
-	app.Use("/following-blogs/*", middlewares.AuthenticatedGuard(c.service.db))
-	app.Use("/pinned-blogs/*", middlewares.AuthenticatedGuard(c.service.db))
-	app.Use("/comments/*", middlewares.AuthenticatedGuard(c.service.db))
+	app.Use("/blogs/*", middlewares.RequireAuthenticated())
+	app.Use("/users/*", middlewares.RequireAuthenticated())
+	app.Use("/following-blogs/*", middlewares.RequireAuthenticated())
+	app.Use("/pinned-blogs/*", middlewares.RequireAuthenticated())
+	app.Use("/comments/*", middlewares.RequireAuthenticated())
 
 	// Blog CRUD routes
 	app.Post("/blogs", c.CreateBlog)                                 // Create a new blog post
 	app.Get("/pinned-blogs", c.FindPinnedBlogs)                      // Get all pinned blogs
+	app.Get("/blogs/drafts", c.FindDrafts)                           // Get the current user's unpublished blog posts
+	app.Get("/blogs/for-you", c.FindForYouBlogs)                     // Get blog posts ranked for the current user
+	app.Get("/blogs/trending", c.FindTrendingBlogs)                  // Get the highest scoring recent blog posts
+	app.Get("/blogs/slug/:slug", c.FindBlogBySlug)                   // Get a blog post by its slug
 	app.Get("/blogs/:blogId", c.FindBlogById)                        // Get a blog post by its ID
 	app.Get("/following-blogs", c.FindFollowingBlogs)                // Get all sessions blogs
 	app.Get("/blogs", c.FindAllBlogs)                                // Get all blogs
@@ -40,6 +48,12 @@ func (c *BlogsController) RegisterRoutes(app *fiber.App) {
 	app.Delete("/blogs/:blogId", c.DeleteBlog)                       // Delete a blog post
 	app.Get("/blogs/:blogId/follows/likes", c.FindLikesAndFollowers) // Get all likes and followed or followers that like a specific post
 
+	// Revision history routes
+	app.Get("/blogs/:blogId/revisions", c.FindRevisions)                      // Get a blog post's revisions
+	app.Get("/blogs/:blogId/revisions/diff", c.DiffRevisions)                 // Compare two revisions of a blog post
+	app.Get("/blogs/:blogId/revisions/:revision", c.FindRevision)             // Get one revision of a blog post
+	app.Post("/blogs/:blogId/revisions/:revision/restore", c.RestoreRevision) // Restore a revision as a new one
+
 	// Comment-related routes
 	app.Post("/blogs/:blogId/comments", c.AddComment)         // Add a comment to a blog post
 	app.Get("/blogs/:blogId/comments", c.FindComments)        // Add a comment to a blog post
@@ -55,6 +69,9 @@ func (c *BlogsController) RegisterRoutes(app *fiber.App) {
 	// User-related blog routes
 	app.Get("/users/:userId/blogs", c.FindUserBlogs) // Get all user blogs
 
+	// Tag-related blog routes
+	app.Get("/tags/:tag/blogs", c.FindTagBlogs) // Get all blogs with a tag
+
 }
 
 // @Summary Get all blogs
@@ -64,15 +81,73 @@ func (c *BlogsController) RegisterRoutes(app *fiber.App) {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Router /blogs [get]
 // @Security ApiKeyAuth
 func (c *BlogsController) FindAllBlogs(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	blogs, err := c.service.FindAll(currentUser, params)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(blogs)
+}
+
+// @Summary Get For You blogs
+// @Description Get published blogs ranked for the current user: by a score of weighted likes, comments, shares and views that decays with age, boosted by the user's affinity to each blog's author and tags. Scores are recomputed in the background. Ranked lists are paged by number only.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param count query bool false "Whether to count the total items" default(true)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /blogs/for-you [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindForYouBlogs(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	blogs, err := c.service.FindForYou(currentUser, params)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(blogs)
+}
+
+// @Summary Get trending blogs
+// @Description Get the blogs published within a window with the highest scores, the same scores that rank the For You feed without the user's affinities
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param window query string false "How far back to look, from 1h to 30d, e.g. 6h or 7d" default(24h)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param count query bool false "Whether to count the total items" default(true)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /blogs/trending [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindTrendingBlogs(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	blogs, err := c.service.FindAll(currentUser, page, limit)
+	blogs, err := c.service.FindTrending(ctx.Query("window", "24h"), currentUser, params)
 	if err != nil {
 		return err
 	}
@@ -80,21 +155,26 @@ func (c *BlogsController) FindAllBlogs(ctx *fiber.Ctx) error {
 }
 
 // @Summary Get pinned blogs
-// @Description Get all pinned blogs with pagination
+// @Description Get all active pinned blogs, newest pin first
 // @Tags Blogs
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
-// @Router /blogs/pinned [get]
+// @Failure 400 {object} models.ErrorResponse
+// @Router /pinned-blogs [get]
 // @Security ApiKeyAuth
 func (c *BlogsController) FindPinnedBlogs(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	blogs, err := c.service.FindPinnedBlogs(currentUser, page, limit)
+	blogs, err := c.service.FindPinnedBlogs(currentUser, params)
 	if err != nil {
 		return err
 	}
@@ -123,6 +203,50 @@ func (c *BlogsController) FindBlogById(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(blog)
 }
 
+// @Summary Get a single blog by slug
+// @Description Get a single blog by its slug. Slugs the blog had before a title change redirect permanently to the current one.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param slug path string true "Blog slug"
+// @Success 200 {object} BlogWithMeta
+// @Success 301 "Redirect to the blog's current slug"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/slug/{slug} [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindBlogBySlug(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	blog, redirectSlug, err := c.service.FindBySlug(ctx.Params("slug"), currentUser)
+	if err != nil {
+		return err
+	}
+	if redirectSlug != "" {
+		return ctx.Redirect("/blogs/slug/"+redirectSlug, fiber.StatusMovedPermanently)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(blog)
+}
+
+// @Summary Get a blog's SEO metadata
+// @Description Get the OpenGraph and Twitter card fields of a blog: title, a description excerpt, the first attached image and the canonical URL. Does not count a view.
+// @Tags Blogs
+// @Produce json
+// @Param blogId path string true "Blog ID"
+// @Success 200 {object} BlogMetaResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/{blogId}/meta [get]
+func (c *BlogsController) FindBlogMeta(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.FindMeta(ctx.Params("blogId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
 // @Summary Get session blogs
 // @Description Get blogs from followed users or the current user with pagination
 // @Tags Blogs
@@ -130,21 +254,170 @@ func (c *BlogsController) FindBlogById(ctx *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Router /following-blogs [get]
 // @Security ApiKeyAuth
 func (c *BlogsController) FindFollowingBlogs(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	blogs, err := c.service.FindFollowingBlogs(currentUser, params)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(blogs)
+}
+
+// @Summary Get my drafts
+// @Description Get the current user's draft and scheduled blogs with pagination, most recently edited first. Pass a status to list only draft, scheduled or archived blogs.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param status query string false "Only blogs with this status" Enums(draft, scheduled, archived)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param count query bool false "Whether to count the total items" default(true)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/drafts [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindDrafts(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	blogs, err := c.service.FindFollowingBlogs(currentUser, page, limit)
+	blogs, err := c.service.FindDrafts(ctx.Query("status"), currentUser, params)
 	if err != nil {
 		return err
 	}
 	return ctx.Status(fiber.StatusOK).JSON(blogs)
 }
 
+// @Summary Get blog revisions
+// @Description Get the revisions of a blog post, newest first. Every change to the content is saved as a revision with its author and changed fields. Only the author and admins can see them.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param blogId path string true "Blog ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param count query bool false "Whether to count the total items" default(true)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/{blogId}/revisions [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindRevisions(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.service.FindRevisions(ctx.Params("blogId"), currentUser, params)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(revisions)
+}
+
+// @Summary Get a blog revision
+// @Description Get one revision of a blog post by its number. Only the author and admins can see it.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param blogId path string true "Blog ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} models.BlogRevision
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/{blogId}/revisions/{revision} [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindRevision(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	revision, err := ctx.ParamsInt("revision")
+	if err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Revision must be a number"}
+	}
+
+	response, err := c.service.FindRevision(ctx.Params("blogId"), revision, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// @Summary Compare blog revisions
+// @Description Get a line-by-line diff of the title and text between two revisions of a blog post, and the fields that differ. Only the author and admins can see it.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param blogId path string true "Blog ID"
+// @Param from query int true "Older revision number"
+// @Param to query int true "Newer revision number"
+// @Success 200 {object} RevisionDiffResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/{blogId}/revisions/diff [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) DiffRevisions(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	from, fromErr := strconv.Atoi(ctx.Query("from"))
+	to, toErr := strconv.Atoi(ctx.Query("to"))
+	if fromErr != nil || toErr != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Revision numbers from and to are required"}
+	}
+
+	diff, err := c.service.DiffRevisions(ctx.Params("blogId"), from, to, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(diff)
+}
+
+// @Summary Restore a blog revision
+// @Description Puts the content of an older revision back on a blog post, saved as a new revision that records which one it restored.
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param blogId path string true "Blog ID"
+// @Param revision path int true "Revision number to restore"
+// @Success 200 {object} MutationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /blogs/{blogId}/revisions/{revision}/restore [post]
+// @Security ApiKeyAuth
+func (c *BlogsController) RestoreRevision(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	revision, err := ctx.ParamsInt("revision")
+	if err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Revision must be a number"}
+	}
+
+	response, err := c.service.RestoreRevision(ctx.Params("blogId"), revision, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
 // @Summary Get user blogs
 // @Description Get all blogs by a specific user with pagination
 // @Tags Blogs
@@ -153,7 +426,10 @@ func (c *BlogsController) FindFollowingBlogs(ctx *fiber.Ctx) error {
 // @Param userId path string true "User ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/{userId}/blogs [get]
@@ -161,10 +437,12 @@ func (c *BlogsController) FindFollowingBlogs(ctx *fiber.Ctx) error {
 func (c *BlogsController) FindUserBlogs(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
 	userId := ctx.Params("userId")
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	blogs, err := c.service.FindUserBlogs(userId, currentUser, page, limit)
+	blogs, err := c.service.FindUserBlogs(userId, currentUser, params)
 	if err != nil {
 		return err
 	}
@@ -180,6 +458,8 @@ func (c *BlogsController) FindUserBlogs(ctx *fiber.Ctx) error {
 // @Success 201 {object} MutationResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /blogs [post]
 // @Security ApiKeyAuth
@@ -257,6 +537,7 @@ func (c *BlogsController) DeleteBlog(ctx *fiber.Ctx) error {
 // @Param blogId path string true "Blog ID"
 // @Success 200 {object} LikeResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /blogs/{blogId}/likes [put]
 // @Security ApiKeyAuth
@@ -281,6 +562,7 @@ func (c *BlogsController) LikeBlog(ctx *fiber.Ctx) error {
 // @Success 201 {object} MutationResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /blogs/{blogId}/comments [post]
 // @Security ApiKeyAuth
@@ -359,6 +641,7 @@ func (c *BlogsController) UpdateComment(ctx *fiber.Ctx) error {
 // @Param commentId path string true "Comment ID"
 // @Success 200 {object} LikeResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /comments/{commentId}/likes [put]
 // @Security ApiKeyAuth
@@ -403,7 +686,10 @@ func (c *BlogsController) FindCommentLikes(ctx *fiber.Ctx) error {
 // @Param blogId path string true "Blog ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /blogs/{blogId}/comments [get]
@@ -411,10 +697,12 @@ func (c *BlogsController) FindCommentLikes(ctx *fiber.Ctx) error {
 func (c *BlogsController) FindComments(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
 	blogId := ctx.Params("blogId")
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	comments, err := c.service.FindComments(blogId, currentUser, page, limit)
+	comments, err := c.service.FindComments(blogId, currentUser, params)
 	if err != nil {
 		return err
 	}
@@ -429,7 +717,10 @@ func (c *BlogsController) FindComments(ctx *fiber.Ctx) error {
 // @Param commentId path string true "Comment ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /comments/{commentId}/replies [get]
@@ -437,10 +728,12 @@ func (c *BlogsController) FindComments(ctx *fiber.Ctx) error {
 func (c *BlogsController) FindReplies(ctx *fiber.Ctx) error {
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
 	commentId := ctx.Params("commentId")
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 
-	replies, err := c.service.FindReplies(commentId, currentUser, page, limit)
+	replies, err := c.service.FindReplies(commentId, currentUser, params)
 	if err != nil {
 		return err
 	}
@@ -483,6 +776,7 @@ func (c *BlogsController) AddReply(ctx *fiber.Ctx) error {
 // @Produce json
 // @Param blogId path string true "Blog ID"
 // @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /blogs/{blogId}/follows/likes [get]
 // @Security ApiKeyAuth
@@ -496,3 +790,32 @@ func (c *BlogsController) FindLikesAndFollowers(ctx *fiber.Ctx) error {
 	}
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
+
+// @Summary Get tag blogs
+// @Description Get published blogs filed under a tag with pagination
+// @Tags Blogs
+// @Accept json
+// @Produce json
+// @Param tag path string true "Tag name"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the items after it instead of a page"
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tags/{tag}/blogs [get]
+// @Security ApiKeyAuth
+func (c *BlogsController) FindTagBlogs(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	blogs, err := c.service.FindTagBlogs(ctx.Params("tag"), currentUser, params)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(blogs)
+}
@@ -1,6 +1,11 @@
 package blogs
 
-import "github.com/epsierra/phinex-blog-api/src/models"
+import (
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/textdiff"
+)
 
 // CreateBlogDto defines the input for creating a blog
 
@@ -15,6 +20,13 @@ type CreateBlogDto struct {
 	RepostedFromBlogId string   `json:"RepostedFromBlogId,omitempty" example:"some-other-blog-id"`
 	Pinned             bool     `json:"pinned,omitempty" example:"false"`
 	PinnedNumerOfDays  int      `json:"pinnedNumberOfDays,omitempty" example:"7"`
+	MfaCode            string   `json:"mfaCode,omitempty" example:"123456"`
+	Tags               []string `json:"tags,omitempty" example:"golang,web-development"`
+	// Format is how Text is written: plain (the default) or markdown
+	Format models.TextFormat `json:"format,omitempty" example:"markdown"`
+	// Status is draft, scheduled or published (the default, or scheduled when PublishAt is in the future)
+	Status    models.BlogStatus `json:"status,omitempty" example:"draft"`
+	PublishAt *time.Time        `json:"publishAt,omitempty" example:"2026-01-01T09:00:00Z"`
 }
 
 // UpdateBlogDto defines the input for updating a blog
@@ -26,6 +38,13 @@ type UpdateBlogDto struct {
 	Images            []string `json:"images" example:"https://example.com/image3.jpg"`
 	Video             string   `json:"video" example:"https://example.com/updated-video.mp4"`
 	Audio             string   `json:"audio" example:"https://example.com/updated-audio.mp3"`
+	// Tags replaces the blog's tags when present; an empty list removes them
+	Tags []string `json:"tags,omitempty" example:"golang"`
+	// Format changes how Text is written: plain or markdown
+	Format models.TextFormat `json:"format,omitempty" example:"markdown"`
+	// Status moves the blog through its lifecycle; PublishAt alone reschedules a scheduled blog
+	Status    models.BlogStatus `json:"status,omitempty" example:"scheduled"`
+	PublishAt *time.Time        `json:"publishAt,omitempty" example:"2026-01-01T09:00:00Z"`
 }
 
 // FollowUnfollowDto defines the input for follow/unfollow
@@ -48,6 +67,8 @@ type CreateCommentDto struct {
 	Video   string `json:"video,omitempty" example:"https://example.com/comment-video.mp4"`
 	Audio   string `json:"audio" example:"https://example.com/updated-audio.mp3"`
 	Sticker string `json:"sticker,omitempty" example:"smiley_face"`
+	// Format is how Text is written: plain (the default) or markdown
+	Format models.TextFormat `json:"format,omitempty" example:"markdown"`
 }
 
 // CreateReplyDto defines the input for creating a reply
@@ -57,6 +78,8 @@ type CreateReplyDto struct {
 	Video   string `json:"video,omitempty" example:"https://example.com/reply-video.mp4"`
 	Audio   string `json:"audio" example:"https://example.com/updated-audio.mp3"`
 	Sticker string `json:"sticker,omitempty" example:"thumbs_up"`
+	// Format is how Text is written: plain (the default) or markdown
+	Format models.TextFormat `json:"format,omitempty" example:"plain"`
 }
 
 // CommentWithMeta represents a comment with metadata
@@ -83,3 +106,50 @@ type MutationResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 }
+
+// RevisionDiffResponse compares two revisions of a blog
+type RevisionDiffResponse struct {
+	BlogId        string          `json:"blogId"`
+	From          int             `json:"from"`
+	To            int             `json:"to"`
+	ChangedFields []string        `json:"changedFields"`
+	Title         []textdiff.Line `json:"title"`
+	Text          []textdiff.Line `json:"text"`
+	Unified       string          `json:"unified" example:" First line\n-Old second line\n+New second line\n"`
+}
+
+// BlogMeta holds the fields a server-side renderer puts in a blog page's head
+type BlogMeta struct {
+	Title        string        `json:"title" example:"My First Blog Post"`
+	Description  string        `json:"description" example:"This is the content of my first blog post."`
+	Image        string        `json:"image,omitempty" example:"https://example.com/image1.jpg"`
+	CanonicalUrl string        `json:"canonicalUrl" example:"https://phinex.app/blogs/my-first-blog-post"`
+	OpenGraph    OpenGraphMeta `json:"openGraph"`
+	Twitter      TwitterMeta   `json:"twitter"`
+}
+
+// OpenGraphMeta are a blog's og: and article: properties
+type OpenGraphMeta struct {
+	Type          string   `json:"type" example:"article"`
+	Title         string   `json:"title" example:"My First Blog Post"`
+	Description   string   `json:"description" example:"This is the content of my first blog post."`
+	Url           string   `json:"url" example:"https://phinex.app/blogs/my-first-blog-post"`
+	Image         string   `json:"image,omitempty" example:"https://example.com/image1.jpg"`
+	PublishedTime string   `json:"publishedTime,omitempty" example:"2026-01-01T09:00:00Z"`
+	ModifiedTime  string   `json:"modifiedTime" example:"2026-01-02T09:00:00Z"`
+	Author        string   `json:"author" example:"Jane Doe"`
+	Tags          []string `json:"tags" example:"golang"`
+}
+
+// TwitterMeta are a blog's twitter: card properties
+type TwitterMeta struct {
+	Card        string `json:"card" example:"summary_large_image"`
+	Title       string `json:"title" example:"My First Blog Post"`
+	Description string `json:"description" example:"This is the content of my first blog post."`
+	Image       string `json:"image,omitempty" example:"https://example.com/image1.jpg"`
+}
+
+// BlogMetaResponse represents a blog's SEO metadata
+type BlogMetaResponse struct {
+	Data BlogMeta `json:"data"`
+}
@@ -0,0 +1,59 @@
+package blogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/epsierra/phinex-blog-api/src/markup"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// renderText renders the text of a blog or comment to sanitised HTML. Every
+// image the text embeds must be one of allowedImages, the media attached to
+// the blog or comment.
+func (s *BlogsService) renderText(format models.TextFormat, text string, allowedImages []string) (models.TextFormat, string, error) {
+	textFormat, err := markup.ParseFormat(format)
+	if err != nil {
+		return "", "", &fiber.Error{Code: fiber.StatusBadRequest, Message: err.Error()}
+	}
+	rendered, err := markup.Render(textFormat, text)
+	if err != nil {
+		s.logger.Printf("Error rendering text: %v", err)
+		return "", "", &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to render text"}
+	}
+	for _, image := range rendered.Images {
+		if !slices.Contains(allowedImages, image) {
+			return "", "", &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Embedded image %s must be one of the attached images", image)}
+		}
+	}
+	return textFormat, rendered.HTML, nil
+}
+
+// withHTML fills in the HTML of text saved before it was rendered on write
+func withHTML(format models.TextFormat, text, html string) string {
+	if html != "" || text == "" {
+		return html
+	}
+	rendered, err := markup.Render(format, text)
+	if err != nil {
+		return ""
+	}
+	return rendered.HTML
+}
+
+// blogImages decodes a blog's image list
+func blogImages(images json.RawMessage) []string {
+	var list []string
+	json.Unmarshal(images, &list)
+	return list
+}
+
+// commentImages lists the image attached to a comment, if any
+func commentImages(image string) []string {
+	if image == "" {
+		return nil
+	}
+	return []string{image}
+}
@@ -0,0 +1,89 @@
+package blogs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/markup"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/tags"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// metaDescriptionLength is the longest description excerpt, which search
+// engines and link previews cut off beyond roughly this length
+const metaDescriptionLength = 160
+
+// FindMeta returns the OpenGraph and Twitter card fields of a blog. Unlike
+// FindOne it does not count a view, since renderers fetch it for every page
+// load. Unpublished blogs are only visible to their author and admins.
+func (s *BlogsService) FindMeta(blogId string, currentUser models.ICurrentUser) (BlogMetaResponse, error) {
+	var blog models.Blog
+	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("user_id", "full_name")
+	}).Where("blog_id = ?", blogId).First(&blog).Error
+	if err == nil && blog.Status != models.BlogStatusPublished && blog.UserId != currentUser.UserId && !currentUser.IsAdmin() {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return BlogMetaResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s does not exist", blogId)}
+		}
+		s.logger.Printf("Error fetching blog meta: %v", err)
+		return BlogMetaResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blog"}
+	}
+
+	blogTags, err := tags.BlogTagNames(s.db, []string{blog.BlogId})
+	if err != nil {
+		s.logger.Printf("Error fetching blog tags: %v", err)
+		return BlogMetaResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blog"}
+	}
+
+	canonicalUrl := blog.Url
+	if canonicalUrl == "" {
+		canonicalUrl = s.blogURL(blog.Slug)
+	}
+	description := markup.Excerpt(withHTML(blog.Format, blog.Text, blog.Html), metaDescriptionLength)
+	var image string
+	if images := blogImages(blog.Images); len(images) > 0 {
+		image = images[0]
+	}
+
+	meta := BlogMeta{
+		Title:        blog.Title,
+		Description:  description,
+		Image:        image,
+		CanonicalUrl: canonicalUrl,
+		OpenGraph: OpenGraphMeta{
+			Type:         "article",
+			Title:        blog.Title,
+			Description:  description,
+			Url:          canonicalUrl,
+			Image:        image,
+			ModifiedTime: blog.UpdatedAt.UTC().Format(time.RFC3339),
+			Author:       blog.User.FullName,
+			Tags:         blogTags[blog.BlogId],
+		},
+		Twitter: TwitterMeta{
+			Card:        "summary",
+			Title:       blog.Title,
+			Description: description,
+			Image:       image,
+		},
+	}
+	if meta.OpenGraph.Tags == nil {
+		meta.OpenGraph.Tags = []string{}
+	}
+	if blog.PublishedAt != nil {
+		meta.OpenGraph.PublishedTime = blog.PublishedAt.UTC().Format(time.RFC3339)
+	} else if blog.Status == models.BlogStatusPublished {
+		// Blogs published before publishing was tracked
+		meta.OpenGraph.PublishedTime = blog.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if image != "" {
+		meta.Twitter.Card = "summary_large_image"
+	}
+	return BlogMetaResponse{Data: meta}, nil
+}
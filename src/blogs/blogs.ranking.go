@@ -0,0 +1,244 @@
+package blogs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTrendingWindow is the longest window trending blogs can be asked for
+const maxTrendingWindow = 30 * 24 * time.Hour
+
+// maxHalfLives caps a blog's age in half-lives when scoring it. 0.5^1000 is
+// still a normal double; much older blogs would underflow and fail the query.
+const maxHalfLives = 1000
+
+// RankingWeights tune the For You and trending feeds. A blog's score is
+// (1 + Likes×likes + Comments×comments + Shares×shares + Views×views), halved
+// every HalfLife since it was published. The For You feed multiplies it by
+// (1 + Author×author affinity + Tag×tag affinity), where affinities run from 0
+// to 1 and come from the viewer's engagement over the last AffinityWindow.
+type RankingWeights struct {
+	Likes          float64
+	Comments       float64
+	Shares         float64
+	Views          float64
+	Author         float64
+	Tag            float64
+	HalfLife       time.Duration
+	AffinityWindow time.Duration
+}
+
+// rankingWeightsFromEnv reads the ranking weights from RANKING_* variables. A
+// half-life that is set but not a positive duration is an error rather than
+// silently replaced, since scores are divided by it.
+func rankingWeightsFromEnv() (RankingWeights, error) {
+	if value := os.Getenv("RANKING_HALF_LIFE"); value != "" {
+		if halfLife, err := time.ParseDuration(value); err != nil || halfLife <= 0 {
+			return RankingWeights{}, fmt.Errorf("RANKING_HALF_LIFE must be a positive duration, got %q", value)
+		}
+	}
+	return RankingWeights{
+		Likes:          utils.GetEnvFloat("RANKING_LIKES_WEIGHT", 1),
+		Comments:       utils.GetEnvFloat("RANKING_COMMENTS_WEIGHT", 2),
+		Shares:         utils.GetEnvFloat("RANKING_SHARES_WEIGHT", 3),
+		Views:          utils.GetEnvFloat("RANKING_VIEWS_WEIGHT", 0.1),
+		Author:         utils.GetEnvFloat("RANKING_AUTHOR_AFFINITY_WEIGHT", 1),
+		Tag:            utils.GetEnvFloat("RANKING_TAG_AFFINITY_WEIGHT", 0.5),
+		HalfLife:       utils.GetEnvDuration("RANKING_HALF_LIFE", 24*time.Hour),
+		AffinityWindow: utils.GetEnvDuration("RANKING_AFFINITY_WINDOW", 30*24*time.Hour),
+	}, nil
+}
+
+// RecomputeScores rescores every published blog and recomputes every user's
+// author and tag affinities. Scores of blogs that are no longer published are
+// dropped.
+func (s *BlogsService) RecomputeScores() error {
+	now := time.Now().UTC()
+	weights := s.ranking
+	since := now.Add(-weights.AffinityWindow)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO blog_scores (blog_id, score, computed_at)
+			SELECT blog_id,
+				(1 + CAST(? AS DOUBLE PRECISION) * likes_count + CAST(? AS DOUBLE PRECISION) * comments_count
+					+ CAST(? AS DOUBLE PRECISION) * shares_count + CAST(? AS DOUBLE PRECISION) * views_count)
+					* POWER(0.5, LEAST(GREATEST(EXTRACT(EPOCH FROM (? - COALESCE(published_at, created_at))), 0) / CAST(? AS DOUBLE PRECISION), ?)),
+				?
+			FROM blogs WHERE status = ?
+			ON CONFLICT (blog_id) DO UPDATE SET score = EXCLUDED.score, computed_at = EXCLUDED.computed_at`,
+			weights.Likes, weights.Comments, weights.Shares, weights.Views,
+			now, weights.HalfLife.Seconds(), maxHalfLives, now, models.BlogStatusPublished).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("computed_at < ?", now).Delete(&models.BlogScore{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM user_affinities").Error; err != nil {
+			return err
+		}
+		// Engagement with a user's own blogs says nothing about their taste
+		return tx.Exec(`WITH interactions AS (
+				SELECT user_id, ref_id AS blog_id, CAST(? AS DOUBLE PRECISION) AS weight FROM likes WHERE created_at >= ?
+				UNION ALL SELECT user_id, ref_id, CAST(? AS DOUBLE PRECISION) FROM comments WHERE created_at >= ?
+				UNION ALL SELECT user_id, ref_id, CAST(? AS DOUBLE PRECISION) FROM shares WHERE created_at >= ?
+				UNION ALL SELECT user_id, ref_id, CAST(? AS DOUBLE PRECISION) FROM views WHERE created_at >= ? AND user_id <> ''
+			), engaged AS (
+				SELECT interactions.user_id, blogs.user_id AS author_id, blogs.blog_id, interactions.weight
+				FROM interactions JOIN blogs ON blogs.blog_id = interactions.blog_id
+				WHERE blogs.user_id <> interactions.user_id
+			), totals AS (
+				SELECT user_id, CAST(? AS VARCHAR) AS kind, author_id AS target_id, SUM(weight) AS weight FROM engaged GROUP BY user_id, author_id
+				UNION ALL
+				SELECT engaged.user_id, CAST(? AS VARCHAR), blog_tags.tag_id, SUM(engaged.weight)
+				FROM engaged JOIN blog_tags ON blog_tags.blog_id = engaged.blog_id
+				GROUP BY engaged.user_id, blog_tags.tag_id
+			)
+			INSERT INTO user_affinities (user_id, kind, target_id, weight, computed_at)
+			SELECT user_id, kind, target_id, weight / MAX(weight) OVER (PARTITION BY user_id, kind), ?
+			FROM totals WHERE weight > 0`,
+			weights.Likes, since, weights.Comments, since, weights.Shares, since, weights.Views, since,
+			models.AffinityKindAuthor, models.AffinityKindTag, now).Error
+	})
+}
+
+// StartRanking recomputes blog scores and affinities now and then every
+// interval until the returned stop function is called.
+func (s *BlogsService) StartRanking(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			if err := s.RecomputeScores(); err != nil {
+				s.logger.Printf("Error recomputing blog scores: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// FindForYou retrieves published blogs ranked for the current user: by score,
+// boosted by their affinity to each blog's author and tags. Blogs published
+// since scores were last computed rank as if they had no engagement yet.
+func (s *BlogsService) FindForYou(currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if params.After != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Ranked feeds cannot be paged by cursor"}
+	}
+
+	var totalItems int64
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Count(&totalItems)
+	}
+
+	var blogs []models.Blog
+	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("profile_image", "full_name", "user_id", "email", "verified").Preload("UserRoles.Role")
+	}).Scopes(publishedBlogs).
+		Joins("LEFT JOIN blog_scores ON blog_scores.blog_id = blogs.blog_id").
+		Joins("LEFT JOIN user_affinities AS author_affinity ON author_affinity.user_id = ? AND author_affinity.kind = ? AND author_affinity.target_id = blogs.user_id",
+			currentUser.UserId, models.AffinityKindAuthor).
+		Joins(`LEFT JOIN (
+			SELECT blog_tags.blog_id, MAX(user_affinities.weight) AS weight FROM blog_tags
+			JOIN user_affinities ON user_affinities.target_id = blog_tags.tag_id AND user_affinities.user_id = ? AND user_affinities.kind = ?
+			GROUP BY blog_tags.blog_id
+		) AS tag_affinity ON tag_affinity.blog_id = blogs.blog_id`, currentUser.UserId, models.AffinityKindTag).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "COALESCE(blog_scores.score, 1) * (1 + ? * COALESCE(author_affinity.weight, 0) + ? * COALESCE(tag_affinity.weight, 0)) DESC, blogs.blog_id DESC",
+			Vars:               []interface{}{s.ranking.Author, s.ranking.Tag},
+			WithoutParentheses: true,
+		}}).
+		Scopes(params.Numbered).Find(&blogs).Error
+	if err != nil {
+		s.logger.Printf("Error fetching ranked blogs: %v", err)
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
+	}
+	return s.rankedPage(blogs, params, totalItems, currentUser)
+}
+
+// FindTrending retrieves the blogs published within the window with the
+// highest scores. window is a duration such as 6h, or a number of days such
+// as 7d.
+func (s *BlogsService) FindTrending(window string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if params.After != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Ranked feeds cannot be paged by cursor"}
+	}
+	duration, err := parseWindow(window)
+	if err != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
+	}
+	inWindow := s.db.Where("COALESCE(blogs.published_at, blogs.created_at) >= ?", time.Now().UTC().Add(-duration))
+
+	var totalItems int64
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Where(inWindow).Count(&totalItems)
+	}
+
+	var blogs []models.Blog
+	err = s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("profile_image", "full_name", "user_id", "email", "verified").Preload("UserRoles.Role")
+	}).Scopes(publishedBlogs).Where(inWindow).
+		Joins("LEFT JOIN blog_scores ON blog_scores.blog_id = blogs.blog_id").
+		Order("COALESCE(blog_scores.score, 1) DESC, blogs.blog_id DESC").
+		Scopes(params.Numbered).Find(&blogs).Error
+	if err != nil {
+		s.logger.Printf("Error fetching trending blogs: %v", err)
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
+	}
+	return s.rankedPage(blogs, params, totalItems, currentUser)
+}
+
+// rankedPage trims and enriches a page of ranked blogs
+func (s *BlogsService) rankedPage(blogs []models.Blog, params pagination.Params, totalItems int64, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, nil)
+
+	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
+	if err != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
+	}
+
+	return models.PaginatedResponse{
+		Data:     blogsWithMeta,
+		Metadata: metadata,
+	}, nil
+}
+
+// parseWindow parses a trending window, which may also be given in days
+func parseWindow(window string) (time.Duration, error) {
+	invalid := &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Window must be a duration between 1h and %dd, such as 24h or 7d", int(maxTrendingWindow.Hours()/24))}
+
+	var duration time.Duration
+	if days, found := strings.CutSuffix(window, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, invalid
+		}
+		duration = time.Duration(count) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(window)
+		if err != nil {
+			return 0, invalid
+		}
+		duration = parsed
+	}
+	if duration < time.Hour || duration > maxTrendingWindow {
+		return 0, invalid
+	}
+	return duration, nil
+}
@@ -0,0 +1,293 @@
+package blogs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
+	"github.com/epsierra/phinex-blog-api/src/policies"
+	"github.com/epsierra/phinex-blog-api/src/textdiff"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindRevisions retrieves a blog's revisions, newest first, paged by number
+func (s *BlogsService) FindRevisions(blogId string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if params.After != nil {
+		return models.PaginatedResponse{Data: []models.BlogRevision{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Revisions cannot be paged by cursor"}
+	}
+	if _, err := s.findBlogForRevisions(s.db, blogId, currentUser, policies.CanViewBlogRevisions); err != nil {
+		return models.PaginatedResponse{Data: []models.BlogRevision{}}, err
+	}
+
+	var totalItems int64
+	if params.Count {
+		if err := s.db.Model(&models.BlogRevision{}).Where("blog_id = ?", blogId).Count(&totalItems).Error; err != nil {
+			s.logger.Printf("Error counting blog revisions: %v", err)
+			return models.PaginatedResponse{Data: []models.BlogRevision{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch revisions"}
+		}
+	}
+
+	revisions := []models.BlogRevision{}
+	err := s.db.Where("blog_id = ?", blogId).Order("revision DESC").Scopes(params.Numbered).Find(&revisions).Error
+	if err != nil {
+		s.logger.Printf("Error fetching blog revisions: %v", err)
+		return models.PaginatedResponse{Data: []models.BlogRevision{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch revisions"}
+	}
+	revisions, metadata := pagination.Trim(params, revisions, totalItems, nil)
+
+	return models.PaginatedResponse{
+		Data:     revisions,
+		Metadata: metadata,
+	}, nil
+}
+
+// FindRevision retrieves one revision of a blog
+func (s *BlogsService) FindRevision(blogId string, revision int, currentUser models.ICurrentUser) (models.BlogRevision, error) {
+	if _, err := s.findBlogForRevisions(s.db, blogId, currentUser, policies.CanViewBlogRevisions); err != nil {
+		return models.BlogRevision{}, err
+	}
+	found, err := findRevision(s.db, blogId, revision)
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return models.BlogRevision{}, fiberErr
+		}
+		s.logger.Printf("Error fetching blog revision: %v", err)
+		return models.BlogRevision{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch revision"}
+	}
+	return found, nil
+}
+
+// DiffRevisions compares two revisions of a blog, line by line
+func (s *BlogsService) DiffRevisions(blogId string, from, to int, currentUser models.ICurrentUser) (RevisionDiffResponse, error) {
+	if _, err := s.findBlogForRevisions(s.db, blogId, currentUser, policies.CanViewBlogRevisions); err != nil {
+		return RevisionDiffResponse{}, err
+	}
+
+	var revisions [2]models.BlogRevision
+	for i, number := range []int{from, to} {
+		revision, err := findRevision(s.db, blogId, number)
+		if err != nil {
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				return RevisionDiffResponse{}, fiberErr
+			}
+			s.logger.Printf("Error fetching blog revision: %v", err)
+			return RevisionDiffResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to compare revisions"}
+		}
+		revisions[i] = revision
+	}
+
+	text := textdiff.Lines(revisions[0].Text, revisions[1].Text)
+	return RevisionDiffResponse{
+		BlogId:        blogId,
+		From:          from,
+		To:            to,
+		ChangedFields: changedRevisionFields(revisions[0], revisions[1]),
+		Title:         textdiff.Lines(revisions[0].Title, revisions[1].Title),
+		Text:          text,
+		Unified:       textdiff.Unified(text),
+	}, nil
+}
+
+// RestoreRevision puts an older revision's content back on the blog. The
+// restore is itself saved as a new revision, so no history is lost.
+func (s *BlogsService) RestoreRevision(blogId string, revision int, currentUser models.ICurrentUser) (MutationResponse, error) {
+	var blog models.Blog
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		blog, err = s.findBlogForRevisions(tx.Clauses(clause.Locking{Strength: "UPDATE"}), blogId, currentUser, policies.CanUpdateBlog)
+		if err != nil {
+			return err
+		}
+		if err := s.ensureBaseRevision(tx, blog); err != nil {
+			return err
+		}
+		restored, err := findRevision(tx, blogId, revision)
+		if err != nil {
+			return err
+		}
+		if len(changedRevisionFields(revisionOf(blog), restored)) == 0 {
+			return &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Blog already matches revision %d", revision)}
+		}
+		// Revisions keep the HTML rendered when they were saved
+		html := restored.Html
+		if html == "" {
+			if _, html, err = s.renderText(restored.Format, restored.Text, blogImages(restored.Images)); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&blog).Updates(map[string]interface{}{
+			"title":               restored.Title,
+			"external_link":       restored.ExternalLink,
+			"external_link_title": restored.ExternalLinkTitle,
+			"text":                restored.Text,
+			"format":              restored.Format,
+			"html":                html,
+			"images":              restored.Images,
+			"video":               restored.Video,
+			"audio":               restored.Audio,
+			"is_reel":             restored.Video != "",
+			"updated_at":          time.Now().UTC(),
+			"updated_by":          currentUser.FullName,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if blog.Slug == "" || !slugMatchesTitle(blog.Slug, blog.Title) {
+			if err := s.assignSlug(tx, &blog, currentUser); err != nil {
+				return err
+			}
+		}
+		return s.recordRevision(tx, blog, currentUser, &revision)
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
+		s.logger.Printf("Error restoring blog revision: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to restore revision"}
+	}
+
+	return MutationResponse{
+		Message: fmt.Sprintf("Revision %d restored successfully", revision),
+		Data:    blog,
+	}, nil
+}
+
+// findBlogForRevisions loads a blog and checks the given policy on it
+func (s *BlogsService) findBlogForRevisions(tx *gorm.DB, blogId string, currentUser models.ICurrentUser, policy func(models.ICurrentUser, models.Blog) error) (models.Blog, error) {
+	var blog models.Blog
+	if err := tx.Where("blog_id = ?", blogId).First(&blog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Blog{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s does not exist", blogId)}
+		}
+		s.logger.Printf("Error fetching blog: %v", err)
+		return models.Blog{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blog"}
+	}
+	if err := policy(currentUser, blog); err != nil {
+		return models.Blog{}, err
+	}
+	return blog, nil
+}
+
+// findRevision loads one revision of a blog
+func findRevision(tx *gorm.DB, blogId string, revision int) (models.BlogRevision, error) {
+	var found models.BlogRevision
+	if err := tx.Where("blog_id = ? AND revision = ?", blogId, revision).First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BlogRevision{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Revision %d of blog %s does not exist", revision, blogId)}
+		}
+		return models.BlogRevision{}, err
+	}
+	return found, nil
+}
+
+// ensureBaseRevision saves the current content of a blog written before
+// revisions were kept as its first revision, so its first edit can be diffed.
+func (s *BlogsService) ensureBaseRevision(tx *gorm.DB, blog models.Blog) error {
+	var count int64
+	if err := tx.Model(&models.BlogRevision{}).Where("blog_id = ?", blog.BlogId).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	base := revisionOf(blog)
+	base.BlogRevisionId = utils.GenerateID()
+	base.Revision = 1
+	base.AuthorId = blog.UserId
+	base.ChangedFields = mustMarshalFields(changedRevisionFields(models.BlogRevision{}, base))
+	base.CreatedAt = blog.UpdatedAt
+	base.UpdatedAt = blog.UpdatedAt
+	base.CreatedBy = blog.UpdatedBy
+	base.UpdatedBy = blog.UpdatedBy
+	return tx.Create(&base).Error
+}
+
+// recordRevision saves the blog's current content as its next revision, unless
+// it matches the latest revision. restoredFrom marks a restore.
+func (s *BlogsService) recordRevision(tx *gorm.DB, blog models.Blog, currentUser models.ICurrentUser, restoredFrom *int) error {
+	var latest models.BlogRevision
+	err := tx.Where("blog_id = ?", blog.BlogId).Order("revision DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	revision := revisionOf(blog)
+	changed := changedRevisionFields(latest, revision)
+	if len(changed) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	revision.BlogRevisionId = utils.GenerateID()
+	revision.Revision = latest.Revision + 1
+	revision.AuthorId = currentUser.UserId
+	revision.ChangedFields = mustMarshalFields(changed)
+	revision.RestoredFrom = restoredFrom
+	revision.CreatedAt = now
+	revision.UpdatedAt = now
+	revision.CreatedBy = currentUser.FullName
+	revision.UpdatedBy = currentUser.FullName
+	return tx.Create(&revision).Error
+}
+
+// revisionOf copies a blog's content into an unsaved revision
+func revisionOf(blog models.Blog) models.BlogRevision {
+	return models.BlogRevision{
+		BlogId:            blog.BlogId,
+		Title:             blog.Title,
+		ExternalLink:      blog.ExternalLink,
+		ExternalLinkTitle: blog.ExternalLinkTitle,
+		Text:              blog.Text,
+		Format:            blog.Format,
+		Html:              blog.Html,
+		Images:            blog.Images,
+		Video:             blog.Video,
+		Audio:             blog.Audio,
+	}
+}
+
+// changedRevisionFields lists the JSON names of the content fields that
+// differ between two revisions
+func changedRevisionFields(a, b models.BlogRevision) []string {
+	changed := []string{}
+	for _, field := range []struct {
+		name   string
+		differ bool
+	}{
+		{"title", a.Title != b.Title},
+		{"externalLink", a.ExternalLink != b.ExternalLink},
+		{"externalLinkTitle", a.ExternalLinkTitle != b.ExternalLinkTitle},
+		{"text", a.Text != b.Text},
+		{"format", a.Format != b.Format},
+		{"images", !sameImages(a.Images, b.Images)},
+		{"video", a.Video != b.Video},
+		{"audio", a.Audio != b.Audio},
+	} {
+		if field.differ {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// sameImages compares two image lists, treating a missing list as empty
+func sameImages(a, b json.RawMessage) bool {
+	var aList, bList []string
+	json.Unmarshal(a, &aList)
+	json.Unmarshal(b, &bList)
+	return slices.Equal(aList, bList)
+}
+
+// mustMarshalFields encodes a field list; a string slice always marshals
+func mustMarshalFields(fields []string) json.RawMessage {
+	encoded, _ := json.Marshal(fields)
+	return encoded
+}
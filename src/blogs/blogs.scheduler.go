@@ -0,0 +1,65 @@
+package blogs
+
+import (
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PublishDueBlogs publishes every scheduled blog whose publishAt has passed
+// and returns how many it published. Each blog is claimed by a single UPDATE,
+// so several replicas can run the scheduler at once.
+func (s *BlogsService) PublishDueBlogs() (int, error) {
+	now := time.Now().UTC()
+	var published []models.Blog
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&published).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "blog_id"}, {Name: "user_id"}}}).
+			Where("status = ? AND publish_at <= ?", models.BlogStatusScheduled, now).
+			Updates(map[string]interface{}{
+				"status":       models.BlogStatusPublished,
+				"published_at": gorm.Expr("publish_at"),
+				"updated_at":   now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, blog := range published {
+			if err := adjustTotalPosts(tx, blog.UserId, 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// StartScheduler publishes due blogs every interval until the returned stop
+// function is called.
+func (s *BlogsService) StartScheduler(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				count, err := s.PublishDueBlogs()
+				if err != nil {
+					s.logger.Printf("Error publishing scheduled blogs: %v", err)
+				} else if count > 0 {
+					s.logger.Printf("Published %d scheduled blogs", count)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -8,66 +8,84 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/epsierra/phinex-blog-api/src/auth"
 	pb "github.com/epsierra/phinex-blog-api/src/blockchain"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
+	"github.com/epsierra/phinex-blog-api/src/policies"
+	"github.com/epsierra/phinex-blog-api/src/tags"
 	"github.com/epsierra/phinex-blog-api/src/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+const (
+	// maxSlugAttempts is how many slugs assignSlug tries when other blogs keep
+	// claiming them first
+	maxSlugAttempts = 5
+	// uniqueViolationCode is the Postgres error code of a unique violation
+	uniqueViolationCode = "23505"
+)
+
 // BlogsService handles blog-related operations
 type BlogsService struct {
 	db               *gorm.DB
 	logger           *log.Logger
 	blockchainClient pb.TransactionServiceClient
+	publicWebURL     string
+	ranking          RankingWeights
+	authService      *auth.AuthService
 }
 
 // NewBlogsService creates a new BlogsService instance
-func NewBlogsService(db *gorm.DB) *BlogsService {
+func NewBlogsService(db *gorm.DB, authService *auth.AuthService) *BlogsService {
+	ranking, err := rankingWeightsFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring blog ranking: ", err)
+	}
+
 	blockchainClient, conn := pb.NewBlockchainClient()
 	defer conn.Close()
 	return &BlogsService{
 		db:               db,
 		logger:           log.New(os.Stderr, "blogs-service: ", log.LstdFlags),
 		blockchainClient: blockchainClient,
+		publicWebURL:     strings.TrimRight(os.Getenv("PUBLIC_WEB_URL"), "/"),
+		ranking:          ranking,
+		authService:      authService,
 	}
 }
 
 // FindAll retrieves all blogs ordered by created_at descending
-func (s *BlogsService) FindAll(currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+func (s *BlogsService) FindAll(currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
 	var totalItems int64
-	s.db.Model(&models.Blog{}).Count(&totalItems)
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Count(&totalItems)
+	}
 
-	offset := (page - 1) * limit
 	var blogs []models.Blog
 	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "full_name", "user_id", "email", "verified")
-	}).Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}).Limit(limit).Offset(offset).Find(&blogs).Error
+	}).Scopes(publishedBlogs, params.Keyset("blogs.created_at", "blogs.blog_id")).Find(&blogs).Error
 	if err != nil {
 		s.logger.Printf("Error fetching blogs: %v", err)
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
 	}
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, blogCursor)
 
 	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
 	if err != nil {
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
 	return models.PaginatedResponse{
-		Data: blogsWithMeta,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     blogsWithMeta,
+		Metadata: metadata,
 	}, nil
 }
 
@@ -82,6 +100,13 @@ func (s *BlogsService) FindOne(blogId string, currentUser models.ICurrentUser) (
 		if err != nil {
 			return err
 		}
+		// Unpublished blogs are only visible to their author, and do not count views
+		if blog.Status != models.BlogStatusPublished {
+			if blog.UserId != currentUser.UserId && !currentUser.IsAdmin() {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		}
 
 		// Increment views_count for the blog
 		if err := tx.Model(&models.Blog{}).Where("blog_id = ?", blogId).Update("views_count", gorm.Expr("views_count + ?", 1)).Error; err != nil {
@@ -118,12 +143,77 @@ func (s *BlogsService) FindOne(blogId string, currentUser models.ICurrentUser) (
 	return blogsWithMeta[0], nil
 }
 
-// FindFollowingBlogs retrieves blogs from followed users or the current user
-func (s *BlogsService) FindFollowingBlogs(currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+// FindBySlug retrieves a blog by its slug. A slug the blog has since replaced
+// returns the current slug as redirectSlug instead, for a permanent redirect.
+func (s *BlogsService) FindBySlug(slug string, currentUser models.ICurrentUser) (blog BlogWithMeta, redirectSlug string, err error) {
+	var history models.BlogSlug
+	err = s.db.Preload("Blog", func(db *gorm.DB) *gorm.DB {
+		return db.Select("blog_id", "slug")
+	}).Where("slug = ?", strings.ToLower(slug)).First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return BlogWithMeta{}, "", &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with slug %s does not exist", slug)}
+		}
+		s.logger.Printf("Error fetching blog slug: %v", err)
+		return BlogWithMeta{}, "", &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blog"}
+	}
+	if history.Blog != nil && history.Blog.Slug != history.Slug {
+		return BlogWithMeta{}, history.Blog.Slug, nil
+	}
+
+	blog, err = s.FindOne(history.BlogId, currentUser)
+	return blog, "", err
+}
+
+// FindDrafts retrieves the current user's unpublished blogs, most recently
+// edited first: drafts and scheduled blogs, or only those with the given
+// status. Edits reorder the list, so it is paged by number only.
+func (s *BlogsService) FindDrafts(status string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if params.After != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Drafts cannot be paged by cursor"}
+	}
+	statuses := []models.BlogStatus{models.BlogStatusDraft, models.BlogStatusScheduled}
+	if status != "" {
+		if _, ok := blogStatusTransitions[models.BlogStatus(status)]; !ok || models.BlogStatus(status) == models.BlogStatusPublished {
+			return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Status must be draft, scheduled or archived"}
+		}
+		statuses = []models.BlogStatus{models.BlogStatus(status)}
+	}
+	query := s.db.Model(&models.Blog{}).Where("user_id = ? AND status IN ?", currentUser.UserId, statuses)
+
 	var totalItems int64
-	s.db.Model(&models.Blog{}).Count(&totalItems)
+	if params.Count {
+		if err := query.Count(&totalItems).Error; err != nil {
+			s.logger.Printf("Error counting drafts: %v", err)
+			return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch drafts"}
+		}
+	}
 
-	offset := (page - 1) * limit
+	var blogs []models.Blog
+	err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("profile_image", "full_name", "user_id", "email", "verified")
+	}).Order(clause.OrderByColumn{Column: clause.Column{Name: "updated_at"}, Desc: true}).
+		Order("blog_id DESC").Scopes(params.Numbered).Find(&blogs).Error
+	if err != nil {
+		s.logger.Printf("Error fetching drafts: %v", err)
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch drafts"}
+	}
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, nil)
+
+	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
+	if err != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
+	}
+
+	return models.PaginatedResponse{
+		Data:     blogsWithMeta,
+		Metadata: metadata,
+	}, nil
+}
+
+// FindFollowingBlogs retrieves blogs from followed users, the current user or
+// followed tags
+func (s *BlogsService) FindFollowingBlogs(currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
 	var followIds []interface{}
 	err := s.db.Model(&models.Follow{}).Clauses(clause.Where{
 		Exprs: []clause.Expression{
@@ -138,50 +228,50 @@ func (s *BlogsService) FindFollowingBlogs(currentUser models.ICurrentUser, page,
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to retrieve follow relationships"}
 	}
 
+	followedTagBlogIds := s.db.Model(&models.BlogTag{}).Select("blog_tags.blog_id").
+		Joins("JOIN tag_follows ON tag_follows.tag_id = blog_tags.tag_id").
+		Where("tag_follows.user_id = ?", currentUser.UserId)
+	followed := s.db.Where("user_id IN ?", append(followIds, currentUser.UserId)).
+		Or("blog_id IN (?)", followedTagBlogIds)
+
+	var totalItems int64
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Where(followed).Count(&totalItems)
+	}
+
 	var blogs []models.Blog
 	err = s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "full_name", "user_id", "email", "verified").Preload("UserRoles.Role")
-	}).Clauses(clause.Where{
-		Exprs: []clause.Expression{clause.IN{Column: "user_id", Values: append(followIds, currentUser.UserId)}},
-	}).Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}).
-		Limit(limit).Offset(offset).Find(&blogs).Error
+	}).Scopes(publishedBlogs).Where(followed).Scopes(params.Keyset("blogs.created_at", "blogs.blog_id")).Find(&blogs).Error
 	if err != nil {
 		s.logger.Printf("Error fetching session blogs: %v", err)
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
 	}
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, blogCursor)
 
 	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
 	if err != nil {
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
 	return models.PaginatedResponse{
-		Data: blogsWithMeta,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     blogsWithMeta,
+		Metadata: metadata,
 	}, nil
 }
 
 // FindUserBlogs retrieves blogs by a specific user
-func (s *BlogsService) FindUserBlogs(userId string, currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+func (s *BlogsService) FindUserBlogs(userId string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
 	var totalItems int64
-	s.db.Model(&models.Blog{}).Where("user_id = ?", userId).Count(&totalItems)
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Where("user_id = ?", userId).Count(&totalItems)
+	}
 
-	offset := (page - 1) * limit
 	var blogs []models.Blog
 	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "full_name", "user_id", "email", "verified").Preload("UserRoles.Role")
-	}).Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "user_id", Value: userId}}}).
-		Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}).
-		Limit(limit).Offset(offset).Find(&blogs).Error
+	}).Scopes(publishedBlogs).Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "user_id", Value: userId}}}).
+		Scopes(params.Keyset("blogs.created_at", "blogs.blog_id")).Find(&blogs).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User with ID %s does not exist", userId)}
@@ -190,31 +280,81 @@ func (s *BlogsService) FindUserBlogs(userId string, currentUser models.ICurrentU
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
 	}
 
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, blogCursor)
+
 	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
 	if err != nil {
 		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	return models.PaginatedResponse{
+		Data:     blogsWithMeta,
+		Metadata: metadata,
+	}, nil
+}
+
+// FindTagBlogs retrieves published blogs filed under a tag
+func (s *BlogsService) FindTagBlogs(tag string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	tag, err := tags.NormalizeName(tag)
+	if err != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
+	}
+	tagBlogIds := s.db.Model(&models.BlogTag{}).Select("blog_tags.blog_id").
+		Joins("JOIN tags ON tags.tag_id = blog_tags.tag_id").
+		Where("tags.name = ?", tag)
+
+	var totalItems int64
+	if params.Count {
+		s.db.Model(&models.Blog{}).Scopes(publishedBlogs).Where("blog_id IN (?)", tagBlogIds).Count(&totalItems)
+	}
+
+	var blogs []models.Blog
+	err = s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("profile_image", "full_name", "user_id", "email", "verified").Preload("UserRoles.Role")
+	}).Scopes(publishedBlogs).Where("blog_id IN (?)", tagBlogIds).
+		Scopes(params.Keyset("blogs.created_at", "blogs.blog_id")).Find(&blogs).Error
+	if err != nil {
+		s.logger.Printf("Error fetching tag blogs: %v", err)
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blogs"}
+	}
+	blogs, metadata := pagination.Trim(params, blogs, totalItems, blogCursor)
+
+	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
+	if err != nil {
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, err
+	}
 
 	return models.PaginatedResponse{
-		Data: blogsWithMeta,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     blogsWithMeta,
+		Metadata: metadata,
 	}, nil
 }
 
 // Create creates a new blog
 func (s *BlogsService) Create(dto CreateBlogDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	now := time.Now().UTC()
+	status, err := initialBlogStatus(dto, now)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if dto.Pinned && status != models.BlogStatusPublished {
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Only published blogs can be pinned"}
+	}
+	tagNames, err := tags.Normalize(dto.Tags)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	format, html, err := s.renderText(dto.Format, dto.Text, dto.Images)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	var publishedAt *time.Time
+	if status == models.BlogStatusPublished {
+		publishedAt = &now
+	}
 
 	var blog models.Blog
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
 		// Marshal Images to JSON
 		imagesJSON, err := json.Marshal(dto.Images)
 		if err != nil {
@@ -230,10 +370,15 @@ func (s *BlogsService) Create(dto CreateBlogDto, currentUser models.ICurrentUser
 			ExternalLink:      dto.ExternalLink,
 			ExternalLinkTitle: dto.ExternalLinkTitle,
 			Text:              dto.Text,
+			Format:            format,
+			Html:              html,
 			Images:            imagesJSON,
 			Video:             dto.Video,
 			Audio:             dto.Audio,
 			IsReel:            dto.Video != "",
+			Status:            status,
+			PublishAt:         dto.PublishAt,
+			PublishedAt:       publishedAt,
 			CreatedAt:         time.Now().UTC(),
 			UpdatedAt:         time.Now().UTC(),
 			CreatedBy:         currentUser.FullName,
@@ -245,19 +390,35 @@ func (s *BlogsService) Create(dto CreateBlogDto, currentUser models.ICurrentUser
 			return err
 		}
 
-		if err := tx.Model(&models.UsersStats{}).Where("user_id = ?", currentUser.UserId).Update("total_posts", gorm.Expr("total_posts + 1")).Error; err != nil {
+		if err := s.assignSlug(tx, &blog, currentUser); err != nil {
+			return err
+		}
+		if err := s.recordRevision(tx, blog, currentUser, nil); err != nil {
+			return err
+		}
+		if err := tags.SetBlogTags(tx, blog.BlogId, tagNames, currentUser); err != nil {
 			return err
 		}
+		blog.Tags = tagNames
+
+		if status == models.BlogStatusPublished {
+			if err := adjustTotalPosts(tx, currentUser.UserId, 1); err != nil {
+				return err
+			}
+		}
 
 		if dto.Pinned && dto.RepostedFromBlogId == "" {
 			if dto.PinnedNumerOfDays <= 0 {
 				return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Pinned number of days is required for pinned blogs."}
 			}
-			if err := s.handlePinnedBlog(tx, &blog, currentUser, dto.PinnedNumerOfDays); err != nil {
+			if err := s.handlePinnedBlog(tx, &blog, currentUser, dto.PinnedNumerOfDays, dto.MfaCode); err != nil {
 				return err
 			}
 		}
 		if dto.RepostedFromBlogId != "" {
+			if _, err := findVisibleBlog(tx, dto.RepostedFromBlogId, currentUser); err != nil {
+				return err
+			}
 
 			// Increment sharesCount on the associated blog
 			if err := tx.Model(&models.Blog{}).Where("blog_id = ?", dto.RepostedFromBlogId).Update("shares_count", gorm.Expr("shares_count + ?", 1)).Error; err != nil {
@@ -277,6 +438,9 @@ func (s *BlogsService) Create(dto CreateBlogDto, currentUser models.ICurrentUser
 		return nil
 	})
 	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
 		s.logger.Printf("Error creating blog: %v", err)
 		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to create blog"}
 	}
@@ -289,9 +453,18 @@ func (s *BlogsService) Create(dto CreateBlogDto, currentUser models.ICurrentUser
 
 // Update updates a blog
 func (s *BlogsService) Update(blogId string, dto UpdateBlogDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	var tagNames []string
+	if dto.Tags != nil {
+		var err error
+		if tagNames, err = tags.Normalize(dto.Tags); err != nil {
+			return MutationResponse{}, err
+		}
+	}
+
 	var blog models.Blog
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		err := tx.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "blog_id", Value: blogId}}}).
+		// Lock the blog so concurrent edits get consecutive revision numbers
+		err := tx.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "blog_id", Value: blogId}}}, clause.Locking{Strength: "UPDATE"}).
 			First(&blog).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -299,6 +472,12 @@ func (s *BlogsService) Update(blogId string, dto UpdateBlogDto, currentUser mode
 			}
 			return err
 		}
+		if err := policies.CanUpdateBlog(currentUser, blog); err != nil {
+			return err
+		}
+		if err := s.ensureBaseRevision(tx, blog); err != nil {
+			return err
+		}
 
 		updateData := map[string]interface{}{
 			"updated_at": time.Now().UTC(),
@@ -333,7 +512,48 @@ func (s *BlogsService) Update(blogId string, dto UpdateBlogDto, currentUser mode
 		if dto.Audio != "" {
 			updateData["audio"] = dto.Audio
 		}
-		return tx.Model(&blog).Updates(updateData).Error
+		if dto.Text != "" || dto.Format != "" || len(dto.Images) > 0 {
+			text, format, images := blog.Text, blog.Format, blogImages(blog.Images)
+			if dto.Text != "" {
+				text = dto.Text
+			}
+			if dto.Format != "" {
+				format = dto.Format
+			}
+			if len(dto.Images) > 0 {
+				images = dto.Images
+			}
+			format, html, err := s.renderText(format, text, images)
+			if err != nil {
+				return err
+			}
+			updateData["format"] = format
+			updateData["html"] = html
+		}
+		if err := s.changeBlogStatus(tx, &blog, dto, updateData); err != nil {
+			return err
+		}
+		if err := tx.Model(&blog).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if dto.Tags != nil {
+			if err := tags.SetBlogTags(tx, blog.BlogId, tagNames, currentUser); err != nil {
+				return err
+			}
+		}
+		tagsByBlog, err := tags.BlogTagNames(tx, []string{blog.BlogId})
+		if err != nil {
+			return err
+		}
+		blog.Tags = append([]string{}, tagsByBlog[blog.BlogId]...)
+
+		// A new title gets a new slug; the old one keeps redirecting
+		if blog.Slug == "" || !slugMatchesTitle(blog.Slug, blog.Title) {
+			if err := s.assignSlug(tx, &blog, currentUser); err != nil {
+				return err
+			}
+		}
+		return s.recordRevision(tx, blog, currentUser, nil)
 	})
 	if err != nil {
 		s.logger.Printf("Error updating blog: %v", err)
@@ -362,6 +582,9 @@ func (s *BlogsService) Delete(blogId string, currentUser models.ICurrentUser) (m
 			}
 			return err
 		}
+		if err := policies.CanDeleteBlog(currentUser, blog); err != nil {
+			return err
+		}
 
 		if err := tx.Model(&models.PinnedBlog{}).Where("blog_id = ?", blogId).Delete(&models.PinnedBlog{}).Error; err != nil { // Unpin if pinned
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -371,8 +594,10 @@ func (s *BlogsService) Delete(blogId string, currentUser models.ICurrentUser) (m
 			}
 		}
 
-		if err := tx.Model(&models.UsersStats{}).Where("user_id = ?", blog.UserId).Update("total_posts", gorm.Expr("total_posts - 1")).Error; err != nil {
-			return err
+		if blog.Status == models.BlogStatusPublished {
+			if err := adjustTotalPosts(tx, blog.UserId, -1); err != nil {
+				return err
+			}
 		}
 
 		if err := tx.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "ref_id", Value: blogId}}}).
@@ -422,6 +647,10 @@ func (s *BlogsService) LikeBlog(blogId string, currentUser models.ICurrentUser)
 	var like models.Like
 	var blog models.Blog
 	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findVisibleBlog(tx, blogId, currentUser); err != nil {
+			return err
+		}
+
 		err := tx.Clauses(clause.Where{
 			Exprs: []clause.Expression{
 				clause.Eq{Column: "user_id", Value: currentUser.UserId},
@@ -465,6 +694,9 @@ func (s *BlogsService) LikeBlog(blogId string, currentUser models.ICurrentUser)
 		return tx.Model(&models.UsersStats{}).Where("user_id = ?", currentUser.UserId).Update("total_likes", gorm.Expr("total_likes + 1")).Error
 	})
 	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return LikeResponse{}, fiberErr
+		}
 		s.logger.Printf("Error liking/unliking blog: %v", err)
 		return LikeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to like/unlike blog"}
 	}
@@ -477,14 +709,24 @@ func (s *BlogsService) LikeBlog(blogId string, currentUser models.ICurrentUser)
 
 // AddComment adds a comment to a blog
 func (s *BlogsService) AddComment(blogId string, dto CreateCommentDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	format, html, err := s.renderText(dto.Format, dto.Text, commentImages(dto.Image))
+	if err != nil {
+		return MutationResponse{}, err
+	}
 
 	var comment models.Comment
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findVisibleBlog(tx, blogId, currentUser); err != nil {
+			return err
+		}
+
 		comment = models.Comment{
 			CommentId: utils.GenerateID(),
 			UserId:    currentUser.UserId,
 			RefId:     blogId,
 			Text:      dto.Text,
+			Format:    format,
+			Html:      html,
 			Image:     dto.Image,
 			Video:     dto.Video,
 			Audio:     dto.Audio,
@@ -506,6 +748,9 @@ func (s *BlogsService) AddComment(blogId string, dto CreateCommentDto, currentUs
 		return nil
 	})
 	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
 		s.logger.Printf("Error adding comment: %v", err)
 		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add comment"}
 	}
@@ -558,6 +803,13 @@ func (s *BlogsService) DeleteComment(commentId string, currentUser models.ICurre
 			}
 			return err
 		}
+		blogAuthorId, err := commentBlogAuthor(tx, comment)
+		if err != nil {
+			return err
+		}
+		if err := policies.CanDeleteComment(currentUser, comment, blogAuthorId); err != nil {
+			return err
+		}
 
 		if err := tx.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "ref_id", Value: commentId}}}).
 			Delete(&models.Comment{}).Error; err != nil {
@@ -591,6 +843,64 @@ func (s *BlogsService) DeleteComment(commentId string, currentUser models.ICurre
 	return map[string]string{"message": "Comment deleted successfully"}, nil
 }
 
+// commentBlogAuthor returns the author of the blog a comment or reply belongs to,
+// or an empty string when the blog no longer exists
+func commentBlogAuthor(tx *gorm.DB, comment models.Comment) (string, error) {
+	blogId, err := commentBlogId(tx, comment)
+	if err != nil {
+		return "", err
+	}
+
+	var blog models.Blog
+	err = tx.Select("user_id").Where("blog_id = ?", blogId).First(&blog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return blog.UserId, err
+}
+
+// commentBlogId returns the ID of the blog a comment belongs to. Replies point
+// at their parent comment, so they are followed up to the top-level comment.
+func commentBlogId(tx *gorm.DB, comment models.Comment) (string, error) {
+	refId := comment.RefId
+	for {
+		var parent models.Comment
+		err := tx.Select("ref_id").Where("comment_id = ?", refId).First(&parent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return refId, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		refId = parent.RefId
+	}
+}
+
+// findVisibleComment loads a comment or reply on a blog the current user can
+// see. Comments on blogs hidden from the user are reported as missing.
+func findVisibleComment(tx *gorm.DB, commentId string, currentUser models.ICurrentUser) (models.Comment, error) {
+	notFound := &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Comment with ID %s does not exist", commentId)}
+
+	var comment models.Comment
+	if err := tx.Where("comment_id = ?", commentId).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Comment{}, notFound
+		}
+		return models.Comment{}, err
+	}
+	blogId, err := commentBlogId(tx, comment)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if _, err := findVisibleBlog(tx, blogId, currentUser); err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok && fiberErr.Code == fiber.StatusNotFound {
+			return models.Comment{}, notFound
+		}
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
 // UpdateComment updates a comment
 func (s *BlogsService) UpdateComment(commentId string, dto CreateCommentDto, currentUser models.ICurrentUser) (MutationResponse, error) {
 	var comment models.Comment
@@ -603,6 +913,9 @@ func (s *BlogsService) UpdateComment(commentId string, dto CreateCommentDto, cur
 			}
 			return err
 		}
+		if err := policies.CanUpdateComment(currentUser, comment); err != nil {
+			return err
+		}
 
 		updateData := map[string]interface{}{
 			"updated_at": time.Now().UTC(),
@@ -623,6 +936,24 @@ func (s *BlogsService) UpdateComment(commentId string, dto CreateCommentDto, cur
 		if dto.Sticker != "" {
 			updateData["sticker"] = dto.Sticker
 		}
+		if dto.Text != "" || dto.Format != "" || dto.Image != "" {
+			text, format, image := comment.Text, comment.Format, comment.Image
+			if dto.Text != "" {
+				text = dto.Text
+			}
+			if dto.Format != "" {
+				format = dto.Format
+			}
+			if dto.Image != "" {
+				image = dto.Image
+			}
+			format, html, err := s.renderText(format, text, commentImages(image))
+			if err != nil {
+				return err
+			}
+			updateData["format"] = format
+			updateData["html"] = html
+		}
 
 		return tx.Model(&comment).Updates(updateData).Error
 	})
@@ -700,6 +1031,10 @@ func (s *BlogsService) LikeComment(commentId string, currentUser models.ICurrent
 	var like models.Like
 	var comment models.Comment
 	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findVisibleComment(tx, commentId, currentUser); err != nil {
+			return err
+		}
+
 		err := tx.Clauses(clause.Where{
 			Exprs: []clause.Expression{
 				clause.Eq{Column: "user_id", Value: currentUser.UserId},
@@ -735,6 +1070,9 @@ func (s *BlogsService) LikeComment(commentId string, currentUser models.ICurrent
 		return tx.Model(&models.Comment{}).Where("comment_id = ?", commentId).Update("likes_count", gorm.Expr("likes_count + ?", 1)).First(&comment).Error
 	})
 	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return LikeResponse{}, fiberErr
+		}
 		s.logger.Printf("Error liking/unliking comment: %v", err)
 		return LikeResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to like/unlike comment"}
 	}
@@ -747,6 +1085,14 @@ func (s *BlogsService) LikeComment(commentId string, currentUser models.ICurrent
 
 // FindCommentLikes retrieves likes for a comment
 func (s *BlogsService) FindCommentLikes(commentId string, currentUser models.ICurrentUser) ([]models.Like, error) {
+	if _, err := findVisibleComment(s.db, commentId, currentUser); err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return []models.Like{}, fiberErr
+		}
+		s.logger.Printf("Error fetching comment: %v", err)
+		return []models.Like{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch likes"}
+	}
+
 	var likes []models.Like
 	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "user_id", "full_name", "email", "verified").Preload("UserRoles.Role")
@@ -768,17 +1114,25 @@ func (s *BlogsService) FindCommentLikes(commentId string, currentUser models.ICu
 }
 
 // FindComments retrieves comments for a blog
-func (s *BlogsService) FindComments(blogId string, currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+func (s *BlogsService) FindComments(blogId string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if _, err := findVisibleBlog(s.db, blogId, currentUser); err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return models.PaginatedResponse{Data: []CommentWithMeta{}}, fiberErr
+		}
+		s.logger.Printf("Error fetching blog: %v", err)
+		return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch comments"}
+	}
+
 	var totalItems int64
-	s.db.Model(&models.Comment{}).Where("ref_id = ?", blogId).Count(&totalItems)
+	if params.Count {
+		s.db.Model(&models.Comment{}).Where("ref_id = ?", blogId).Count(&totalItems)
+	}
 
-	offset := (page - 1) * limit
 	var comments []models.Comment
 	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "user_id", "full_name", "email", "verified")
 	}).Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "ref_id", Value: blogId}}}).
-		Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}).
-		Limit(limit).Offset(offset).Find(&comments).Error
+		Scopes(params.Keyset("comments.created_at", "comments.comment_id")).Find(&comments).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s does not exist", blogId)}
@@ -787,8 +1141,11 @@ func (s *BlogsService) FindComments(blogId string, currentUser models.ICurrentUs
 		return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch comments"}
 	}
 
+	comments, metadata := pagination.Trim(params, comments, totalItems, commentCursor)
+
 	var enrichedComments []CommentWithMeta
 	for _, comment := range comments {
+		comment.Html = withHTML(comment.Format, comment.Text, comment.Html)
 		var liked bool = false
 		if currentUser.IsAuthenticated {
 			liked = s.db.Model(&models.Like{}).Clauses(clause.Where{
@@ -807,47 +1164,39 @@ func (s *BlogsService) FindComments(blogId string, currentUser models.ICurrentUs
 		})
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
 	if len(enrichedComments) == 0 {
 		return models.PaginatedResponse{
-			Data: []CommentWithMeta{},
-			Metadata: models.PaginationMetadata{
-				CurrentPage:     int64(page),
-				ItemsPerPage:    int64(limit),
-				TotalItems:      totalItems,
-				TotalPages:      totalPages,
-				HasNextPage:     int64(page) < totalPages,
-				HasPreviousPage: int64(page) > 1,
-			},
+			Data:     []CommentWithMeta{},
+			Metadata: metadata,
 		}, nil
 	}
 
 	return models.PaginatedResponse{
-		Data: enrichedComments,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     enrichedComments,
+		Metadata: metadata,
 	}, nil
 }
 
 // FindReplies retrieves replies for a comment
-func (s *BlogsService) FindReplies(commentId string, currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+func (s *BlogsService) FindReplies(commentId string, currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
+	if _, err := findVisibleComment(s.db, commentId, currentUser); err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return models.PaginatedResponse{Data: []CommentWithMeta{}}, fiberErr
+		}
+		s.logger.Printf("Error fetching comment: %v", err)
+		return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch replies"}
+	}
+
 	var totalItems int64
-	s.db.Model(&models.Comment{}).Where("ref_id = ?", commentId).Count(&totalItems)
+	if params.Count {
+		s.db.Model(&models.Comment{}).Where("ref_id = ?", commentId).Count(&totalItems)
+	}
 
-	offset := (page - 1) * limit
 	var comments []models.Comment
 	err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("profile_image", "user_id", "full_name", "email", "verified")
 	}).Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "ref_id", Value: commentId}}}).
-		Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}).
-		Limit(limit).Offset(offset).Find(&comments).Error
+		Scopes(params.Keyset("comments.created_at", "comments.comment_id")).Find(&comments).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Comment with ID %s does not exist", commentId)}
@@ -856,8 +1205,11 @@ func (s *BlogsService) FindReplies(commentId string, currentUser models.ICurrent
 		return models.PaginatedResponse{Data: []CommentWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch replies"}
 	}
 
+	comments, metadata := pagination.Trim(params, comments, totalItems, commentCursor)
+
 	var enrichedComments []CommentWithMeta
 	for _, comment := range comments {
+		comment.Html = withHTML(comment.Format, comment.Text, comment.Html)
 		var repliesCount, likesCount int64
 		repliesCount = comment.RepliesCount
 		likesCount = comment.LikesCount
@@ -884,44 +1236,39 @@ func (s *BlogsService) FindReplies(commentId string, currentUser models.ICurrent
 		})
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
 	if len(enrichedComments) == 0 {
 		return models.PaginatedResponse{
-			Data: []CommentWithMeta{},
-			Metadata: models.PaginationMetadata{
-				CurrentPage:     int64(page),
-				ItemsPerPage:    int64(limit),
-				TotalItems:      totalItems,
-				TotalPages:      totalPages,
-				HasNextPage:     int64(page) < totalPages,
-				HasPreviousPage: int64(page) > 1,
-			},
+			Data:     []CommentWithMeta{},
+			Metadata: metadata,
 		}, nil
 	}
 
 	return models.PaginatedResponse{
-		Data: enrichedComments,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     enrichedComments,
+		Metadata: metadata,
 	}, nil
 }
 
 // AddReply adds a reply to a comment
 func (s *BlogsService) AddReply(commentId string, dto CreateReplyDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	format, html, err := s.renderText(dto.Format, dto.Text, commentImages(dto.Image))
+	if err != nil {
+		return MutationResponse{}, err
+	}
+
 	var reply models.Comment
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findVisibleComment(tx, commentId, currentUser); err != nil {
+			return err
+		}
+
 		reply = models.Comment{
 			CommentId: utils.GenerateID(),
 			RefId:     commentId,
 			UserId:    currentUser.UserId,
 			Text:      dto.Text,
+			Format:    format,
+			Html:      html,
 			Image:     dto.Image,
 			Video:     dto.Video,
 			Sticker:   dto.Sticker,
@@ -939,6 +1286,9 @@ func (s *BlogsService) AddReply(commentId string, dto CreateReplyDto, currentUse
 		return tx.Model(&models.Comment{}).Where("comment_id = ?", commentId).Update("replies_count", gorm.Expr("replies_count + ?", 1)).Error
 	})
 	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
 		s.logger.Printf("Error adding reply: %v", err)
 		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add reply"}
 	}
@@ -951,6 +1301,14 @@ func (s *BlogsService) AddReply(commentId string, dto CreateReplyDto, currentUse
 
 // FindLikesAndFollowers retrieves likes and followers for a blog
 func (s *BlogsService) FindLikesAndFollowers(blogId string, currentUser models.ICurrentUser) (map[string]interface{}, error) {
+	if _, err := findVisibleBlog(s.db, blogId, currentUser); err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return nil, fiberErr
+		}
+		s.logger.Printf("Error fetching blog: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch likes"}
+	}
+
 	var userIds []string
 	var likes []models.Like
 	err := s.db.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "ref_id", Value: blogId}}}).
@@ -997,7 +1355,22 @@ func (s *BlogsService) FindLikesAndFollowers(blogId string, currentUser models.I
 // enrichBlogs enriches blogs with metadata
 func (s *BlogsService) enrichBlogs(blogs []models.Blog, currentUser models.ICurrentUser) ([]BlogWithMeta, error) {
 	var blogsWithMeta []BlogWithMeta = []BlogWithMeta{}
+	blogIds := make([]string, 0, len(blogs))
+	for _, blog := range blogs {
+		blogIds = append(blogIds, blog.BlogId)
+	}
+	tagsByBlog := map[string][]string{}
+	if len(blogIds) > 0 {
+		var err error
+		if tagsByBlog, err = tags.BlogTagNames(s.db, blogIds); err != nil {
+			s.logger.Printf("Error fetching blog tags: %v", err)
+			return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch blog tags"}
+		}
+	}
+
 	for _, blog := range blogs {
+		blog.Tags = append([]string{}, tagsByBlog[blog.BlogId]...)
+		blog.Html = withHTML(blog.Format, blog.Text, blog.Html)
 		// Counts are now stored directly in the model and updated on creation/deletion
 		var likesCount = blog.LikesCount
 		var sharesCount = blog.SharesCount
@@ -1035,42 +1408,34 @@ func (s *BlogsService) enrichBlogs(blogs []models.Blog, currentUser models.ICurr
 	return blogsWithMeta, nil
 }
 
-// FindPinnedBlogs retrieves all currently active pinned blogs with pagination
-func (s *BlogsService) FindPinnedBlogs(currentUser models.ICurrentUser, page, limit int) (models.PaginatedResponse, error) {
+// FindPinnedBlogs retrieves all currently active pinned blogs, newest pin first
+func (s *BlogsService) FindPinnedBlogs(currentUser models.ICurrentUser, params pagination.Params) (models.PaginatedResponse, error) {
 	now := time.Now().UTC()
-	offset := (page - 1) * limit
 
-	// Get total count of active pinned blogs
 	var totalItems int64
-	if err := s.db.Model(&models.PinnedBlog{}).
-		Where("end_date >= ?", now).
-		Count(&totalItems).Error; err != nil {
-		s.logger.Printf("Error counting active pinned blogs: %v", err)
-		return models.PaginatedResponse{}, &fiber.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: "Unable to count pinned blogs",
+	if params.Count {
+		if err := s.db.Model(&models.PinnedBlog{}).
+			Where("end_date >= ?", now).
+			Scopes(pinnedPublishedBlogs).
+			Count(&totalItems).Error; err != nil {
+			s.logger.Printf("Error counting active pinned blogs: %v", err)
+			return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to count pinned blogs"}
 		}
 	}
 
-	// Fetch active pinned blogs with pagination
 	var pinnedBlogs []models.PinnedBlog
 	err := s.db.
 		Where("end_date >= ?", now).
+		Scopes(pinnedPublishedBlogs, params.Keyset("pinned_blogs.created_at", "pinned_blogs.pinned_blog_id")).
 		Preload("Blog.User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("profile_image", "full_name", "user_id", "email", "verified")
 		}).
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
 		Find(&pinnedBlogs).Error
-
 	if err != nil {
 		s.logger.Printf("Error fetching pinned blogs: %v", err)
-		return models.PaginatedResponse{}, &fiber.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: "Unable to fetch pinned blogs",
-		}
+		return models.PaginatedResponse{Data: []BlogWithMeta{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch pinned blogs"}
 	}
+	pinnedBlogs, metadata := pagination.Trim(params, pinnedBlogs, totalItems, pinnedBlogCursor)
 
 	// Extract blogs and filter out nil entries
 	blogs := make([]models.Blog, 0, len(pinnedBlogs))
@@ -1080,30 +1445,21 @@ func (s *BlogsService) FindPinnedBlogs(currentUser models.ICurrentUser, page, li
 		}
 	}
 
-	// Enrich blogs with metadata
 	blogsWithMeta, err := s.enrichBlogs(blogs, currentUser)
 	if err != nil {
 		return models.PaginatedResponse{}, fmt.Errorf("failed to enrich blogs: %w", err)
 	}
 
-	// Calculate pagination metadata
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
-	return models.PaginatedResponse{
-		Data: blogsWithMeta,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
-	}, nil
+	return models.PaginatedResponse{Data: blogsWithMeta, Metadata: metadata}, nil
 }
 
-// handlePinnedBlog creates a new pinned blog entry and handles payment
-func (s *BlogsService) handlePinnedBlog(tx *gorm.DB, blog *models.Blog, currentUser models.ICurrentUser, pinnedNumberOfDays int) error {
+// handlePinnedBlog creates a new pinned blog entry and handles payment.
+// Users with two-factor authentication must confirm the payment with a code.
+func (s *BlogsService) handlePinnedBlog(tx *gorm.DB, blog *models.Blog, currentUser models.ICurrentUser, pinnedNumberOfDays int, mfaCode string) error {
+	if err := s.authService.RequireSecondFactor(tx, currentUser, mfaCode); err != nil {
+		return err
+	}
+
 	pinnedBlogPricePerDay := 20.00 // Assuming a price per day
 	totalPinnedBlogPrice := pinnedBlogPricePerDay * float64(pinnedNumberOfDays)
 
@@ -1187,6 +1543,266 @@ func (s *BlogsService) handlePinnedBlog(tx *gorm.DB, blog *models.Blog, currentU
 	return nil
 }
 
+// blogStatusTransitions lists the statuses each status can move to. A
+// published blog can only be archived; restoring it from the archive
+// republishes it.
+var blogStatusTransitions = map[models.BlogStatus][]models.BlogStatus{
+	models.BlogStatusDraft:     {models.BlogStatusScheduled, models.BlogStatusPublished, models.BlogStatusArchived},
+	models.BlogStatusScheduled: {models.BlogStatusDraft, models.BlogStatusPublished, models.BlogStatusArchived},
+	models.BlogStatusPublished: {models.BlogStatusArchived},
+	models.BlogStatusArchived:  {models.BlogStatusDraft, models.BlogStatusPublished},
+}
+
+// blogCursor is a blog's position in a list ordered by creation
+func blogCursor(blog models.Blog) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: blog.CreatedAt, Id: blog.BlogId}
+}
+
+// pinnedBlogCursor is a pin's position in a list ordered by creation
+func pinnedBlogCursor(pin models.PinnedBlog) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: pin.CreatedAt, Id: pin.PinnedBlogId}
+}
+
+// commentCursor is a comment's position in a list ordered by creation
+func commentCursor(comment models.Comment) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: comment.CreatedAt, Id: comment.CommentId}
+}
+
+// publishedBlogs limits a blog query to published blogs
+func publishedBlogs(db *gorm.DB) *gorm.DB {
+	return db.Where("blogs.status = ?", models.BlogStatusPublished)
+}
+
+// pinnedPublishedBlogs limits a pinned blog query to published blogs
+func pinnedPublishedBlogs(db *gorm.DB) *gorm.DB {
+	return db.Where("blog_id IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&models.Blog{}).Select("blog_id").Scopes(publishedBlogs))
+}
+
+// initialBlogStatus works out the status of a new blog. Without an explicit
+// status a blog is published, or scheduled when PublishAt is in the future.
+func initialBlogStatus(dto CreateBlogDto, now time.Time) (models.BlogStatus, error) {
+	status := dto.Status
+	if status == "" {
+		status = models.BlogStatusPublished
+		if dto.PublishAt != nil && dto.PublishAt.After(now) {
+			status = models.BlogStatusScheduled
+		}
+	}
+
+	switch status {
+	case models.BlogStatusDraft, models.BlogStatusPublished:
+		return status, nil
+	case models.BlogStatusScheduled:
+		if dto.PublishAt == nil || !dto.PublishAt.After(now) {
+			return "", &fiber.Error{Code: fiber.StatusBadRequest, Message: "Scheduled blogs need a publishAt time in the future"}
+		}
+		return status, nil
+	}
+	return "", &fiber.Error{Code: fiber.StatusBadRequest, Message: "Status must be draft, scheduled or published"}
+}
+
+// changeBlogStatus validates the status and publishAt of an update and adds
+// them to updateData, keeping the author's post count in step with publishing.
+func (s *BlogsService) changeBlogStatus(tx *gorm.DB, blog *models.Blog, dto UpdateBlogDto, updateData map[string]interface{}) error {
+	if dto.Status == "" && dto.PublishAt == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+
+	target := dto.Status
+	if target == "" {
+		target = blog.Status
+	}
+	if _, known := blogStatusTransitions[target]; !known {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Status must be draft, scheduled, published or archived"}
+	}
+	if target != blog.Status && !containsStatus(blogStatusTransitions[blog.Status], target) {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("A %s blog cannot be moved to %s", blog.Status, target)}
+	}
+
+	publishAt := blog.PublishAt
+	if dto.PublishAt != nil {
+		if target != models.BlogStatusDraft && target != models.BlogStatusScheduled {
+			return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Only draft and scheduled blogs can have a publishAt time"}
+		}
+		publishAt = dto.PublishAt
+	}
+	if target == models.BlogStatusScheduled && (publishAt == nil || !publishAt.After(now)) {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Scheduled blogs need a publishAt time in the future"}
+	}
+
+	wasPublished := blog.Status == models.BlogStatusPublished
+	isPublished := target == models.BlogStatusPublished
+	switch {
+	case isPublished && !wasPublished:
+		if blog.PublishedAt == nil {
+			updateData["published_at"] = now
+		}
+		if err := adjustTotalPosts(tx, blog.UserId, 1); err != nil {
+			return err
+		}
+	case wasPublished && !isPublished:
+		if err := adjustTotalPosts(tx, blog.UserId, -1); err != nil {
+			return err
+		}
+	}
+
+	updateData["status"] = target
+	updateData["publish_at"] = publishAt
+	return nil
+}
+
+// adjustTotalPosts changes a user's published post count
+func adjustTotalPosts(tx *gorm.DB, userId string, delta int) error {
+	return tx.Model(&models.UsersStats{}).Where("user_id = ?", userId).Update("total_posts", gorm.Expr("total_posts + ?", delta)).Error
+}
+
+// findVisibleBlog returns a blog the current user may see and interact with:
+// published blogs are visible to everyone, other blogs only to their author and
+// admins. Hidden blogs are reported as missing, as in FindOne.
+func findVisibleBlog(tx *gorm.DB, blogId string, currentUser models.ICurrentUser) (models.Blog, error) {
+	var blog models.Blog
+	err := tx.Select("blog_id", "user_id", "status").Where("blog_id = ?", blogId).First(&blog).Error
+	if err == nil && blog.Status != models.BlogStatusPublished && blog.UserId != currentUser.UserId && !currentUser.IsAdmin() {
+		err = gorm.ErrRecordNotFound
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Blog{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s does not exist", blogId)}
+	}
+	return blog, err
+}
+
+// containsStatus checks if a slice contains a blog status
+func containsStatus(statuses []models.BlogStatus, status models.BlogStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// assignSlug gives a blog a unique slug derived from its title, records it in
+// the blog's slug history and points the blog's Url at it. It must run in a
+// transaction: when another blog claims the same slug first, the history
+// insert is rolled back to a savepoint and the next free suffix is tried.
+func (s *BlogsService) assignSlug(tx *gorm.DB, blog *models.Blog, currentUser models.ICurrentUser) error {
+	var slug string
+	for attempt := 1; ; attempt++ {
+		candidate, owned, err := uniqueSlug(tx, titleSlug(blog.Title), blog.BlogId)
+		if err != nil {
+			return err
+		}
+		if owned {
+			slug = candidate
+			break
+		}
+
+		err = claimSlug(tx, models.BlogSlug{
+			BlogSlugId: utils.GenerateID(),
+			BlogId:     blog.BlogId,
+			Slug:       candidate,
+			CreatedAt:  time.Now().UTC(),
+			UpdatedAt:  time.Now().UTC(),
+			CreatedBy:  currentUser.FullName,
+			UpdatedBy:  currentUser.FullName,
+		})
+		if err == nil {
+			slug = candidate
+			break
+		}
+		if !isUniqueViolation(err) || attempt == maxSlugAttempts {
+			return err
+		}
+	}
+
+	blog.Slug = slug
+	blog.Url = s.blogURL(slug)
+	return tx.Model(&models.Blog{}).Where("blog_id = ?", blog.BlogId).Updates(map[string]interface{}{
+		"slug": blog.Slug,
+		"url":  blog.Url,
+	}).Error
+}
+
+// claimSlug adds a slug to a blog's history under a savepoint, so that losing
+// a race for the slug leaves the transaction usable for another try
+func claimSlug(tx *gorm.DB, history models.BlogSlug) error {
+	if err := tx.SavePoint("claim_slug").Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		if rollbackErr := tx.RollbackTo("claim_slug").Error; rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether a database error is a unique constraint
+// violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// blogURL returns the canonical public URL of the blog with the given slug
+func (s *BlogsService) blogURL(slug string) string {
+	return s.publicWebURL + "/blogs/" + slug
+}
+
+// titleSlug returns the slug for a title, before any collision suffix
+func titleSlug(title string) string {
+	if slug := utils.Slugify(title); slug != "" {
+		return slug
+	}
+	// Titles with nothing to transliterate, such as CJK text
+	return "post"
+}
+
+// uniqueSlug returns base, or base with the lowest free "-2", "-3"... suffix.
+// Slugs in any blog's history count as taken, except those of the blog
+// itself, which it may return to; owned reports such a slug.
+func uniqueSlug(tx *gorm.DB, base string, blogId string) (slug string, owned bool, err error) {
+	var existing []models.BlogSlug
+	err = tx.Select("blog_id", "slug").Where("slug = ? OR slug LIKE ?", base, base+"-%").Find(&existing).Error
+	if err != nil {
+		return "", false, err
+	}
+	owners := make(map[string]string, len(existing))
+	for _, history := range existing {
+		owners[history.Slug] = history.BlogId
+	}
+
+	for i := 1; ; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		switch owners[candidate] {
+		case "":
+			return candidate, false, nil
+		case blogId:
+			return candidate, true, nil
+		}
+	}
+}
+
+// slugMatchesTitle reports whether a slug was derived from the title, with or
+// without a collision suffix, so retitling "Hello!" to "Hello" keeps its slug
+func slugMatchesTitle(slug, title string) bool {
+	base := titleSlug(title)
+	if slug == base {
+		return true
+	}
+	suffix, found := strings.CutPrefix(slug, base+"-")
+	if !found {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, v := range slice {
@@ -0,0 +1,60 @@
+package blogs
+
+import (
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// slugBackfillBatch is how many blogs BackfillSlugs loads at a time
+const slugBackfillBatch = 100
+
+// BackfillSlugs gives a slug, a slug history entry and a canonical Url to
+// every blog created before slugs existed, oldest first so that they get the
+// unsuffixed slugs. It returns how many blogs it updated. Each blog is locked
+// and checked again in its own transaction, so several replicas can run it at
+// once while the API serves requests.
+func (s *BlogsService) BackfillSlugs() (int, error) {
+	system := models.ICurrentUser{FullName: "system"}
+	count := 0
+	for {
+		var pending []models.Blog
+		err := s.db.Select("blog_id").Where("slug IS NULL OR slug = ''").
+			Order("created_at").Limit(slugBackfillBatch).Find(&pending).Error
+		if err != nil || len(pending) == 0 {
+			return count, err
+		}
+
+		for _, candidate := range pending {
+			err := s.db.Transaction(func(tx *gorm.DB) error {
+				var blog models.Blog
+				locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("blog_id", "title").
+					Where("blog_id = ? AND (slug IS NULL OR slug = '')", candidate.BlogId).Limit(1).Find(&blog)
+				if locked.Error != nil || locked.RowsAffected == 0 {
+					// Another replica got there first
+					return locked.Error
+				}
+				if err := s.assignSlug(tx, &blog, system); err != nil {
+					return err
+				}
+				count++
+				return nil
+			})
+			if err != nil {
+				return count, err
+			}
+		}
+	}
+}
+
+// StartSlugBackfill runs BackfillSlugs once in the background
+func (s *BlogsService) StartSlugBackfill() {
+	go func() {
+		count, err := s.BackfillSlugs()
+		if err != nil {
+			s.logger.Printf("Error backfilling blog slugs: %v", err)
+		} else if count > 0 {
+			s.logger.Printf("Backfilled slugs of %d blogs", count)
+		}
+	}()
+}
@@ -35,5 +35,12 @@ func NewDatabaseConnection() (*gorm.DB, error) {
 }
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&models.User{}, &models.Blog{}, &models.Follow{}, &models.Comment{}, &models.Like{}, &models.Share{}, &models.Role{}, &models.UserRole{}, &models.UsersStats{})
+	err := db.AutoMigrate(&models.User{}, &models.Blog{}, &models.Follow{}, &models.Comment{}, &models.Like{}, &models.Share{}, &models.Role{}, &models.UserRole{}, &models.UsersStats{}, &models.RefreshToken{}, &models.OneTimeToken{}, &models.UserMfa{}, &models.MfaRecoveryCode{}, &models.AuditLog{}, &models.ApiKey{}, &models.ExternalIdentity{}, &models.OidcLoginState{}, &models.BlogSlug{}, &models.BlogRevision{}, &models.Tag{}, &models.BlogTag{}, &models.TagFollow{}, &models.Show{}, &models.ShowEpisode{}, &models.Download{}, &models.BlogScore{}, &models.UserAffinity{})
+	if err != nil {
+		return err
+	}
+	if err := migrateSearch(db); err != nil {
+		return err
+	}
+	return migratePagination(db)
 }
@@ -5,6 +5,9 @@ ALTER ROLE phinex SET TIME ZONE 'UTC';
 -- Create schemas
 CREATE SCHEMA IF NOT EXISTS public;
 
+-- Trigram matching for fuzzy user name search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 -- Create ENUM types in public schema
 CREATE TYPE public.user_status AS ENUM ('active', 'banned', 'suspended', 'online');
 CREATE TYPE public.role_name AS ENUM ('Authenticated', 'Anonymous', 'BusinessOwner', 'SuperAdmin', 'PaymentAgent', 'Admin');
@@ -16,6 +19,7 @@ CREATE TYPE public.preorder_status AS ENUM ('requested', 'pending', 'approved',
 CREATE TYPE public.product_type AS ENUM ('order', 'preorder');
 CREATE TYPE public.transaction_type AS ENUM ('deposit', 'withdrawal', 'transfer', 'payment', 'refund');
 CREATE TYPE public.transaction_status AS ENUM ('pending', 'completed', 'failed', 'canceled');
+CREATE TYPE public.blog_status AS ENUM ('draft', 'scheduled', 'published', 'archived');
 CREATE TYPE public.refund_request_status AS ENUM ('pending', 'approved', 'rejected', 'processed');
 CREATE TYPE public.subscription_status AS ENUM ('active', 'cancelled', 'expired');
 CREATE TYPE public.subscription_plan AS ENUM ('monthly', 'yearly');
@@ -34,6 +38,8 @@ CREATE TABLE IF NOT EXISTS public.users (
     bio TEXT,
     phone_number VARCHAR,
     status public.user_status DEFAULT 'active',
+    status_reason TEXT,
+    suspended_until TIMESTAMPTZ,
     password VARCHAR NOT NULL,
     gender VARCHAR NOT NULL,
     dob DATE NOT NULL,
@@ -41,6 +47,7 @@ CREATE TABLE IF NOT EXISTS public.users (
     verified BOOLEAN DEFAULT FALSE,
     email_is_verified BOOLEAN DEFAULT FALSE,
     phone_number_is_verified BOOLEAN DEFAULT FALSE,
+    search_vector TSVECTOR GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(full_name, '') || ' ' || coalesce(user_name, '')), 'A') || setweight(to_tsvector('english', coalesce(bio, '')), 'C')) STORED,
     created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMPTZ,
     created_by VARCHAR(80) NOT NULL,
@@ -79,7 +86,7 @@ CREATE TABLE IF NOT EXISTS public.blogs (
     blog_id VARCHAR(25) PRIMARY KEY,
     blog_id_serial SERIAL UNIQUE,
     user_id VARCHAR(25) NOT NULL,
-    slug VARCHAR(25),
+    slug VARCHAR(100),
     title VARCHAR,
     url TEXT,
     external_link TEXT,
@@ -89,9 +96,16 @@ CREATE TABLE IF NOT EXISTS public.blogs (
     shares_count INTEGER NOT NULL DEFAULT 0,
     is_reel BOOLEAN DEFAULT FALSE,
     views_count INTEGER DEFAULT 0,
+    downloads_count INTEGER DEFAULT 0,
     text TEXT,
+    format VARCHAR(20) DEFAULT 'plain',
+    html TEXT,
     images JSON,
     video TEXT,
+    status public.blog_status NOT NULL DEFAULT 'published',
+    publish_at TIMESTAMPTZ,
+    published_at TIMESTAMPTZ,
+    search_vector TSVECTOR GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(title, '')), 'A') || setweight(to_tsvector('english', coalesce(text, '')), 'B')) STORED,
     created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     created_by VARCHAR(80) NOT NULL,
@@ -367,11 +381,14 @@ CREATE TABLE IF NOT EXISTS public.comments (
     ref_id VARCHAR(25),
     user_id VARCHAR(25),
     text TEXT,
+    format VARCHAR(20) DEFAULT 'plain',
+    html TEXT,
     image TEXT,
     likes_count INTEGER DEFAULT 0,
     replies_count INTEGER DEFAULT 0,
     sticker TEXT,
     video TEXT,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', coalesce(text, ''))) STORED,
     created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
     created_by VARCHAR(80) NOT NULL,
@@ -683,12 +700,265 @@ CREATE TABLE IF NOT EXISTS public.reports (
     FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS public.refresh_tokens (
+    refresh_token_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    family_id VARCHAR(25) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    replaced_by VARCHAR(25),
+    ip VARCHAR(64),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.one_time_tokens (
+    one_time_token_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    purpose VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.user_mfas (
+    user_mfa_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL UNIQUE,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    confirmed_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.mfa_recovery_codes (
+    mfa_recovery_code_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.audit_logs (
+    audit_log_id VARCHAR(25) PRIMARY KEY,
+    actor_id VARCHAR(25),
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    details JSONB,
+    ip VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.api_keys (
+    api_key_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    name VARCHAR(80) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.external_identities (
+    external_identity_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.oidc_login_states (
+    oidc_login_state_id VARCHAR(25) PRIMARY KEY,
+    state_hash VARCHAR(64) NOT NULL UNIQUE,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.blog_slugs (
+    blog_slug_id VARCHAR(25) PRIMARY KEY,
+    blog_id VARCHAR(25) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (blog_id) REFERENCES public.blogs(blog_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.blog_revisions (
+    blog_revision_id VARCHAR(25) PRIMARY KEY,
+    blog_id VARCHAR(25) NOT NULL,
+    revision INTEGER NOT NULL,
+    author_id VARCHAR(25) NOT NULL,
+    title VARCHAR(255),
+    external_link TEXT,
+    external_link_title VARCHAR(255),
+    text TEXT,
+    format VARCHAR(20) DEFAULT 'plain',
+    html TEXT,
+    images JSON,
+    video TEXT,
+    audio TEXT,
+    changed_fields JSON,
+    restored_from INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (blog_id) REFERENCES public.blogs(blog_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.tags (
+    tag_id VARCHAR(25) PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.blog_tags (
+    blog_tag_id VARCHAR(25) PRIMARY KEY,
+    blog_id VARCHAR(25) NOT NULL,
+    tag_id VARCHAR(25) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (blog_id) REFERENCES public.blogs(blog_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES public.tags(tag_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.tag_follows (
+    tag_follow_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    tag_id VARCHAR(25) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES public.tags(tag_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.shows (
+    show_id VARCHAR(25) PRIMARY KEY,
+    user_id VARCHAR(25) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    artwork TEXT,
+    category VARCHAR(100),
+    subcategory VARCHAR(100),
+    explicit BOOLEAN DEFAULT FALSE,
+    language VARCHAR(10) DEFAULT 'en',
+    type VARCHAR(20) DEFAULT 'episodic',
+    author VARCHAR(255),
+    owner_email VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.show_episodes (
+    show_episode_id VARCHAR(25) PRIMARY KEY,
+    show_id VARCHAR(25) NOT NULL,
+    blog_id VARCHAR(25) NOT NULL UNIQUE,
+    season_number INTEGER NOT NULL DEFAULT 0,
+    episode_number INTEGER NOT NULL,
+    episode_type VARCHAR(20) DEFAULT 'full',
+    explicit BOOLEAN DEFAULT FALSE,
+    audio_length BIGINT,
+    audio_type VARCHAR(100),
+    duration_seconds INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (show_id) REFERENCES public.shows(show_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (blog_id) REFERENCES public.blogs(blog_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.downloads (
+    download_id VARCHAR(25) PRIMARY KEY,
+    ref_id VARCHAR(25) NOT NULL,
+    user_id VARCHAR(25),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    created_by VARCHAR(80) NOT NULL,
+    updated_by VARCHAR(80) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.blog_scores (
+    blog_id VARCHAR(25) PRIMARY KEY,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (blog_id) REFERENCES public.blogs(blog_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.user_affinities (
+    user_id VARCHAR(25) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    target_id VARCHAR(25) NOT NULL,
+    weight DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, kind, target_id),
+    FOREIGN KEY (user_id) REFERENCES public.users(user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
 -- Indexes for blogs
 CREATE INDEX idx_blogs_user_id ON public.blogs(user_id);
 CREATE INDEX idx_blogs_slug ON public.blogs(slug);
 CREATE INDEX idx_blogs_created_at ON public.blogs(created_at);
+CREATE INDEX idx_blogs_search_vector ON public.blogs USING GIN (search_vector);
 CREATE INDEX idx_blogs_is_reel ON public.blogs(is_reel);
 CREATE INDEX idx_blogs_views_count ON public.blogs(views_count);
+CREATE INDEX idx_blogs_status_publish_at ON public.blogs(status, publish_at);
+CREATE INDEX idx_blogs_created_at_blog_id ON public.blogs(created_at DESC, blog_id DESC);
 
 -- Indexes for likes
 CREATE INDEX idx_likes_user_id ON public.likes(user_id);
@@ -703,6 +973,9 @@ CREATE INDEX idx_roles_created_at ON public.roles(created_at);
 CREATE INDEX idx_users_email ON public.users(email);
 CREATE INDEX idx_users_status ON public.users(status);
 CREATE INDEX idx_users_created_at ON public.users(created_at);
+CREATE INDEX idx_users_search_vector ON public.users USING GIN (search_vector);
+CREATE INDEX idx_users_full_name_trgm ON public.users USING GIN (full_name gin_trgm_ops);
+CREATE INDEX idx_users_user_name_trgm ON public.users USING GIN (user_name gin_trgm_ops);
 
 -- Indexes for public.users_stats
 CREATE INDEX idx_users_stats_user_id ON public.users_stats(user_id);
@@ -768,6 +1041,8 @@ CREATE INDEX idx_cart_items_created_at ON public.cart_items(created_at);
 CREATE INDEX idx_comments_user_id ON public.comments(user_id);
 CREATE INDEX idx_comments_ref_id ON public.comments(ref_id);
 CREATE INDEX idx_comments_created_at ON public.comments(created_at);
+CREATE INDEX idx_comments_ref_id_created_at ON public.comments(ref_id, created_at DESC, comment_id DESC);
+CREATE INDEX idx_comments_search_vector ON public.comments USING GIN (search_vector);
 
 -- Indexes for public.views
 CREATE INDEX idx_views_ref_id ON public.views(ref_id);
@@ -792,6 +1067,8 @@ CREATE INDEX idx_contacts_created_at ON public.contacts(created_at);
 CREATE INDEX idx_follows_follower_id ON public.follows(follower_id);
 CREATE INDEX idx_follows_following_id ON public.follows(following_id);
 CREATE INDEX idx_follows_created_at ON public.follows(created_at);
+CREATE INDEX idx_follows_follower_id_created_at ON public.follows(follower_id, created_at DESC, follow_id DESC);
+CREATE INDEX idx_follows_following_id_created_at ON public.follows(following_id, created_at DESC, follow_id DESC);
 
 -- Indexes for public.notification_details
 CREATE INDEX idx_notification_details_user_id ON public.notification_details(user_id);
@@ -868,6 +1145,55 @@ CREATE INDEX idx_subscriptions_created_at ON public.subscriptions(created_at);
 CREATE INDEX idx_reports_user_id ON public.reports(user_id);
 CREATE INDEX idx_reports_created_at ON public.reports(created_at);
 
+-- Indexes for refresh_tokens
+CREATE INDEX idx_refresh_tokens_user_id ON public.refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON public.refresh_tokens(family_id);
+
+-- Indexes for one_time_tokens
+CREATE INDEX idx_one_time_tokens_token_hash ON public.one_time_tokens(token_hash);
+CREATE INDEX idx_one_time_tokens_user_id_purpose ON public.one_time_tokens(user_id, purpose);
+
+-- Indexes for mfa_recovery_codes
+CREATE INDEX idx_mfa_recovery_codes_user_id ON public.mfa_recovery_codes(user_id);
+
+-- Indexes for audit_logs
+CREATE INDEX idx_audit_logs_target ON public.audit_logs(target_type, target_id);
+CREATE INDEX idx_audit_logs_actor_id ON public.audit_logs(actor_id);
+CREATE INDEX idx_audit_logs_created_at ON public.audit_logs(created_at);
+
+-- Indexes for api_keys
+CREATE INDEX idx_api_keys_user_id ON public.api_keys(user_id);
+
+-- Indexes for external_identities
+CREATE UNIQUE INDEX idx_external_identities_issuer_subject ON public.external_identities(issuer, subject);
+CREATE INDEX idx_external_identities_user_id ON public.external_identities(user_id);
+
+-- Indexes for blog_slugs
+CREATE INDEX idx_blog_slugs_blog_id ON public.blog_slugs(blog_id);
+
+-- Indexes for blog_revisions
+CREATE UNIQUE INDEX idx_blog_revisions_blog_id_revision ON public.blog_revisions(blog_id, revision);
+
+-- Indexes for blog_tags
+CREATE UNIQUE INDEX idx_blog_tags_blog_id_tag_id ON public.blog_tags(blog_id, tag_id);
+CREATE INDEX idx_blog_tags_tag_id ON public.blog_tags(tag_id);
+
+-- Indexes for tag_follows
+CREATE UNIQUE INDEX idx_tag_follows_user_id_tag_id ON public.tag_follows(user_id, tag_id);
+CREATE INDEX idx_tag_follows_tag_id ON public.tag_follows(tag_id);
+
+-- Indexes for shows
+CREATE INDEX idx_shows_user_id ON public.shows(user_id);
+
+-- Indexes for show_episodes
+CREATE UNIQUE INDEX idx_show_episodes_numbering ON public.show_episodes(show_id, season_number, episode_number);
+
+-- Indexes for downloads
+CREATE INDEX idx_downloads_ref_id ON public.downloads(ref_id);
+
+-- Indexes for blog_scores
+CREATE INDEX idx_blog_scores_score ON public.blog_scores(score);
+
 -- Grant Access to role
 GRANT USAGE ON SCHEMA public TO phinex;
 GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO phinex;
@@ -0,0 +1,22 @@
+package database
+
+import "gorm.io/gorm"
+
+// paginationMigrations add the indexes behind cursor pagination, which walks
+// lists newest first by (created_at, id). They are kept in step with init.sql
+// here for databases created before cursors were introduced.
+var paginationMigrations = []string{
+	`CREATE INDEX IF NOT EXISTS idx_blogs_created_at_blog_id ON blogs (created_at DESC, blog_id DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_ref_id_created_at ON comments (ref_id, created_at DESC, comment_id DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_follows_follower_id_created_at ON follows (follower_id, created_at DESC, follow_id DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_follows_following_id_created_at ON follows (following_id, created_at DESC, follow_id DESC)`,
+}
+
+func migratePagination(db *gorm.DB) error {
+	for _, statement := range paginationMigrations {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
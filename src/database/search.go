@@ -0,0 +1,27 @@
+package database
+
+import "gorm.io/gorm"
+
+// searchMigrations add the generated tsvector columns and indexes behind
+// full-text search. GORM cannot declare generated columns, so they are kept
+// in step with init.sql here.
+var searchMigrations = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`ALTER TABLE blogs ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(title, '')), 'A') || setweight(to_tsvector('english', coalesce(text, '')), 'B')) STORED`,
+	`ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(text, ''))) STORED`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(full_name, '') || ' ' || coalesce(user_name, '')), 'A') || setweight(to_tsvector('english', coalesce(bio, '')), 'C')) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_blogs_search_vector ON blogs USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users USING GIN (full_name gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_user_name_trgm ON users USING GIN (user_name gin_trgm_ops)`,
+}
+
+func migrateSearch(db *gorm.DB) error {
+	for _, statement := range searchMigrations {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
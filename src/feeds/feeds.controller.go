@@ -0,0 +1,135 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FeedsController handles HTTP requests for syndication feeds
+type FeedsController struct {
+	service *FeedsService
+}
+
+// NewFeedsController creates a new FeedsController instance
+func NewFeedsController(service *FeedsService) *FeedsController {
+	return &FeedsController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the feed routes to the Fiber app. Feeds are public,
+// so no guard covers /feeds.
+func (c *FeedsController) RegisterRoutes(app *fiber.App) {
+	app.Get("/feeds/latest.:format", c.FindLatestFeed)
+	app.Get("/feeds/users/:userId.:format", c.FindAuthorFeed)
+	app.Get("/feeds/tags/:tag.:format", c.FindTagFeed)
+}
+
+// @Summary Latest blogs feed
+// @Description The latest published blogs as RSS 2.0, Atom or JSON Feed. Supports conditional GET with ETag and Last-Modified.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param format path string true "rss, atom or json"
+// @Success 200 {string} string "The feed"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/latest.{format} [get]
+func (c *FeedsController) FindLatestFeed(ctx *fiber.Ctx) error {
+	feed, err := c.service.LatestFeed()
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, feed)
+}
+
+// @Summary Author feed
+// @Description A user's latest published blogs as RSS 2.0, Atom or JSON Feed. Supports conditional GET with ETag and Last-Modified.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param format path string true "rss, atom or json"
+// @Success 200 {string} string "The feed"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/users/{userId}.{format} [get]
+func (c *FeedsController) FindAuthorFeed(ctx *fiber.Ctx) error {
+	feed, err := c.service.AuthorFeed(ctx.Params("userId"))
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, feed)
+}
+
+// @Summary Tag feed
+// @Description The latest published blogs with a tag as RSS 2.0, Atom or JSON Feed. Supports conditional GET with ETag and Last-Modified.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param tag path string true "Tag name"
+// @Param format path string true "rss, atom or json"
+// @Success 200 {string} string "The feed"
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/tags/{tag}.{format} [get]
+func (c *FeedsController) FindTagFeed(ctx *fiber.Ctx) error {
+	feed, err := c.service.TagFeed(ctx.Params("tag"))
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, feed)
+}
+
+// send renders a feed in the requested format
+func (c *FeedsController) send(ctx *fiber.Ctx, feed Feed) error {
+	body, contentType, err := c.service.Render(feed, ctx.Params("format"), ctx.Path())
+	if err != nil {
+		return err
+	}
+	return SendConditional(ctx, body, contentType, feed.Updated())
+}
+
+// SendConditional sends a feed body with caching headers, answering 304 Not
+// Modified when the client's copy is still current. updated may be zero when
+// the feed is empty.
+func SendConditional(ctx *fiber.Ctx, body []byte, contentType string, updated time.Time) error {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if !updated.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, updated.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(ctx.Get(fiber.HeaderIfNoneMatch), ctx.Get(fiber.HeaderIfModifiedSince), etag, updated) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Send(body)
+}
+
+// notModified applies the conditional GET rules: If-None-Match takes
+// precedence, and If-Modified-Since is compared at one-second precision.
+func notModified(ifNoneMatch, ifModifiedSince, etag string, updated time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince == "" || updated.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	return err == nil && !updated.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,360 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/epsierra/phinex-blog-api/src/blogs"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
+	"github.com/epsierra/phinex-blog-api/src/tags"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Feed formats
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+const (
+	// siteName titles the feeds
+	siteName = "Phinex"
+	// summaryLength is how much of a blog's text RSS descriptions carry
+	summaryLength = 300
+)
+
+// Feed is a list of published blogs to render as RSS, Atom or JSON Feed
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	Blogs       []blogs.BlogWithMeta
+}
+
+// Updated is when the newest change to the feed's blogs was made
+func (f Feed) Updated() time.Time {
+	var updated time.Time
+	for _, blog := range f.Blogs {
+		if blog.Blog.UpdatedAt.After(updated) {
+			updated = blog.Blog.UpdatedAt
+		}
+	}
+	return updated
+}
+
+// FeedsService builds syndication feeds from published blogs
+type FeedsService struct {
+	db           *gorm.DB
+	logger       *log.Logger
+	blogsService *blogs.BlogsService
+	publicWebURL string
+	baseURL      string
+	itemsLimit   int
+}
+
+// NewFeedsService creates a new FeedsService instance. Feeds carry the
+// latest FEED_ITEMS_LIMIT (default 20) blogs and link to themselves under the
+// API's BASE_URL.
+func NewFeedsService(db *gorm.DB, blogsService *blogs.BlogsService) *FeedsService {
+	return &FeedsService{
+		db:           db,
+		logger:       log.New(os.Stderr, "feeds-service: ", log.LstdFlags),
+		blogsService: blogsService,
+		publicWebURL: strings.TrimRight(os.Getenv("PUBLIC_WEB_URL"), "/"),
+		baseURL:      strings.TrimRight(os.Getenv("BASE_URL"), "/"),
+		itemsLimit:   utils.GetEnvInt("FEED_ITEMS_LIMIT", 20),
+	}
+}
+
+// LatestFeed returns the newest published blogs
+func (s *FeedsService) LatestFeed() (Feed, error) {
+	response, err := s.blogsService.FindAll(anonymous(), pagination.Params{Page: 1, Limit: s.itemsLimit})
+	if err != nil {
+		return Feed{}, err
+	}
+	return Feed{
+		Title:       siteName + " - Latest",
+		Description: "The latest blogs on " + siteName,
+		Link:        s.publicWebURL,
+		Blogs:       response.Data.([]blogs.BlogWithMeta),
+	}, nil
+}
+
+// AuthorFeed returns a user's newest published blogs
+func (s *FeedsService) AuthorFeed(userId string) (Feed, error) {
+	var author models.User
+	if err := s.db.Select("user_id", "full_name").Where("user_id = ?", userId).First(&author).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Feed{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("User with ID %s does not exist", userId)}
+		}
+		s.logger.Printf("Error fetching feed author: %v", err)
+		return Feed{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch feed"}
+	}
+
+	response, err := s.blogsService.FindUserBlogs(userId, anonymous(), pagination.Params{Page: 1, Limit: s.itemsLimit})
+	if err != nil {
+		return Feed{}, err
+	}
+	return Feed{
+		Title:       author.FullName + " on " + siteName,
+		Description: "The latest blogs by " + author.FullName,
+		Link:        s.publicWebURL + "/users/" + url.PathEscape(userId),
+		Blogs:       response.Data.([]blogs.BlogWithMeta),
+	}, nil
+}
+
+// TagFeed returns the newest published blogs with a tag
+func (s *FeedsService) TagFeed(tag string) (Feed, error) {
+	tag, err := tags.NormalizeName(tag)
+	if err != nil {
+		return Feed{}, err
+	}
+	response, err := s.blogsService.FindTagBlogs(tag, anonymous(), pagination.Params{Page: 1, Limit: s.itemsLimit})
+	if err != nil {
+		return Feed{}, err
+	}
+	return Feed{
+		Title:       "#" + tag + " on " + siteName,
+		Description: "The latest blogs tagged " + tag,
+		Link:        s.publicWebURL + "/tags/" + url.PathEscape(tag),
+		Blogs:       response.Data.([]blogs.BlogWithMeta),
+	}, nil
+}
+
+// Render encodes a feed in the given format and returns it with its content
+// type. selfPath is the path the feed is served at.
+func (s *FeedsService) Render(feed Feed, format, selfPath string) ([]byte, string, error) {
+	selfURL := s.baseURL + selfPath
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	switch format {
+	case FormatRSS:
+		var lengths map[string]int64
+		if lengths, err = s.audioLengths(feed); err != nil {
+			s.logger.Printf("Error fetching audio lengths: %v", err)
+			return nil, "", &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to render feed"}
+		}
+		body, err = xml.MarshalIndent(s.rss(feed, selfURL, lengths), "", "  ")
+		body = append([]byte(xml.Header), body...)
+		contentType = "application/rss+xml; charset=utf-8"
+	case FormatAtom:
+		body, err = xml.MarshalIndent(s.atom(feed, selfURL), "", "  ")
+		body = append([]byte(xml.Header), body...)
+		contentType = "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		body, err = json.MarshalIndent(s.jsonFeed(feed, selfURL), "", "  ")
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		return nil, "", &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Unknown feed format %s", format)}
+	}
+	if err != nil {
+		s.logger.Printf("Error encoding %s feed: %v", format, err)
+		return nil, "", &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to render feed"}
+	}
+	return body, contentType, nil
+}
+
+// rss builds an RSS document. lengths holds the known audio sizes by blog ID.
+func (s *FeedsService) rss(feed Feed, selfURL string, lengths map[string]int64) rssFeed {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		SelfLink:    atomLink{Href: selfURL, Rel: "self", Type: "application/rss+xml"},
+		Items:       []rssItem{},
+	}
+	if updated := feed.Updated(); !updated.IsZero() {
+		channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, entry := range feed.Blogs {
+		blog := entry.Blog
+		item := rssItem{
+			Title:       blog.Title,
+			Link:        s.blogLink(blog),
+			GUID:        rssGUID{IsPermaLink: false, Value: blog.BlogId},
+			PubDate:     published(blog).UTC().Format(time.RFC1123Z),
+			Creator:     blog.User.FullName,
+			Categories:  blog.Tags,
+			Description: summary(blog.Text),
+			Content:     &cdata{Value: blog.Html},
+		}
+		// RSS allows one enclosure per item and requires its size, which is
+		// only known for audio published as a podcast episode
+		if length := lengths[blog.BlogId]; length > 0 && blog.Audio != "" {
+			item.Enclosure = &rssEnclosure{URL: blog.Audio, Length: length, Type: MediaType(blog.Audio, "audio/mpeg")}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	return rssFeed{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		DublinNS:  "http://purl.org/dc/elements/1.1/",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	}
+}
+
+func (s *FeedsService) atom(feed Feed, selfURL string) atomFeed {
+	updated := feed.Updated()
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	document := atomFeed{
+		ID:      selfURL,
+		Title:   feed.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+		},
+		Entries: []atomEntry{},
+	}
+
+	for _, entry := range feed.Blogs {
+		blog := entry.Blog
+		link := s.blogLink(blog)
+		atomEntry := atomEntry{
+			ID:        "urn:phinex:blog:" + blog.BlogId,
+			Title:     blog.Title,
+			Updated:   blog.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: published(blog).UTC().Format(time.RFC3339),
+			Author:    atomAuthor{Name: blog.User.FullName},
+			Links:     []atomLink{{Href: link, Rel: "alternate", Type: "text/html"}},
+			Content:   atomContent{Type: "html", Value: blog.Html},
+		}
+		for _, media := range enclosures(blog) {
+			atomEntry.Links = append(atomEntry.Links, atomLink{Href: media.URL, Rel: "enclosure", Type: media.MimeType})
+		}
+		for _, tag := range blog.Tags {
+			atomEntry.Categories = append(atomEntry.Categories, atomCategory{Term: tag})
+		}
+		document.Entries = append(document.Entries, atomEntry)
+	}
+	return document
+}
+
+func (s *FeedsService) jsonFeed(feed Feed, selfURL string) jsonFeed {
+	document := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     selfURL,
+		Description: feed.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, entry := range feed.Blogs {
+		blog := entry.Blog
+		link := s.blogLink(blog)
+		document.Items = append(document.Items, jsonFeedItem{
+			ID:            blog.BlogId,
+			URL:           link,
+			Title:         blog.Title,
+			ContentHTML:   blog.Html,
+			ContentText:   blog.Text,
+			DatePublished: published(blog).UTC().Format(time.RFC3339),
+			DateModified:  blog.UpdatedAt.UTC().Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: blog.User.FullName, Avatar: blog.User.ProfileImage}},
+			Tags:          blog.Tags,
+			Attachments:   enclosures(blog),
+		})
+	}
+	return document
+}
+
+// audioLengths returns the stored audio sizes of the feed's blogs that are
+// podcast episodes, by blog ID
+func (s *FeedsService) audioLengths(feed Feed) (map[string]int64, error) {
+	lengths := map[string]int64{}
+	var blogIds []string
+	for _, entry := range feed.Blogs {
+		if entry.Blog.Audio != "" {
+			blogIds = append(blogIds, entry.Blog.BlogId)
+		}
+	}
+	if len(blogIds) == 0 {
+		return lengths, nil
+	}
+
+	var episodes []models.ShowEpisode
+	err := s.db.Select("blog_id", "audio_length").Where("blog_id IN ? AND audio_length > 0", blogIds).Find(&episodes).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, episode := range episodes {
+		lengths[episode.BlogId] = episode.AudioLength
+	}
+	return lengths, nil
+}
+
+// blogLink is the public address of a blog
+func (s *FeedsService) blogLink(blog models.Blog) string {
+	if blog.Url != "" {
+		return blog.Url
+	}
+	return s.publicWebURL + "/blogs/" + blog.BlogId
+}
+
+// enclosures lists a blog's video and audio with their media types
+func enclosures(blog models.Blog) []jsonFeedAttachment {
+	var media []jsonFeedAttachment
+	if blog.Video != "" {
+		media = append(media, jsonFeedAttachment{URL: blog.Video, MimeType: MediaType(blog.Video, "video/mp4")})
+	}
+	if blog.Audio != "" {
+		media = append(media, jsonFeedAttachment{URL: blog.Audio, MimeType: MediaType(blog.Audio, "audio/mpeg")})
+	}
+	return media
+}
+
+// MediaType guesses a media URL's type from its extension
+func MediaType(mediaURL, fallback string) string {
+	parsed, err := url.Parse(mediaURL)
+	if err != nil {
+		return fallback
+	}
+	if mediaType := mime.TypeByExtension(strings.ToLower(path.Ext(parsed.Path))); mediaType != "" {
+		return strings.Split(mediaType, ";")[0]
+	}
+	return fallback
+}
+
+// published is when a blog was published, or created for blogs published
+// before publishing was tracked
+func published(blog models.Blog) time.Time {
+	if blog.PublishedAt != nil {
+		return *blog.PublishedAt
+	}
+	return blog.CreatedAt
+}
+
+// summary shortens text to a plain excerpt
+func summary(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= summaryLength {
+		return text
+	}
+	return string([]rune(text)[:summaryLength]) + "…"
+}
+
+// anonymous is the user feeds are built for, so they only show public blogs
+func anonymous() models.ICurrentUser {
+	return models.ICurrentUser{Roles: []string{string(models.RoleNameAnonymous)}}
+}
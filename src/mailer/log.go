@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes emails to a logger instead of sending them, for local development
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer creates a new LogMailer
+func NewLogMailer(logger *log.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send logs the message
+func (m *LogMailer) Send(message Message) error {
+	m.logger.Printf("To: %s | Subject: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// FileMailer appends emails to a file instead of sending them, so tests can read them back
+type FileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileMailer creates a new FileMailer
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send appends the message to the file
+func (m *FileMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	return err
+}
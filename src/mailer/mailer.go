@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/epsierra/phinex-blog-api/src/utils"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailerFromEnv builds the mailer selected by MAILER_DRIVER.
+//
+//	MAILER_DRIVER smtp, file or log (default in development and test)
+//	MAIL_FROM     sender address used by the smtp driver
+//	SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD
+//	MAILER_FILE   file the file driver appends messages to
+//
+// Emails carry live verification and password reset tokens, so outside
+// development and test the driver must be chosen explicitly.
+func NewMailerFromEnv() (Mailer, error) {
+	switch driver := strings.ToLower(os.Getenv("MAILER_DRIVER")); driver {
+	case "":
+		if !utils.IsDevelopmentEnv() {
+			return nil, fmt.Errorf("MAILER_DRIVER environment variable not set")
+		}
+		return NewLogMailer(log.New(os.Stderr, "mailer: ", log.LstdFlags)), nil
+	case "log":
+		return NewLogMailer(log.New(os.Stderr, "mailer: ", log.LstdFlags)), nil
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("MAILER_FILE environment variable not set")
+		}
+		return NewFileMailer(path), nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		from := os.Getenv("MAIL_FROM")
+		if host == "" || from == "" {
+			return nil, fmt.Errorf("SMTP_HOST and MAIL_FROM environment variables must be set")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	default:
+		return nil, fmt.Errorf("unsupported MAILER_DRIVER %q", driver)
+	}
+}
@@ -0,0 +1,47 @@
+package markup
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inlineElements do not separate the words around them
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Strong: true, atom.Em: true, atom.Del: true, atom.Code: true,
+}
+
+// Excerpt returns the text of rendered HTML as a single line of at most
+// maxLength characters, cut at a word boundary and ending in an ellipsis when
+// shortened
+func Excerpt(fragment string, maxLength int) string {
+	var text strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return shorten(strings.Join(strings.Fields(text.String()), " "), maxLength)
+		case html.TextToken:
+			text.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			// Block boundaries and line breaks separate words
+			name, _ := tokenizer.TagName()
+			if !inlineElements[atom.Lookup(name)] {
+				text.WriteByte(' ')
+			}
+		}
+	}
+}
+
+func shorten(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	runes := []rune(text)[:maxLength]
+	if space := strings.LastIndex(string(runes), " "); space > 0 {
+		return strings.TrimRight(string(runes)[:space], " ,;:.-") + "…"
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,380 @@
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingLine     = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleLine        = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceLine       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([A-Za-z0-9_+-]*)")
+	bulletItem      = regexp.MustCompile(`^ {0,3}([-*+])[ \t]+(.*)$`)
+	orderedItem     = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)][ \t]+(.*)$`)
+	quoteLine       = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	autolinkPattern = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]+)>`)
+)
+
+// renderMarkdown converts a CommonMark-style subset to HTML: headings,
+// paragraphs, emphasis, strikethrough, code, block quotes, lists, rules, links
+// and images. Raw HTML in the source is escaped rather than passed through.
+func renderMarkdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+	var out strings.Builder
+	renderBlocks(&out, strings.Split(source, "\n"))
+	return out.String()
+}
+
+// renderBlocks renders a sequence of lines as block elements
+func renderBlocks(out *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fenceLine.MatchString(line):
+			match := fenceLine.FindStringSubmatch(line)
+			fence, language := match[1], match[2]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++ // closing fence
+			out.WriteString("<pre><code")
+			if language != "" {
+				out.WriteString(` class="language-` + html.EscapeString(strings.ToLower(language)) + `"`)
+			}
+			out.WriteString(">")
+			for _, codeLine := range code {
+				out.WriteString(html.EscapeString(codeLine) + "\n")
+			}
+			out.WriteString("</code></pre>\n")
+
+		case headingLine.MatchString(strings.TrimLeft(line, " ")) && len(line)-len(strings.TrimLeft(line, " ")) < 4:
+			match := headingLine.FindStringSubmatch(strings.TrimLeft(line, " "))
+			level := strconv.Itoa(len(match[1]))
+			out.WriteString("<h" + level + ">" + renderInline(strings.TrimSpace(match[2])) + "</h" + level + ">\n")
+			i++
+
+		case ruleLine.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case quoteLine.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quoteLine.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteLine.FindStringSubmatch(lines[i])[1])
+			}
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+
+		case bulletItem.MatchString(line) || orderedItem.MatchString(line):
+			i = renderList(out, lines, i)
+
+		default:
+			var paragraph []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && (len(paragraph) == 0 || !startsBlock(lines[i])); i++ {
+				paragraph = append(paragraph, lines[i])
+			}
+			out.WriteString("<p>" + renderParagraph(paragraph) + "</p>\n")
+		}
+	}
+}
+
+// startsBlock reports whether a line interrupts a paragraph
+func startsBlock(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return fenceLine.MatchString(line) || ruleLine.MatchString(line) || quoteLine.MatchString(line) ||
+		bulletItem.MatchString(line) || orderedItem.MatchString(line) ||
+		(headingLine.MatchString(trimmed) && len(line)-len(trimmed) < 4)
+}
+
+// renderList renders the list starting at lines[start] and returns the index
+// of the first line after it. Lines indented under an item belong to it, so
+// lists nest by indentation.
+func renderList(out *strings.Builder, lines []string, start int) int {
+	ordered := orderedItem.MatchString(lines[start])
+	itemPattern := bulletItem
+	tag := "ul"
+	if ordered {
+		itemPattern = orderedItem
+		tag = "ol"
+	}
+
+	out.WriteString("<" + tag)
+	if ordered {
+		if number, _ := strconv.Atoi(orderedItem.FindStringSubmatch(lines[start])[1]); number != 1 {
+			out.WriteString(` start="` + strconv.Itoa(number) + `"`)
+		}
+	}
+	out.WriteString(">\n")
+
+	i := start
+	for i < len(lines) && itemPattern.MatchString(lines[i]) {
+		item := []string{itemPattern.FindStringSubmatch(lines[i])[2]}
+		loose := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item only if indented content follows
+				if i+1 < len(lines) && indentation(lines[i+1]) >= 2 {
+					item = append(item, "")
+					loose = true
+					continue
+				}
+				break
+			}
+			if indentation(line) >= 2 {
+				item = append(item, strings.TrimPrefix(line, strings.Repeat(" ", min(indentation(line), 4))))
+				continue
+			}
+			if itemPattern.MatchString(line) || startsBlock(line) {
+				break
+			}
+			item = append(item, line) // lazy continuation of the item's paragraph
+		}
+
+		var content strings.Builder
+		renderBlocks(&content, item)
+		rendered := strings.TrimSuffix(content.String(), "\n")
+		if !loose {
+			// Tight lists keep their text out of paragraphs
+			rendered = strings.ReplaceAll(strings.ReplaceAll(rendered, "<p>", ""), "</p>", "")
+		}
+		out.WriteString("<li>" + rendered + "</li>\n")
+
+		// Skip a blank line between items
+		if i+1 < len(lines) && strings.TrimSpace(lines[i]) == "" && itemPattern.MatchString(lines[i+1]) {
+			i++
+		}
+	}
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// renderParagraph joins a paragraph's lines, turning a trailing backslash or
+// two trailing spaces into a line break
+func renderParagraph(lines []string) string {
+	var out strings.Builder
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		lineBreak := false
+		if strings.HasSuffix(line, "  ") {
+			line, lineBreak = strings.TrimRight(line, " "), true
+		} else if strings.HasSuffix(line, "\\") {
+			line, lineBreak = strings.TrimSuffix(line, "\\"), true
+		}
+		out.WriteString(renderInline(line))
+		if i < len(lines)-1 {
+			if lineBreak {
+				out.WriteString("<br>")
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+// renderInline renders code spans, links, images, autolinks, emphasis and
+// strikethrough, escaping everything else
+func renderInline(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && isPunctuation(text[i+1]):
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			run := runLength(text, i, '`')
+			if end := strings.Index(text[i+run:], strings.Repeat("`", run)); end >= 0 {
+				code := text[i+run : i+run+end]
+				out.WriteString("<code>" + html.EscapeString(strings.TrimSpace(code)) + "</code>")
+				i += run + end + run
+				continue
+			}
+			out.WriteString(text[i : i+run])
+			i += run
+			continue
+
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, destination, title, length, ok := parseLink(text[i+1:]); ok {
+				out.WriteString(`<img src="` + html.EscapeString(destination) + `" alt="` + html.EscapeString(plainText(label)) + `"`)
+				if title != "" {
+					out.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				out.WriteString(">")
+				i += 1 + length
+				continue
+			}
+
+		case c == '[':
+			if label, destination, title, length, ok := parseLink(text[i:]); ok {
+				out.WriteString(`<a href="` + html.EscapeString(destination) + `"`)
+				if title != "" {
+					out.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				out.WriteString(">" + renderInline(label) + "</a>")
+				i += length
+				continue
+			}
+
+		case c == '<':
+			if match := autolinkPattern.FindStringSubmatch(text[i:]); match != nil {
+				link := html.EscapeString(match[1])
+				out.WriteString(`<a href="` + link + `">` + html.EscapeString(strings.TrimPrefix(match[1], "mailto:")) + "</a>")
+				i += len(match[0])
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if rendered, length, ok := renderEmphasis(text, i); ok {
+				out.WriteString(rendered)
+				i += length
+				continue
+			}
+		}
+		out.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return out.String()
+}
+
+// renderEmphasis renders the emphasis opening at text[start]: one * or _ for
+// <em>, two for <strong> and two ~ for <del>
+func renderEmphasis(text string, start int) (string, int, bool) {
+	c := text[start]
+	run := runLength(text, start, c)
+	// Intraword underscores, as in snake_case, are not emphasis
+	if c == '_' && start > 0 && isWordByte(text[start-1]) {
+		return "", 0, false
+	}
+
+	var size int
+	var tag string
+	switch {
+	case c == '~' && run >= 2:
+		size, tag = 2, "del"
+	case c == '~':
+		return "", 0, false
+	case run >= 2:
+		size, tag = 2, "strong"
+	default:
+		size, tag = 1, "em"
+	}
+
+	delimiter := strings.Repeat(string(c), size)
+	contentStart := start + size
+	if contentStart >= len(text) || text[contentStart] == ' ' {
+		return "", 0, false
+	}
+	for searchFrom := contentStart + 1; searchFrom <= len(text); {
+		end := strings.Index(text[searchFrom:], delimiter)
+		if end < 0 {
+			return "", 0, false
+		}
+		end += searchFrom
+		closes := text[end-1] != ' ' && text[end-1] != '\\'
+		if c == '_' && end+size < len(text) && isWordByte(text[end+size]) {
+			closes = false
+		}
+		// A single delimiter must not be half of a double one
+		if size == 1 && end+1 < len(text) && text[end+1] == c {
+			closes = false
+			end++
+		}
+		if closes {
+			return "<" + tag + ">" + renderInline(text[contentStart:end]) + "</" + tag + ">", end + size - start, true
+		}
+		searchFrom = end + 1
+	}
+	return "", 0, false
+}
+
+// parseLink parses "[label](destination "title")" at the start of text and
+// returns its parts and length
+func parseLink(text string) (label, destination, title string, length int, ok bool) {
+	depth := 0
+	labelEnd := -1
+	for i := 0; i < len(text) && labelEnd < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				labelEnd = i
+			}
+		}
+	}
+	if labelEnd < 0 || labelEnd+1 >= len(text) || text[labelEnd+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	rest := text[labelEnd+2:]
+	depth = 0
+	end := -1
+	for i := 0; i < len(rest) && end < 0; i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				end = i
+			}
+			depth--
+		}
+	}
+	if end < 0 {
+		return "", "", "", 0, false
+	}
+
+	target := strings.TrimSpace(rest[:end])
+	if space := strings.IndexAny(target, " \t"); space >= 0 {
+		quoted := strings.TrimSpace(target[space:])
+		if len(quoted) < 2 || !(quoted[0] == '"' && quoted[len(quoted)-1] == '"' || quoted[0] == '\'' && quoted[len(quoted)-1] == '\'') {
+			return "", "", "", 0, false
+		}
+		target, title = target[:space], quoted[1:len(quoted)-1]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return text[1:labelEnd], target, title, labelEnd + 2 + end + 1, true
+}
+
+// plainText strips inline markup from an image description
+func plainText(text string) string {
+	return html.UnescapeString(stripTags.ReplaceAllString(renderInline(text), ""))
+}
+
+var stripTags = regexp.MustCompile(`<[^>]*>`)
+
+func runLength(text string, start int, c byte) int {
+	n := 0
+	for start+n < len(text) && text[start+n] == c {
+		n++
+	}
+	return n
+}
+
+func isPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,62 @@
+// Package markup renders the text of blogs and comments to sanitised HTML.
+package markup
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+)
+
+// Rendered is text rendered to HTML, with the images it embeds
+type Rendered struct {
+	HTML   string
+	Images []string
+}
+
+// ParseFormat validates a requested text format, defaulting to plain text
+func ParseFormat(format models.TextFormat) (models.TextFormat, error) {
+	switch models.TextFormat(strings.ToLower(strings.TrimSpace(string(format)))) {
+	case "", models.TextFormatPlain:
+		return models.TextFormatPlain, nil
+	case models.TextFormatMarkdown:
+		return models.TextFormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("format must be %s or %s", models.TextFormatPlain, models.TextFormatMarkdown)
+	}
+}
+
+// Render converts text in the given format to sanitised HTML. Plain text is
+// escaped and split into paragraphs and line breaks.
+func Render(format models.TextFormat, text string) (Rendered, error) {
+	var unsafe string
+	switch format {
+	case models.TextFormatMarkdown:
+		unsafe = renderMarkdown(text)
+	case models.TextFormatPlain, "":
+		unsafe = renderPlain(text)
+	default:
+		return Rendered{}, fmt.Errorf("unknown text format %q", format)
+	}
+
+	clean, images, err := sanitize(unsafe)
+	if err != nil {
+		return Rendered{}, err
+	}
+	return Rendered{HTML: clean, Images: images}, nil
+}
+
+// renderPlain turns blank-line separated blocks into paragraphs and other
+// newlines into line breaks
+func renderPlain(text string) string {
+	var out strings.Builder
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		block = strings.Trim(block, "\n")
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		out.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(block), "\n", "<br>\n") + "</p>\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,162 @@
+package markup
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedAttributes lists the elements kept in rendered HTML and the
+// attributes each may carry. Other elements are unwrapped, keeping their text.
+var allowedAttributes = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.Em: nil, atom.Del: nil,
+	atom.Code: {"class"}, atom.Pre: nil, atom.Blockquote: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+	atom.A:   {"href", "title"},
+	atom.Img: {"src", "alt", "title"},
+}
+
+// droppedElements are removed together with their content
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Template: true, atom.Noscript: true, atom.Textarea: true, atom.Select: true, atom.Svg: true, atom.Math: true,
+}
+
+var codeLanguage = regexp.MustCompile(`^language-[a-z0-9_+-]+$`)
+
+// sanitize parses an HTML fragment and re-serialises only allowed elements
+// and attributes. Links may only point to http(s), mailto or relative URLs and
+// open external sites without passing on the referrer or page access; images
+// must be http(s). It returns the clean HTML and the image sources it kept.
+func sanitize(fragment string) (string, []string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s := &sanitizer{}
+	for _, node := range nodes {
+		s.write(node)
+	}
+	return s.out.String(), s.images, nil
+}
+
+type sanitizer struct {
+	out    strings.Builder
+	images []string
+}
+
+func (s *sanitizer) write(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		s.out.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		// Comments and doctypes are dropped
+		return
+	}
+
+	if droppedElements[node.DataAtom] {
+		return
+	}
+	names, allowed := allowedAttributes[node.DataAtom]
+	if !allowed {
+		s.writeChildren(node)
+		return
+	}
+
+	attributes := map[string]string{}
+	for _, attribute := range node.Attr {
+		if attribute.Namespace == "" && slices.Contains(names, attribute.Key) {
+			attributes[attribute.Key] = attribute.Val
+		}
+	}
+
+	var extra []html.Attribute
+	switch node.DataAtom {
+	case atom.A:
+		href, ok := safeURL(attributes["href"], true)
+		if !ok {
+			s.writeChildren(node)
+			return
+		}
+		attributes["href"] = href
+		if isAbsolute(href) {
+			extra = append(extra,
+				html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"},
+				html.Attribute{Key: "target", Val: "_blank"})
+		}
+	case atom.Img:
+		src, ok := safeURL(attributes["src"], false)
+		if !ok {
+			return
+		}
+		attributes["src"] = src
+		s.images = append(s.images, src)
+	case atom.Code:
+		if !codeLanguage.MatchString(attributes["class"]) {
+			delete(attributes, "class")
+		}
+	case atom.Ol:
+		if start, err := strconv.Atoi(attributes["start"]); err != nil || start < 0 {
+			delete(attributes, "start")
+		}
+	}
+
+	s.out.WriteString("<" + node.Data)
+	for _, name := range names {
+		if value, ok := attributes[name]; ok {
+			s.out.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+		}
+	}
+	for _, attribute := range extra {
+		s.out.WriteString(" " + attribute.Key + `="` + attribute.Val + `"`)
+	}
+	s.out.WriteString(">")
+
+	if node.DataAtom == atom.Br || node.DataAtom == atom.Hr || node.DataAtom == atom.Img {
+		return
+	}
+	s.writeChildren(node)
+	s.out.WriteString("</" + node.Data + ">")
+}
+
+func (s *sanitizer) writeChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		s.write(child)
+	}
+}
+
+// safeURL cleans a link or image URL, rejecting scripting schemes. Relative
+// URLs are only allowed for links.
+func safeURL(raw string, relativeAllowed bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if raw == "" || err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.String(), parsed.Host != ""
+	case "mailto":
+		return parsed.String(), relativeAllowed
+	case "":
+		// Reject protocol-relative URLs, which leave the site like absolute ones
+		return parsed.String(), relativeAllowed && !strings.HasPrefix(raw, "//")
+	default:
+		return "", false
+	}
+}
+
+func isAbsolute(link string) bool {
+	return strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://")
+}
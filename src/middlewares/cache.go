@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+)
+
+// currentUsers caches users and their roles between requests
+var currentUsers = &userCache{entries: map[string]cachedUser{}}
+
+// cacheSweepInterval is how often set drops expired entries
+const cacheSweepInterval = time.Minute
+
+type cachedUser struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+// userCache is a small in-memory TTL cache keyed by user ID
+type userCache struct {
+	mu        sync.RWMutex
+	entries   map[string]cachedUser
+	lastSweep time.Time
+}
+
+func (c *userCache) get(userId string) (models.User, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[userId]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return models.User{}, false
+	}
+	return entry.user, true
+}
+
+func (c *userCache) set(user models.User, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Drop expired entries so the cache does not grow with every user ever
+	// seen. This walks the whole map, so it runs at most once per interval.
+	if now.Sub(c.lastSweep) >= cacheSweepInterval {
+		c.lastSweep = now
+		for userId, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, userId)
+			}
+		}
+	}
+	c.entries[user.UserId] = cachedUser{user: user, expiresAt: now.Add(ttl)}
+}
+
+// InvalidateUser drops a cached user. Call it after changing a user's roles,
+// status or profile so the next request sees the change.
+func InvalidateUser(userId string) {
+	currentUsers.mu.Lock()
+	defer currentUsers.mu.Unlock()
+	delete(currentUsers.entries, userId)
+}
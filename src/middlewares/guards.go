@@ -1,7 +1,10 @@
 package middlewares
 
 import (
+	"log"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/epsierra/phinex-blog-api/src/models" // Adjust to your project structure
 	"github.com/epsierra/phinex-blog-api/src/utils"
@@ -9,547 +12,290 @@ import (
 	"gorm.io/gorm"
 )
 
-// Helper function to check if a URL matches any of the provided substrings
-func contains(url string, substrings []string) bool {
-	for _, substring := range substrings {
-		if strings.Contains(url, substring) {
-			return true
-		}
-	}
-	return false
-}
-
-func AdminGuard(db *gorm.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-
-		// Extract token from Authorization header
-		authorization := c.Get("Authorization")
-		if authorization == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "No token provided",
-			})
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
-		}
-		tokenStr := parts[1]
-
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
-
-		userId, ok := decodedData["userId"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
-		}
-
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
-		}
-
-		// Check if user has Admin or SuperAdmin role
-		isAdminOrHigher := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			if roleName == string(models.RoleNameAdmin) || roleName == string(models.RoleNameSuperAdmin) {
-				isAdminOrHigher = true
-			}
-		}
+var logger = log.New(os.Stderr, "auth-middleware: ", log.LstdFlags)
 
-		if !isAdminOrHigher {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: Admins or super admins only",
-			})
-		}
+// authErrorKey holds why a request's credentials were not accepted, so the
+// Require* guards can explain the refusal
+const authErrorKey = "authError"
 
-		// Set current user in context
-		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
-			IsAuthenticated: true,
-			IP:              c.IP(),
-		}
-		c.Locals("user", currentUser)
+// activeSessionId returns the session ID of a decoded access token if that
+// session has not been logged out, revoked or expired.
+func activeSessionId(db *gorm.DB, decodedData map[string]interface{}) (string, bool) {
+	if tokenType, _ := decodedData["typ"].(string); tokenType != utils.TokenTypeAccess {
+		return "", false
+	}
+	sessionId, ok := decodedData["sid"].(string)
+	if !ok || sessionId == "" {
+		return "", false
+	}
 
-		return c.Next()
+	var count int64
+	err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionId, time.Now().UTC()).
+		Count(&count).Error
+	if err != nil || count == 0 {
+		return "", false
 	}
+	return sessionId, true
 }
 
-func AnonymousGuard(db *gorm.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Skip authentication for specific routes
-		url := c.OriginalURL()
-		if contains(url, []string{"metrics", "bloggers", "sse", "webhook", "swagger-docs"}) {
-			return c.Next()
-		}
-
-		// Extract token from Authorization header
-		authorization := c.Get("Authorization")
-		if authorization == "" {
-			// Allow anonymous access
-			currentUser := models.ICurrentUser{
-				Roles:           []string{string(models.RoleNameAnonymous)},
-				IsAuthenticated: false,
-				IP:              c.IP(),
+// suspendedAllowedPaths are the write endpoints suspended users may still call
+var suspendedAllowedPaths = []string{"/auth/logout"}
+
+// statusDenial returns the status code and message to refuse a request with
+// because of the user's status, or 0 when the request may proceed. Banned users
+// are refused outright; suspended users may only read until the suspension ends.
+func statusDenial(c *fiber.Ctx, user models.User) (int, string) {
+	switch user.EffectiveStatus() {
+	case models.UserStatusBanned:
+		return fiber.StatusForbidden, "Account is banned"
+	case models.UserStatusSuspended:
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return 0, ""
+		}
+		for _, path := range suspendedAllowedPaths {
+			if strings.HasPrefix(c.Path(), path) {
+				return 0, ""
 			}
-			c.Locals("user", currentUser)
-			return c.Next()
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
 		}
-		tokenStr := parts[1]
-
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
-
-		userId, ok := decodedData["userId"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
-		}
-
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
-		}
-
-		// Check if user has any valid role
-		validRoles := []string{
-			string(models.RoleNameAdmin),
-			string(models.RoleNameSuperAdmin),
-			string(models.RoleNameBusinessOwner),
-			string(models.RoleNamePaymentAgent),
-			string(models.RoleNameAuthenticated),
-			string(models.RoleNameAnonymous),
-		}
-		isValidRole := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			for _, validRole := range validRoles {
-				if roleName == validRole {
-					isValidRole = true
-					break
-				}
-			}
+		if user.SuspendedUntil != nil {
+			return fiber.StatusForbidden, "Account is suspended until " + user.SuspendedUntil.UTC().Format(time.RFC3339)
 		}
+		return fiber.StatusForbidden, "Account is suspended"
+	}
+	return 0, ""
+}
 
-		if !isValidRole {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: No valid role assigned",
-			})
-		}
+// Authenticate resolves the current user once per request and stores it in
+// c.Locals("user"). Users sign in with a Bearer JWT, or with an API key sent as
+// "Authorization: ApiKey <key>" or "X-API-Key: <key>"; sending both a Bearer
+// token and an API key is refused. Requests without credentials, or with ones
+// that cannot be accepted, continue as anonymous, as do API keys without the
+// scope the route needs; routes that need a user are protected with
+// RequireAuthenticated or RequireRoles, which report why the credentials were
+// refused.
+// Users and their roles are cached for AUTH_USER_CACHE_TTL (default 30s).
+func Authenticate(db *gorm.DB) fiber.Handler {
+	ttl := utils.GetEnvDuration("AUTH_USER_CACHE_TTL", 30*time.Second)
 
-		// Set current user in context
+	return func(c *fiber.Ctx) error {
 		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
+			Roles:           []string{string(models.RoleNameAnonymous)},
 			IsAuthenticated: false,
 			IP:              c.IP(),
 		}
-		c.Locals("user", currentUser)
-
-		return c.Next()
-	}
-}
-
-func AuthenticatedGuard(db *gorm.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Skip authentication for specific routes
-		// url := c.OriginalURL()
-		// if contains(url, []string{"metrics", "bloggers", "sse", "webhook", "swagger-docs"}) {
-		// 	return c.Next()
-		// }
 
-		// Extract token from Authorization header
 		authorization := c.Get("Authorization")
-		if authorization == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "No token provided",
-			})
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
-		}
-		tokenStr := parts[1]
-
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
-
-		userId, ok := decodedData["userId"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
-		}
-
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
+		apiKey := c.Get("X-API-Key")
+		if strings.HasPrefix(authorization, "ApiKey ") {
+			apiKey = strings.TrimPrefix(authorization, "ApiKey ")
+		} else if apiKey != "" && authorization != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Send either a Bearer token or an API key, not both",
+			})
+		}
+
+		if apiKey != "" || authorization != "" {
+			var (
+				user      models.User
+				sessionId string
+				key       models.ApiKey
+				err       *fiber.Error
+			)
+			if apiKey != "" {
+				user, key, err = resolveApiKey(db, apiKey, ttl)
+			} else {
+				user, sessionId, err = resolveBearerToken(db, authorization, ttl)
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
-		}
-
-		// Check if user is authenticated
-		validRoles := []string{
-			string(models.RoleNameAdmin),
-			string(models.RoleNameSuperAdmin),
-			string(models.RoleNameBusinessOwner),
-			string(models.RoleNamePaymentAgent),
-			string(models.RoleNameAuthenticated),
-		}
-		isAuthenticated := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			for _, validRole := range validRoles {
-				if roleName == validRole {
-					isAuthenticated = true
-					break
+			if err != nil {
+				c.Locals(authErrorKey, err)
+			} else if scopeErr := scopeDenial(c, key); scopeErr != nil {
+				c.Locals(authErrorKey, scopeErr)
+			} else if code, message := statusDenial(c, user); code != 0 {
+				// Refuse banned users; suspended users are read-only. Either way
+				// the request goes on as anonymous, so public routes do not treat
+				// the account as signed in.
+				c.Locals(authErrorKey, &fiber.Error{Code: code, Message: message})
+			} else {
+				roles := make([]string, 0, len(user.UserRoles))
+				for _, ur := range user.UserRoles {
+					roles = append(roles, string(ur.Role.RoleName))
+				}
+				currentUser = models.ICurrentUser{
+					UserId:          user.UserId,
+					Email:           user.Email,
+					FullName:        user.FullName,
+					Roles:           roles,
+					IsAuthenticated: true,
+					IP:              c.IP(),
+					Status:          user.EffectiveStatus(),
+					SessionId:       sessionId,
+				}
+				if key.ApiKeyId != "" {
+					currentUser.ApiKeyId = key.ApiKeyId
+					currentUser.Scopes = key.ScopeList()
 				}
 			}
 		}
 
-		if !isAuthenticated {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: Authenticated users only",
-			})
-		}
-
-		// Set current user in context
-		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
-			IsAuthenticated: isAuthenticated,
-			IP:              c.IP(),
-		}
 		c.Locals("user", currentUser)
-
 		return c.Next()
 	}
 }
 
-func BusinessOwnerGuard(db *gorm.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Skip authentication for specific routes
-		// url := c.OriginalURL()
-		// if contains(url, []string{"metrics", "bloggers", "sse", "webhook"}) {
-		// 	return c.Next()
-		// }
-
-		// Extract token from Authorization header
-		authorization := c.Get("Authorization")
-		if authorization == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "No token provided",
-			})
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
-		}
-		tokenStr := parts[1]
-
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
-
-		userId, ok := decodedData["userId"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
+// scopeDenial refuses an API key that lacks the scope the route requires. It
+// returns nil for requests signed in with a Bearer token.
+func scopeDenial(c *fiber.Ctx, key models.ApiKey) *fiber.Error {
+	if key.ApiKeyId == "" {
+		return nil
+	}
+	scope, ok := requiredScope(c.Method(), c.Path())
+	if !ok {
+		return &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: API keys cannot be used for this endpoint"}
+	}
+	for _, granted := range key.ScopeList() {
+		if granted == scope {
+			return nil
 		}
+	}
+	return &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: API key is missing the " + scope + " scope"}
+}
 
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
-		}
+// resolveBearerToken verifies an Authorization header and loads its user
+func resolveBearerToken(db *gorm.DB, authorization string, ttl time.Duration) (models.User, string, *fiber.Error) {
+	parts := strings.Split(authorization, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return models.User{}, "", &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid token format"}
+	}
 
-		// Check if user has BusinessOwner, Admin, or SuperAdmin role
-		isBusinessOwnerOrHigher := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			if roleName == string(models.RoleNameBusinessOwner) ||
-				roleName == string(models.RoleNameAdmin) ||
-				roleName == string(models.RoleNameSuperAdmin) {
-				isBusinessOwnerOrHigher = true
-			}
-		}
+	// Decode and verify JWT
+	decodedData, err := utils.JwtDecode(parts[1])
+	if err != nil {
+		return models.User{}, "", &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid or expired token"}
+	}
 
-		if !isBusinessOwnerOrHigher {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: Business owners, admins, or super admins only",
-			})
-		}
+	userId, ok := decodedData["userId"].(string)
+	if !ok {
+		return models.User{}, "", &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid user ID in token"}
+	}
 
-		// Set current user in context
-		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
-			IsAuthenticated: true,
-			IP:              c.IP(),
-		}
-		c.Locals("user", currentUser)
+	// Reject tokens whose session was logged out or revoked. This is not
+	// cached so that logging out takes effect immediately.
+	sessionId, ok := activeSessionId(db, decodedData)
+	if !ok {
+		return models.User{}, "", &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Token has been revoked"}
+	}
 
-		return c.Next()
+	user, fiberErr := loadUser(db, userId, ttl)
+	if fiberErr != nil {
+		return models.User{}, "", fiberErr
 	}
+	return user, sessionId, nil
 }
 
-func PaymentAgentGuard(db *gorm.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Skip authentication for specific routes
-		// url := c.OriginalURL()
-		// if contains(url, []string{"metrics", "bloggers", "sse", "webhook"}) {
-		// 	return c.Next()
-		// }
+// apiKeyTouchInterval limits how often an API key's last use is written
+const apiKeyTouchInterval = time.Minute
 
-		// Extract token from Authorization header
-		authorization := c.Get("Authorization")
-		if authorization == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "No token provided",
-			})
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
-		}
-		tokenStr := parts[1]
-
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
-
-		userId, ok := decodedData["userId"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
+// resolveApiKey looks up an API key by its hash and loads its user
+func resolveApiKey(db *gorm.DB, rawKey string, ttl time.Duration) (models.User, models.ApiKey, *fiber.Error) {
+	var key models.ApiKey
+	err := db.Where("key_hash = ?", utils.HashToken(rawKey)).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, models.ApiKey{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid or expired API key"}
 		}
+		return models.User{}, models.ApiKey{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Database error"}
+	}
 
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
+	now := time.Now().UTC()
+	if !key.IsActive(now) {
+		return models.User{}, models.ApiKey{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid or expired API key"}
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := db.Model(&models.ApiKey{}).Where("api_key_id = ?", key.ApiKeyId).UpdateColumn("last_used_at", now).Error; err != nil {
+			logger.Printf("Error recording use of API key %s: %v", key.ApiKeyId, err)
 		}
+	}
 
-		// Check if user has PaymentAgent, Admin, or SuperAdmin role
-		isPaymentAgentOrHigher := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			if roleName == string(models.RoleNamePaymentAgent) ||
-				roleName == string(models.RoleNameAdmin) ||
-				roleName == string(models.RoleNameSuperAdmin) {
-				isPaymentAgentOrHigher = true
-			}
-		}
+	user, fiberErr := loadUser(db, key.UserId, ttl)
+	if fiberErr != nil {
+		return models.User{}, models.ApiKey{}, fiberErr
+	}
+	return user, key, nil
+}
 
-		if !isPaymentAgentOrHigher {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: Payment agents, admins, or super admins only",
-			})
-		}
+// loadUser returns a user with roles, from the cache when possible
+func loadUser(db *gorm.DB, userId string, ttl time.Duration) (models.User, *fiber.Error) {
+	if user, ok := currentUsers.get(userId); ok {
+		return user, nil
+	}
 
-		// Set current user in context
-		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
-			IsAuthenticated: true,
-			IP:              c.IP(),
+	// Query user with roles
+	var user models.User
+	err := db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, &fiber.Error{Code: fiber.StatusUnauthorized, Message: "Invalid token"}
 		}
-		c.Locals("user", currentUser)
-
-		return c.Next()
+		return models.User{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Database error"}
 	}
+	currentUsers.set(user, ttl)
+	return user, nil
 }
 
-func SuperAdminGuard(db *gorm.DB) fiber.Handler {
+// RequireAuthenticated only lets signed-in users through. It must run after
+// Authenticate.
+func RequireAuthenticated() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Skip authentication for specific routes
-		// url := c.OriginalURL()
-		// if contains(url, []string{"metrics", "bloggers", "sse", "webhook"}) {
-		// 	return c.Next()
-		// }
-
-		// Extract token from Authorization header
-		authorization := c.Get("Authorization")
-		if authorization == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "No token provided",
-			})
-		}
-
-		parts := strings.Split(authorization, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid token format",
-			})
+		if _, ok := requireUser(c); !ok {
+			return nil
 		}
-		tokenStr := parts[1]
+		return c.Next()
+	}
+}
 
-		// Decode and verify JWT
-		decodedData, err := utils.JwtDecode(tokenStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid or expired token",
-			})
-		}
+// RequireRoles only lets signed-in users holding at least one of the roles
+// through. It must run after Authenticate.
+func RequireRoles(roles ...models.RoleName) fiber.Handler {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	message := "Forbidden: " + strings.Join(names, ", ") + " only"
 
-		userId, ok := decodedData["userId"].(string)
+	return func(c *fiber.Ctx) error {
+		currentUser, ok := requireUser(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Invalid user ID in token",
-			})
-		}
-
-		// Query user with roles
-		var user models.User
-		err = db.Preload("UserRoles.Role").Where("user_id = ?", userId).First(&user).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"message": "Invalid token",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Database error",
-			})
+			return nil
 		}
-
-		// Check if user has SuperAdmin role
-		isSuperAdmin := false
-		roles := make([]string, 0, len(user.UserRoles))
-		for _, ur := range user.UserRoles {
-			roleName := string(ur.Role.RoleName)
-			roles = append(roles, roleName)
-			if roleName == string(models.RoleNameSuperAdmin) {
-				isSuperAdmin = true
+		for _, role := range roles {
+			if currentUser.HasRole(role) {
+				return c.Next()
 			}
 		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": message,
+		})
+	}
+}
 
-		if !isSuperAdmin {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: Super admins only",
-			})
-		}
-
-		// Set current user in context
-		currentUser := models.ICurrentUser{
-			UserId:          user.UserId,
-			Email:           user.Email,
-			FullName:        user.FullName,
-			Roles:           roles,
-			IsAuthenticated: true,
-			IP:              c.IP(),
-		}
-		c.Locals("user", currentUser)
+// requireUser returns the authenticated user, or writes the refusal and
+// returns false.
+func requireUser(c *fiber.Ctx) (models.ICurrentUser, bool) {
+	if authErr, ok := c.Locals(authErrorKey).(*fiber.Error); ok {
+		c.Status(authErr.Code).JSON(fiber.Map{
+			"message": authErr.Message,
+		})
+		return models.ICurrentUser{}, false
+	}
 
-		return c.Next()
+	currentUser, ok := c.Locals("user").(models.ICurrentUser)
+	if !ok || !currentUser.IsAuthenticated {
+		c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "No token provided",
+		})
+		return models.ICurrentUser{}, false
 	}
+	return currentUser, true
 }
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	blogCommentsPath = regexp.MustCompile(`^/blogs/[^/]+/comments`)
+	userBlogsPath    = regexp.MustCompile(`^/users/[^/]+/blogs$`)
+)
+
+// requiredScope returns the API key scope a request needs: the resource the
+// path belongs to, with "read" for GET and HEAD and "write" otherwise. Paths
+// outside these resources, such as /auth and /admin, cannot be called with an
+// API key.
+func requiredScope(method, path string) (string, bool) {
+	var resource string
+	switch {
+	case strings.HasPrefix(path, "/comments"), blogCommentsPath.MatchString(path):
+		resource = "comments"
+	case strings.HasPrefix(path, "/blogs"),
+		strings.HasPrefix(path, "/following-blogs"),
+		strings.HasPrefix(path, "/pinned-blogs"),
+		strings.HasPrefix(path, "/tags"),
+		strings.HasPrefix(path, "/shows"),
+		userBlogsPath.MatchString(path):
+		resource = "blogs"
+	case strings.HasPrefix(path, "/users"):
+		resource = "users"
+	default:
+		return "", false
+	}
+
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead:
+		return resource + ":read", true
+	}
+	return resource + ":write", true
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// API key scopes. Each resource has a read scope for GET requests and a write
+// scope for everything else.
+const (
+	ScopeBlogsRead     = "blogs:read"
+	ScopeBlogsWrite    = "blogs:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+)
+
+// ApiKeyScopes lists every scope an API key can be granted
+var ApiKeyScopes = []string{
+	ScopeBlogsRead,
+	ScopeBlogsWrite,
+	ScopeCommentsRead,
+	ScopeCommentsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+}
+
+// ApiKey model. Keys act on behalf of their user for integrations and are
+// limited to their scopes. Only the SHA-256 hash of a key is stored; Prefix
+// keeps the first characters so users can tell their keys apart.
+type ApiKey struct {
+	ApiKeyId   string     `gorm:"primaryKey;type:varchar(25);column:api_key_id" json:"apiKeyId"`
+	UserId     string     `gorm:"type:varchar(25);not null;column:user_id" json:"userId"`
+	Name       string     `gorm:"type:varchar(80);not null;column:name" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null;column:prefix" json:"prefix"`
+	KeyHash    string     `gorm:"type:varchar(64);not null;unique;column:key_hash" json:"-"`
+	Scopes     string     `gorm:"type:text;not null;column:scopes" json:"-"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"not null;column:updated_at" json:"updatedAt"`
+	CreatedBy  string     `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy  string     `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the key's scopes
+func (k ApiKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// IsActive reports whether the key is neither revoked nor expired
+func (k ApiKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
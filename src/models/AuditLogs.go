@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog model. An append-only record of a security-relevant change, such as
+// a role grant or a user status change.
+type AuditLog struct {
+	AuditLogId string    `gorm:"primaryKey;type:varchar(25);column:audit_log_id" json:"auditLogId,omitempty"`
+	ActorId    string    `gorm:"type:varchar(25);column:actor_id" json:"actorId,omitempty"`
+	Action     string    `gorm:"type:varchar(100);not null;column:action" json:"action,omitempty"`
+	TargetType string    `gorm:"type:varchar(50);not null;column:target_type" json:"targetType,omitempty"`
+	TargetId   string    `gorm:"type:varchar(255);not null;column:target_id" json:"targetId,omitempty"`
+	Details    string    `gorm:"type:jsonb;column:details" json:"details,omitempty"`
+	IP         string    `gorm:"type:varchar(64);column:ip" json:"ip,omitempty"`
+	CreatedAt  time.Time `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy  string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy  string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BlogRevision is an immutable snapshot of a blog's content, saved on creation
+// and after every change. Revision numbers count up from 1 per blog, and
+// ChangedFields lists the JSON names of the fields that differ from the
+// previous revision.
+type BlogRevision struct {
+	BlogRevisionId    string          `gorm:"primaryKey;type:varchar(25);column:blog_revision_id" json:"blogRevisionId"`
+	BlogId            string          `gorm:"type:varchar(25);not null;uniqueIndex:idx_blog_revisions_blog_id_revision;column:blog_id" json:"blogId"`
+	Revision          int             `gorm:"not null;uniqueIndex:idx_blog_revisions_blog_id_revision;column:revision" json:"revision"`
+	AuthorId          string          `gorm:"type:varchar(25);not null;column:author_id" json:"authorId"`
+	Title             string          `gorm:"type:varchar(255);column:title" json:"title"`
+	ExternalLink      string          `gorm:"type:text;column:external_link" json:"externalLink"`
+	ExternalLinkTitle string          `gorm:"type:varchar(255);column:external_link_title" json:"externalLinkTitle"`
+	Text              string          `gorm:"type:text;column:text" json:"text"`
+	Format            TextFormat      `gorm:"type:varchar(20);default:'plain';column:format" json:"format"`
+	Html              string          `gorm:"type:text;column:html" json:"html"`
+	Images            json.RawMessage `gorm:"type:json;column:images" json:"images"`
+	Video             string          `gorm:"type:text;column:video" json:"video"`
+	Audio             string          `gorm:"type:text;column:audio" json:"audio"`
+	ChangedFields     json.RawMessage `gorm:"type:json;column:changed_fields" json:"changedFields"`
+	RestoredFrom      *int            `gorm:"column:restored_from" json:"restoredFrom,omitempty"`
+	CreatedAt         time.Time       `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt         time.Time       `gorm:"not null;column:updated_at" json:"updatedAt"`
+	CreatedBy         string          `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy         string          `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	Blog *Blog `gorm:"foreignKey:blog_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"blog,omitempty"`
+}
+
+func (BlogRevision) TableName() string {
+	return "blog_revisions"
+}
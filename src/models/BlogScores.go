@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// BlogScore is a published blog's ranking score: its weighted engagement
+// decayed by age. Scores are recomputed in the background and rank the For
+// You and trending feeds.
+type BlogScore struct {
+	BlogId     string    `gorm:"primaryKey;type:varchar(25);column:blog_id" json:"blogId"`
+	Score      float64   `gorm:"not null;index;column:score" json:"score"`
+	ComputedAt time.Time `gorm:"not null;column:computed_at" json:"computedAt"`
+
+	Blog *Blog `gorm:"foreignKey:blog_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"blog,omitempty"`
+}
+
+func (BlogScore) TableName() string {
+	return "blog_scores"
+}
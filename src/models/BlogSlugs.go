@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// BlogSlug records every slug a blog has had, so links using a slug from
+// before a title change keep resolving. A slug belongs to one blog forever.
+type BlogSlug struct {
+	BlogSlugId string    `gorm:"primaryKey;type:varchar(25);column:blog_slug_id" json:"blogSlugId,omitempty"`
+	BlogId     string    `gorm:"type:varchar(25);not null;index;column:blog_id" json:"blogId,omitempty"`
+	Slug       string    `gorm:"type:varchar(100);not null;unique;column:slug" json:"slug,omitempty"`
+	CreatedAt  time.Time `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy  string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy  string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	Blog *Blog `gorm:"foreignKey:blog_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"blog,omitempty"`
+}
+
+func (BlogSlug) TableName() string {
+	return "blog_slugs"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// BlogTag files a blog under a tag
+type BlogTag struct {
+	BlogTagId string    `gorm:"primaryKey;type:varchar(25);column:blog_tag_id" json:"blogTagId"`
+	BlogId    string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_blog_tags_blog_id_tag_id;column:blog_id" json:"blogId"`
+	TagId     string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_blog_tags_blog_id_tag_id;index;column:tag_id" json:"tagId"`
+	CreatedAt time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	CreatedBy string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	Blog *Blog `gorm:"foreignKey:blog_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"blog,omitempty"`
+	Tag  *Tag  `gorm:"foreignKey:tag_id;references:tag_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"tag,omitempty"`
+}
+
+func (BlogTag) TableName() string {
+	return "blog_tags"
+}
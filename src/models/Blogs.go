@@ -5,16 +5,37 @@ import (
 	"time"
 )
 
+// BlogStatus is where a blog is in its publishing lifecycle. Only published
+// blogs appear in feeds.
+type BlogStatus string
+
+const (
+	BlogStatusDraft     BlogStatus = "draft"
+	BlogStatusScheduled BlogStatus = "scheduled" // published by the scheduler at PublishAt
+	BlogStatusPublished BlogStatus = "published"
+	BlogStatusArchived  BlogStatus = "archived"
+)
+
+// TextFormat is how the text of a blog or comment is written
+type TextFormat string
+
+const (
+	TextFormatPlain    TextFormat = "plain"
+	TextFormatMarkdown TextFormat = "markdown"
+)
+
 // Blog model
 type Blog struct {
 	BlogId            string          `gorm:"primaryKey;type:varchar(25);column:blog_id" json:"blogId"`
 	UserId            string          `gorm:"type:varchar(25);not null;column:user_id" json:"userId"`
-	Slug              string          `gorm:"type:varchar(25);column:slug" json:"slug"`
+	Slug              string          `gorm:"type:varchar(100);column:slug" json:"slug"`
 	Title             string          `gorm:"type:varchar(255);column:title" json:"title"`
 	Url               string          `gorm:"type:text;column:url" json:"url"`
 	ExternalLink      string          `gorm:"type:text;column:external_link" json:"externalLink"`
 	ExternalLinkTitle string          `gorm:"type:varchar(255);column:external_link_title" json:"externalLinkTitle"`
 	Text              string          `gorm:"type:text;column:text" json:"text"`
+	Format            TextFormat      `gorm:"type:varchar(20);default:'plain';column:format" json:"format"`
+	Html              string          `gorm:"type:text;column:html" json:"html"`
 	Images            json.RawMessage `gorm:"type:json;column:images" json:"images"`
 	Video             string          `gorm:"type:text;column:video" json:"video"`
 	Audio             string          `gorm:"type:text;column:audio" json:"audio"`
@@ -26,7 +47,12 @@ type Blog struct {
 	LikesCount        int64           `gorm:"column:likes_count" json:"likesCount"`
 	SharesCount       int64           `gorm:"column:shares_count" json:"sharesCount"`
 	ViewsCount        int64           `gorm:"column:views_count" json:"viewsCount"`
+	DownloadsCount    int64           `gorm:"column:downloads_count" json:"downloadsCount"`
 	IsReel            bool            `gorm:"type:boolean;default:false;column:is_reel" json:"isReel"`
+	Status            BlogStatus      `gorm:"type:blog_status;default:'published';column:status" json:"status"`
+	PublishAt         *time.Time      `gorm:"column:publish_at" json:"publishAt,omitempty"`
+	PublishedAt       *time.Time      `gorm:"column:published_at" json:"publishedAt,omitempty"`
+	Tags              []string        `gorm:"-" json:"tags"`
 	User              User            `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
 	Comments          []Comment       `gorm:"foreignKey:ref_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"comments,omitempty"`
 	Likes             []Like          `gorm:"foreignKey:ref_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"likes,omitempty"`
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Download records one fetch of a blog's audio through a podcast feed. It is
+// counted like a View, with the blog's ID as RefId. Podcast apps fetch audio
+// without signing in, so UserId is usually nil.
+type Download struct {
+	DownloadId string    `gorm:"primaryKey;type:varchar(25);column:download_id" json:"downloadId"`
+	RefId      string    `gorm:"type:varchar(25);not null;index;column:ref_id" json:"refId"`
+	UserId     *string   `gorm:"type:varchar(25);column:user_id" json:"userId"`
+	CreatedAt  time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	CreatedBy  string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy  string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:SET NULL,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (Download) TableName() string {
+	return "downloads"
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// ExternalIdentity links a user to an account at an external OpenID Connect
+// provider. Subject is the provider's stable user ID ("sub" claim).
+type ExternalIdentity struct {
+	ExternalIdentityId string    `gorm:"primaryKey;type:varchar(25);column:external_identity_id" json:"externalIdentityId,omitempty"`
+	UserId             string    `gorm:"type:varchar(25);not null;column:user_id" json:"userId,omitempty"`
+	Issuer             string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identities_issuer_subject;column:issuer" json:"issuer,omitempty"`
+	Subject            string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identities_issuer_subject;column:subject" json:"subject,omitempty"`
+	Email              string    `gorm:"type:varchar(255);column:email" json:"email,omitempty"`
+	CreatedAt          time.Time `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt          time.Time `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy          string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy          string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (ExternalIdentity) TableName() string {
+	return "external_identities"
+}
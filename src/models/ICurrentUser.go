@@ -11,5 +11,33 @@ type ICurrentUser struct {
 	IsAuthenticated bool       `json:"isAuthenticated,omitempty"`
 	IP              string     `json:"ip,omitempty"`
 	Status          UserStatus `json:"status,omitempty"`
+	SessionId       string     `json:"sessionId,omitempty"`
+	ApiKeyId        string     `json:"apiKeyId,omitempty"` // Set when the request was made with an API key
+	Scopes          []string   `json:"scopes,omitempty"`   // Scopes of that API key
 	jwt.RegisteredClaims
 }
+
+// HasRole reports whether the current user holds the role
+func (u ICurrentUser) HasRole(role RoleName) bool {
+	for _, r := range u.Roles {
+		if r == string(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the current user is an Admin or SuperAdmin
+func (u ICurrentUser) IsAdmin() bool {
+	return u.HasRole(RoleNameAdmin) || u.HasRole(RoleNameSuperAdmin)
+}
+
+// HasScope reports whether an API key request was granted the scope
+func (u ICurrentUser) HasScope(scope string) bool {
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package models
 
-// PaginationMetadata defines the metadata for paginated responses.
+// PaginationMetadata defines the metadata for paginated responses. Lists
+// paged by cursor report currentPage as 0, and totalItems and totalPages are
+// 0 unless the total was counted.
 type PaginationMetadata struct {
 	CurrentPage     int64 `json:"currentPage"`
 	ItemsPerPage    int64 `json:"itemsPerPage"`
@@ -8,6 +10,8 @@ type PaginationMetadata struct {
 	TotalPages      int64 `json:"totalPages"`
 	HasNextPage     bool  `json:"hasNextPage"`
 	HasPreviousPage bool  `json:"hasPreviousPage"`
+	// NextCursor is passed as ?after= to fetch the next page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // PaginatedResponse defines the structure for paginated API responses.
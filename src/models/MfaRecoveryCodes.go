@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// MfaRecoveryCode model. A bcrypt-hashed single-use code that stands in for a
+// TOTP code when the authenticator is lost.
+type MfaRecoveryCode struct {
+	MfaRecoveryCodeId string     `gorm:"primaryKey;type:varchar(25);column:mfa_recovery_code_id" json:"mfaRecoveryCodeId,omitempty"`
+	UserId            string     `gorm:"type:varchar(25);not null;column:user_id" json:"userId,omitempty"`
+	CodeHash          string     `gorm:"type:varchar(255);not null;column:code_hash" json:"-"`
+	UsedAt            *time.Time `gorm:"column:used_at" json:"usedAt,omitempty"`
+	CreatedAt         time.Time  `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy         string     `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy         string     `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (MfaRecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// OidcLoginState remembers an OpenID Connect login between the redirect to the
+// provider and the callback. The state sent to the provider is stored as a
+// SHA-256 hash; the PKCE code verifier and nonce never leave the server.
+type OidcLoginState struct {
+	OidcLoginStateId string     `gorm:"primaryKey;type:varchar(25);column:oidc_login_state_id" json:"oidcLoginStateId,omitempty"`
+	StateHash        string     `gorm:"type:varchar(64);not null;unique;column:state_hash" json:"-"`
+	CodeVerifier     string     `gorm:"type:varchar(128);not null;column:code_verifier" json:"-"`
+	Nonce            string     `gorm:"type:varchar(64);not null;column:nonce" json:"-"`
+	ExpiresAt        time.Time  `gorm:"not null;column:expires_at" json:"expiresAt,omitempty"`
+	ConsumedAt       *time.Time `gorm:"column:consumed_at" json:"consumedAt,omitempty"`
+	CreatedAt        time.Time  `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy        string     `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy        string     `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+}
+
+func (OidcLoginState) TableName() string {
+	return "oidc_login_states"
+}
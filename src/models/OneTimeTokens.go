@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Enums
+type OneTimeTokenPurpose string
+
+const (
+	OneTimeTokenPurposeEmailVerification OneTimeTokenPurpose = "email_verification"
+	OneTimeTokenPurposePasswordReset     OneTimeTokenPurpose = "password_reset"
+	OneTimeTokenPurposeMfaChallenge      OneTimeTokenPurpose = "mfa_challenge"
+	OneTimeTokenPurposePhoneVerification OneTimeTokenPurpose = "phone_verification"
+)
+
+// OneTimeToken model. A single-use secret sent to a user, stored as a hash:
+// SHA-256 for random tokens, bcrypt for short codes users type in. Target is
+// the address it was sent to, so a token only confirms that address.
+type OneTimeToken struct {
+	OneTimeTokenId string              `gorm:"primaryKey;type:varchar(25);column:one_time_token_id" json:"oneTimeTokenId,omitempty"`
+	UserId         string              `gorm:"type:varchar(25);not null;column:user_id" json:"userId,omitempty"`
+	Purpose        OneTimeTokenPurpose `gorm:"type:varchar(50);not null;column:purpose" json:"purpose,omitempty"`
+	TokenHash      string              `gorm:"type:varchar(64);not null;column:token_hash" json:"-"`
+	Target         string              `gorm:"type:varchar(255);not null;column:target" json:"target,omitempty"`
+	ExpiresAt      time.Time           `gorm:"not null;column:expires_at" json:"expiresAt,omitempty"`
+	ConsumedAt     *time.Time          `gorm:"column:consumed_at" json:"consumedAt,omitempty"`
+	Attempts       int                 `gorm:"not null;default:0;column:attempts" json:"attempts"`
+	CreatedAt      time.Time           `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt      time.Time           `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy      string              `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy      string              `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (OneTimeToken) TableName() string {
+	return "one_time_tokens"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken model. Tokens are stored as SHA-256 hashes; every token issued
+// from one login shares a FamilyId, which access tokens carry as their session ID.
+type RefreshToken struct {
+	RefreshTokenId string     `gorm:"primaryKey;type:varchar(25);column:refresh_token_id" json:"refreshTokenId,omitempty"`
+	UserId         string     `gorm:"type:varchar(25);not null;column:user_id" json:"userId,omitempty"`
+	FamilyId       string     `gorm:"type:varchar(25);not null;column:family_id" json:"familyId,omitempty"`
+	TokenHash      string     `gorm:"type:varchar(64);not null;unique;column:token_hash" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null;column:expires_at" json:"expiresAt,omitempty"`
+	RevokedAt      *time.Time `gorm:"column:revoked_at" json:"revokedAt,omitempty"`
+	ReplacedBy     string     `gorm:"type:varchar(25);column:replaced_by" json:"replacedBy,omitempty"`
+	IP             string     `gorm:"type:varchar(64);column:ip" json:"ip,omitempty"`
+	UserAgent      string     `gorm:"type:text;column:user_agent" json:"userAgent,omitempty"`
+	CreatedAt      time.Time  `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy      string     `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy      string     `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
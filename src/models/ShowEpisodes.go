@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// EpisodeType marks an episode as a regular episode, a trailer or bonus content
+type EpisodeType string
+
+const (
+	EpisodeTypeFull    EpisodeType = "full"
+	EpisodeTypeTrailer EpisodeType = "trailer"
+	EpisodeTypeBonus   EpisodeType = "bonus"
+)
+
+// ShowEpisode publishes an audio blog as an episode of a show. A blog is an
+// episode of at most one show.
+type ShowEpisode struct {
+	ShowEpisodeId   string      `gorm:"primaryKey;type:varchar(25);column:show_episode_id" json:"showEpisodeId"`
+	ShowId          string      `gorm:"type:varchar(25);not null;uniqueIndex:idx_show_episodes_numbering;column:show_id" json:"showId"`
+	BlogId          string      `gorm:"type:varchar(25);not null;unique;column:blog_id" json:"blogId"`
+	SeasonNumber    int         `gorm:"not null;default:0;uniqueIndex:idx_show_episodes_numbering;column:season_number" json:"seasonNumber,omitempty"`
+	EpisodeNumber   int         `gorm:"not null;uniqueIndex:idx_show_episodes_numbering;column:episode_number" json:"episodeNumber"`
+	EpisodeType     EpisodeType `gorm:"type:varchar(20);default:'full';column:episode_type" json:"episodeType"`
+	Explicit        bool        `gorm:"type:boolean;default:false;column:explicit" json:"explicit"`
+	AudioLength     int64       `gorm:"column:audio_length" json:"audioLength"`
+	AudioType       string      `gorm:"type:varchar(100);column:audio_type" json:"audioType"`
+	DurationSeconds int         `gorm:"column:duration_seconds" json:"durationSeconds,omitempty"`
+	CreatedAt       time.Time   `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt       time.Time   `gorm:"not null;column:updated_at" json:"updatedAt"`
+	CreatedBy       string      `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy       string      `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	Show *Show `gorm:"foreignKey:show_id;references:show_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"show,omitempty"`
+	Blog *Blog `gorm:"foreignKey:blog_id;references:blog_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"blog,omitempty"`
+}
+
+func (ShowEpisode) TableName() string {
+	return "show_episodes"
+}
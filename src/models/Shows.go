@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ShowType tells podcast apps how to order a show's episodes
+type ShowType string
+
+const (
+	ShowTypeEpisodic ShowType = "episodic" // newest first
+	ShowTypeSerial   ShowType = "serial"   // oldest first, by season and episode
+)
+
+// Show groups a user's audio blogs into a podcast
+type Show struct {
+	ShowId      string    `gorm:"primaryKey;type:varchar(25);column:show_id" json:"showId"`
+	UserId      string    `gorm:"type:varchar(25);not null;index;column:user_id" json:"userId"`
+	Title       string    `gorm:"type:varchar(255);not null;column:title" json:"title"`
+	Description string    `gorm:"type:text;column:description" json:"description"`
+	Artwork     string    `gorm:"type:text;column:artwork" json:"artwork"`
+	Category    string    `gorm:"type:varchar(100);column:category" json:"category"`
+	Subcategory string    `gorm:"type:varchar(100);column:subcategory" json:"subcategory"`
+	Explicit    bool      `gorm:"type:boolean;default:false;column:explicit" json:"explicit"`
+	Language    string    `gorm:"type:varchar(10);default:'en';column:language" json:"language"`
+	Type        ShowType  `gorm:"type:varchar(20);default:'episodic';column:type" json:"type"`
+	Author      string    `gorm:"type:varchar(255);column:author" json:"author"`
+	OwnerEmail  string    `gorm:"type:varchar(255);column:owner_email" json:"ownerEmail,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"not null;column:updated_at" json:"updatedAt"`
+	CreatedBy   string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy   string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (Show) TableName() string {
+	return "shows"
+}
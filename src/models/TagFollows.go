@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// TagFollow subscribes a user to a tag, whose blogs then appear in their
+// following feed
+type TagFollow struct {
+	TagFollowId string    `gorm:"primaryKey;type:varchar(25);column:tag_follow_id" json:"tagFollowId"`
+	UserId      string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_tag_follows_user_id_tag_id;column:user_id" json:"userId"`
+	TagId       string    `gorm:"type:varchar(25);not null;uniqueIndex:idx_tag_follows_user_id_tag_id;index;column:tag_id" json:"tagId"`
+	CreatedAt   time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	CreatedBy   string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy   string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+	Tag  *Tag  `gorm:"foreignKey:tag_id;references:tag_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"tag,omitempty"`
+}
+
+func (TagFollow) TableName() string {
+	return "tag_follows"
+}
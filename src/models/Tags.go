@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Tag is a topic blogs are filed under. Names are normalised to lowercase
+// ASCII words joined by hyphens, e.g. "machine-learning".
+type Tag struct {
+	TagId     string    `gorm:"primaryKey;type:varchar(25);column:tag_id" json:"tagId"`
+	Name      string    `gorm:"type:varchar(50);not null;unique;column:name" json:"name"`
+	CreatedAt time.Time `gorm:"not null;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	CreatedBy string    `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy"`
+	UpdatedBy string    `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// AffinityKind is what a user has an affinity for
+type AffinityKind string
+
+const (
+	AffinityKindAuthor AffinityKind = "author" // TargetId is a user ID
+	AffinityKindTag    AffinityKind = "tag"    // TargetId is a tag ID
+)
+
+// UserAffinity is how much a user engages with an author's or a tag's blogs,
+// from 0 to 1 relative to what they engage with most. Affinities are
+// recomputed in the background with the blog scores.
+type UserAffinity struct {
+	UserId     string       `gorm:"primaryKey;type:varchar(25);column:user_id" json:"userId"`
+	Kind       AffinityKind `gorm:"primaryKey;type:varchar(20);column:kind" json:"kind"`
+	TargetId   string       `gorm:"primaryKey;type:varchar(25);column:target_id" json:"targetId"`
+	Weight     float64      `gorm:"not null;column:weight" json:"weight"`
+	ComputedAt time.Time    `gorm:"not null;column:computed_at" json:"computedAt"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (UserAffinity) TableName() string {
+	return "user_affinities"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// UserMfa model. TOTP second factor of a user; it only protects logins once
+// Enabled is set by confirming a first code.
+type UserMfa struct {
+	UserMfaId    string     `gorm:"primaryKey;type:varchar(25);column:user_mfa_id" json:"userMfaId,omitempty"`
+	UserId       string     `gorm:"type:varchar(25);not null;unique;column:user_id" json:"userId,omitempty"`
+	Secret       string     `gorm:"type:varchar(64);not null;column:secret" json:"-"`
+	Enabled      bool       `gorm:"type:boolean;not null;default:false;column:enabled" json:"enabled"`
+	ConfirmedAt  *time.Time `gorm:"column:confirmed_at" json:"confirmedAt,omitempty"`
+	LastUsedStep int64      `gorm:"not null;default:0;column:last_used_step" json:"-"`
+	CreatedAt    time.Time  `gorm:"not null;column:created_at" json:"createdAt,omitempty"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at" json:"updatedAt,omitempty"`
+	CreatedBy    string     `gorm:"type:varchar(80);not null;column:created_by" json:"createdBy,omitempty"`
+	UpdatedBy    string     `gorm:"type:varchar(80);not null;column:updated_by" json:"updatedBy,omitempty"`
+
+	User *User `gorm:"foreignKey:user_id;references:user_id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE" json:"user,omitempty"`
+}
+
+func (UserMfa) TableName() string {
+	return "user_mfas"
+}
@@ -28,6 +28,8 @@ type User struct {
 	Bio                   string     `gorm:"type:text;column:bio" json:"bio,omitempty"`
 	PhoneNumber           string     `gorm:"type:varchar(255);column:phone_number" json:"phoneNumber,omitempty"`
 	Status                UserStatus `gorm:"type:user_status;default:'active';column:status" json:"status,omitempty"`
+	StatusReason          string     `gorm:"type:text;column:status_reason" json:"statusReason,omitempty"`
+	SuspendedUntil        *time.Time `gorm:"column:suspended_until" json:"suspendedUntil,omitempty"`
 	Password              string     `gorm:"type:varchar(255);not null;column:password" json:"password,omitempty"`
 	Gender                string     `gorm:"type:varchar(255);column:gender" json:"gender,omitempty"`
 	Dob                   string     `gorm:"type:varchar(255);column:dob" json:"dob,omitempty"`
@@ -54,6 +56,18 @@ func (User) TableName() string {
 	return "users"
 }
 
+// EffectiveStatus returns the user's status, treating a suspension that has
+// run out as active.
+func (u User) EffectiveStatus() UserStatus {
+	if u.Status == UserStatusSuspended && u.SuspendedUntil != nil && time.Now().After(*u.SuspendedUntil) {
+		return UserStatusActive
+	}
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
 func (u *User) AfterFind(tx *gorm.DB) (err error) {
 	u.Password = ""
 	return
@@ -0,0 +1,148 @@
+// Package pagination pages list endpoints either by page number or by an
+// opaque cursor. Page numbers are kept for existing clients; cursors are keyed
+// on (created_at, id), so rows created while a client scrolls do not shift the
+// pages it has yet to fetch, and deep pages do not pay for an OFFSET.
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxLimit caps the number of items a single page can ask for
+const maxLimit = 100
+
+// Cursor is the position of the last item of a page
+type Cursor struct {
+	CreatedAt time.Time
+	Id        string
+}
+
+// Encode returns the cursor as an opaque, URL-safe string
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.Id))
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, err
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return Cursor{}, fmt.Errorf("malformed cursor")
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{CreatedAt: parsed, Id: id}, nil
+}
+
+// Params describes the page a client asked for
+type Params struct {
+	Page  int
+	Limit int
+	// After is set when the client pages by cursor; Page is then ignored
+	After *Cursor
+	// Count reports whether the total number of items should be counted
+	Count bool
+}
+
+// FromQuery reads page, limit, after and count from the query string. The
+// total is counted for numbered pages and skipped for cursor pages unless the
+// client says otherwise with count=true or count=false.
+func FromQuery(ctx *fiber.Ctx, defaultLimit int) (Params, error) {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", strconv.Itoa(defaultLimit)))
+	params := Params{Page: page, Limit: limit}
+	params.normalize(defaultLimit)
+
+	if after := ctx.Query("after"); after != "" {
+		cursor, err := DecodeCursor(after)
+		if err != nil {
+			return Params{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid cursor"}
+		}
+		params.After = &cursor
+		params.Page = 1
+	}
+
+	params.Count = params.After == nil
+	if count := ctx.Query("count"); count != "" {
+		value, err := strconv.ParseBool(count)
+		if err != nil {
+			return Params{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Count must be true or false"}
+		}
+		params.Count = value
+	}
+	return params, nil
+}
+
+func (p *Params) normalize(defaultLimit int) {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = defaultLimit
+	}
+	if p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+}
+
+// Keyset orders a query newest first by the given columns and limits it to the
+// requested page. It fetches one row more than the limit so that Trim can tell
+// whether there is a next page without counting.
+func (p Params) Keyset(createdAtColumn, idColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.After != nil {
+			db = db.Where(fmt.Sprintf("(%s, %s) < (?, ?)", createdAtColumn, idColumn), p.After.CreatedAt, p.After.Id)
+		} else {
+			db = db.Offset((p.Page - 1) * p.Limit)
+		}
+		return db.Order(createdAtColumn + " DESC").Order(idColumn + " DESC").Limit(p.Limit + 1)
+	}
+}
+
+// Numbered limits a query to the requested numbered page, for lists ordered
+// by something other than creation. Like Keyset, it fetches one extra row.
+func (p Params) Numbered(db *gorm.DB) *gorm.DB {
+	return db.Offset((p.Page - 1) * p.Limit).Limit(p.Limit + 1)
+}
+
+// Trim drops the extra row fetched by Keyset and returns the page's metadata,
+// with a cursor to the next page when there is one. totalItems is only
+// reported when the params ask for a count. cursorOf may be nil for lists
+// that can only be paged by number.
+func Trim[T any](p Params, rows []T, totalItems int64, cursorOf func(T) Cursor) ([]T, models.PaginationMetadata) {
+	hasNextPage := len(rows) > p.Limit
+	if hasNextPage {
+		rows = rows[:p.Limit]
+	}
+
+	metadata := models.PaginationMetadata{
+		CurrentPage:     int64(p.Page),
+		ItemsPerPage:    int64(p.Limit),
+		HasNextPage:     hasNextPage,
+		HasPreviousPage: p.After != nil || p.Page > 1,
+	}
+	if p.After != nil {
+		metadata.CurrentPage = 0
+	}
+	if p.Count {
+		metadata.TotalItems = totalItems
+		metadata.TotalPages = (totalItems + int64(p.Limit) - 1) / int64(p.Limit)
+	}
+	if hasNextPage && cursorOf != nil {
+		metadata.NextCursor = cursorOf(rows[len(rows)-1]).Encode()
+	}
+	return rows, metadata
+}
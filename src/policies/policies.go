@@ -0,0 +1,80 @@
+// Package policies decides who may change which content. Authors manage their
+// own blogs and comments, admins moderate everything, and blog authors can
+// remove comments left on their posts.
+package policies
+
+import (
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// forbidden is the error returned for every denied action
+func forbidden(message string) error {
+	return &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: " + message}
+}
+
+// isAuthor reports whether the current user wrote the content
+func isAuthor(currentUser models.ICurrentUser, authorId string) bool {
+	return currentUser.IsAuthenticated && currentUser.UserId != "" && currentUser.UserId == authorId
+}
+
+// CanUpdateBlog allows the author and admins to edit a blog
+func CanUpdateBlog(currentUser models.ICurrentUser, blog models.Blog) error {
+	if isAuthor(currentUser, blog.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only edit your own blogs")
+}
+
+// CanDeleteBlog allows the author and admins to delete a blog
+func CanDeleteBlog(currentUser models.ICurrentUser, blog models.Blog) error {
+	if isAuthor(currentUser, blog.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only delete your own blogs")
+}
+
+// CanUpdateComment allows only the author and admins to edit a comment
+func CanUpdateComment(currentUser models.ICurrentUser, comment models.Comment) error {
+	if isAuthor(currentUser, comment.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only edit your own comments")
+}
+
+// CanDeleteComment allows the author, admins and the author of the blog the
+// comment belongs to to delete a comment. blogAuthorId is empty when the blog
+// could not be resolved.
+func CanDeleteComment(currentUser models.ICurrentUser, comment models.Comment, blogAuthorId string) error {
+	if isAuthor(currentUser, comment.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	if blogAuthorId != "" && isAuthor(currentUser, blogAuthorId) {
+		return nil
+	}
+	return forbidden("You can only delete your own comments or comments on your blogs")
+}
+
+// CanViewBlogRevisions allows the author and admins to read a blog's edit history
+func CanViewBlogRevisions(currentUser models.ICurrentUser, blog models.Blog) error {
+	if isAuthor(currentUser, blog.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only view the history of your own blogs")
+}
+
+// CanManageShow allows the owner and admins to change a show and its episodes
+func CanManageShow(currentUser models.ICurrentUser, show models.Show) error {
+	if isAuthor(currentUser, show.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only manage your own shows")
+}
+
+// CanUpdateUser allows users to edit their own account and admins to edit any
+func CanUpdateUser(currentUser models.ICurrentUser, user models.User) error {
+	if isAuthor(currentUser, user.UserId) || currentUser.IsAdmin() {
+		return nil
+	}
+	return forbidden("You can only edit your own account")
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"strconv"
+
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchController handles HTTP requests for search
+type SearchController struct {
+	service *SearchService
+}
+
+// NewSearchController creates a new SearchController instance
+func NewSearchController(service *SearchService) *SearchController {
+	return &SearchController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the search routes to the Fiber app
+func (c *SearchController) RegisterRoutes(app *fiber.App) {
+	app.Get("/search", middlewares.RequireAuthenticated(), c.Search)
+}
+
+// @Summary Search
+// @Description Full-text search over published blogs (title and text), comments and users, best matches first. Every word matches as a prefix and user names also match approximately. Matches in snippets are wrapped in <mark> tags.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search text"
+// @Param type query string false "Only search blogs, comments or users"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /search [get]
+// @Security ApiKeyAuth
+func (c *SearchController) Search(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	response, err := c.service.Search(ctx.Query("q"), ctx.Query("type"), page, limit)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+)
+
+// BlogResult is a blog matching a search. Matches in TitleHighlight and
+// Snippet are wrapped in <mark> tags.
+type BlogResult struct {
+	BlogId         string    `json:"blogId"`
+	Slug           string    `json:"slug"`
+	Title          string    `json:"title"`
+	TitleHighlight string    `json:"titleHighlight" example:"Getting started with <mark>Postgres</mark>"`
+	Snippet        string    `json:"snippet" example:"… full-text search in <mark>Postgres</mark> uses …"`
+	UserId         string    `json:"userId"`
+	AuthorName     string    `json:"authorName"`
+	AuthorImage    string    `json:"authorImage"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Rank           float64   `json:"rank"`
+}
+
+// CommentResult is a comment or reply matching a search
+type CommentResult struct {
+	CommentId   string    `json:"commentId"`
+	RefId       string    `json:"refId"`
+	Snippet     string    `json:"snippet"`
+	UserId      string    `json:"userId"`
+	AuthorName  string    `json:"authorName"`
+	AuthorImage string    `json:"authorImage"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Rank        float64   `json:"rank"`
+}
+
+// UserResult is a user matching a search
+type UserResult struct {
+	UserId       string  `json:"userId"`
+	FullName     string  `json:"fullName"`
+	UserName     string  `json:"userName"`
+	ProfileImage string  `json:"profileImage"`
+	Verified     bool    `json:"verified"`
+	Rank         float64 `json:"rank"`
+}
+
+// SearchResponse holds a page of results for each searched type
+type SearchResponse struct {
+	Query    string                    `json:"query" example:"postgres search"`
+	Blogs    *models.PaginatedResponse `json:"blogs,omitempty"`
+	Comments *models.PaginatedResponse `json:"comments,omitempty"`
+	Users    *models.PaginatedResponse `json:"users,omitempty"`
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTerms caps how many words of a search are matched
+const maxTerms = 10
+
+// PrefixQuery turns free text into a tsquery source that matches every word
+// as a prefix, so "postg full" finds "PostgreSQL full-text". It returns ""
+// when the text has no letters or digits.
+func PrefixQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxTerms {
+		words = words[:maxTerms]
+	}
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// MatchUsers limits a user query to users whose name, user name or bio match
+// text, or whose name is similar to it. Emails are not matched, so search
+// cannot be used to find out which addresses have accounts.
+func MatchUsers(text string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("users.search_vector @@ to_tsquery('simple', ?) OR users.full_name % ? OR users.user_name % ?",
+			PrefixQuery(text), text, text)
+	}
+}
+
+// UserRank scores how well a user matches text, for ordering MatchUsers results
+func UserRank(text string) clause.Expr {
+	return clause.Expr{
+		SQL:  "GREATEST(ts_rank_cd(users.search_vector, to_tsquery('simple', ?)), similarity(users.full_name, ?), similarity(users.user_name, ?))",
+		Vars: []interface{}{PrefixQuery(text), text, text},
+	}
+}
+
+// ByUserRank orders MatchUsers results best match first
+func ByUserRank(text string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: "? DESC", Vars: []interface{}{UserRank(text)}, WithoutParentheses: true}}
+}
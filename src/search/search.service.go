@@ -0,0 +1,209 @@
+package search
+
+import (
+	"html"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Search result types
+const (
+	TypeBlogs    = "blogs"
+	TypeComments = "comments"
+	TypeUsers    = "users"
+)
+
+// ts_headline marks matches with these sentinels rather than <mark> tags,
+// since it works on raw text; highlight escapes the result and swaps them in.
+const (
+	startSel = "[[mark]]"
+	stopSel  = "[[/mark]]"
+)
+
+// snippetOptions configures ts_headline for result snippets
+const snippetOptions = "StartSel=\"" + startSel + "\", StopSel=\"" + stopSel + "\", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// titleOptions configures ts_headline for highlighted titles
+const titleOptions = "StartSel=\"" + startSel + "\", StopSel=\"" + stopSel + "\", HighlightAll=true"
+
+var markReplacer = strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+
+// highlight HTML-escapes a ts_headline result and turns its match sentinels
+// into <mark> tags, so the only markup in it is the highlighting
+func highlight(headline string) string {
+	return markReplacer.Replace(html.EscapeString(headline))
+}
+
+// SearchService handles full-text search
+type SearchService struct {
+	db     *gorm.DB
+	logger *log.Logger
+}
+
+// NewSearchService creates a new SearchService instance
+func NewSearchService(db *gorm.DB) *SearchService {
+	return &SearchService{
+		db:     db,
+		logger: log.New(os.Stderr, "search-service: ", log.LstdFlags),
+	}
+}
+
+// Search finds published blogs, comments and users matching text. resultType
+// limits the search to one type; otherwise every type gets a page of results.
+func (s *SearchService) Search(text, resultType string, page, limit int) (SearchResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	if len(text) > 200 {
+		return SearchResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Search query is too long"}
+	}
+	tsquery := PrefixQuery(text)
+	if tsquery == "" {
+		return SearchResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Search query must contain letters or digits"}
+	}
+	if resultType != "" && resultType != TypeBlogs && resultType != TypeComments && resultType != TypeUsers {
+		return SearchResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "type must be blogs, comments or users"}
+	}
+
+	response := SearchResponse{Query: text}
+	var err error
+	if resultType == "" || resultType == TypeBlogs {
+		if response.Blogs, err = s.searchBlogs(tsquery, page, limit); err != nil {
+			return SearchResponse{}, err
+		}
+	}
+	if resultType == "" || resultType == TypeComments {
+		if response.Comments, err = s.searchComments(tsquery, page, limit); err != nil {
+			return SearchResponse{}, err
+		}
+	}
+	if resultType == "" || resultType == TypeUsers {
+		if response.Users, err = s.searchUsers(text, page, limit); err != nil {
+			return SearchResponse{}, err
+		}
+	}
+	return response, nil
+}
+
+// searchBlogs ranks published blogs, weighting title matches over text matches
+func (s *SearchService) searchBlogs(tsquery string, page, limit int) (*models.PaginatedResponse, error) {
+	matches := func() *gorm.DB {
+		return s.db.Table("blogs").
+			Joins("CROSS JOIN to_tsquery('english', ?) AS query", tsquery).
+			Where("blogs.status = ? AND blogs.search_vector @@ query", models.BlogStatusPublished)
+	}
+
+	var totalItems int64
+	if err := matches().Count(&totalItems).Error; err != nil {
+		s.logger.Printf("Error counting blog matches: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search blogs"}
+	}
+
+	results := []BlogResult{}
+	err := matches().
+		Select("blogs.blog_id, blogs.slug, blogs.title, blogs.user_id, blogs.created_at, " +
+			"users.full_name AS author_name, users.profile_image AS author_image, " +
+			"ts_rank_cd(blogs.search_vector, query) AS rank, " +
+			"ts_headline('english', coalesce(blogs.title, ''), query, '" + titleOptions + "') AS title_highlight, " +
+			"ts_headline('english', coalesce(blogs.text, ''), query, '" + snippetOptions + "') AS snippet").
+		Joins("JOIN users ON users.user_id = blogs.user_id").
+		Order("rank DESC, blogs.created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&results).Error
+	if err != nil {
+		s.logger.Printf("Error searching blogs: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search blogs"}
+	}
+	for i := range results {
+		results[i].TitleHighlight = highlight(results[i].TitleHighlight)
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+	return paginated(results, totalItems, page, limit), nil
+}
+
+// searchComments ranks comments and replies, leaving out comments on blogs
+// that are not published
+func (s *SearchService) searchComments(tsquery string, page, limit int) (*models.PaginatedResponse, error) {
+	matches := func() *gorm.DB {
+		return s.db.Table("comments").
+			Joins("CROSS JOIN to_tsquery('english', ?) AS query", tsquery).
+			Where("comments.search_vector @@ query").
+			Where("NOT EXISTS (SELECT 1 FROM blogs WHERE blogs.blog_id = comments.ref_id AND blogs.status <> ?)", models.BlogStatusPublished)
+	}
+
+	var totalItems int64
+	if err := matches().Count(&totalItems).Error; err != nil {
+		s.logger.Printf("Error counting comment matches: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search comments"}
+	}
+
+	results := []CommentResult{}
+	err := matches().
+		Select("comments.comment_id, comments.ref_id, comments.user_id, comments.created_at, " +
+			"users.full_name AS author_name, users.profile_image AS author_image, " +
+			"ts_rank_cd(comments.search_vector, query) AS rank, " +
+			"ts_headline('english', coalesce(comments.text, ''), query, '" + snippetOptions + "') AS snippet").
+		Joins("JOIN users ON users.user_id = comments.user_id").
+		Order("rank DESC, comments.created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&results).Error
+	if err != nil {
+		s.logger.Printf("Error searching comments: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search comments"}
+	}
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+	return paginated(results, totalItems, page, limit), nil
+}
+
+// searchUsers ranks users by name, user name and bio, with trigram
+// similarity catching misspelt names
+func (s *SearchService) searchUsers(text string, page, limit int) (*models.PaginatedResponse, error) {
+	matches := func() *gorm.DB {
+		return s.db.Model(&models.User{}).
+			Where("users.status <> ?", models.UserStatusBanned).
+			Scopes(MatchUsers(text))
+	}
+
+	var totalItems int64
+	if err := matches().Count(&totalItems).Error; err != nil {
+		s.logger.Printf("Error counting user matches: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search users"}
+	}
+
+	results := []UserResult{}
+	err := matches().
+		Select("users.user_id, users.full_name, users.user_name, users.profile_image, users.verified, ? AS rank", UserRank(text)).
+		Order(ByUserRank(text)).
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&results).Error
+	if err != nil {
+		s.logger.Printf("Error searching users: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to search users"}
+	}
+	return paginated(results, totalItems, page, limit), nil
+}
+
+func paginated(data interface{}, totalItems int64, page, limit int) *models.PaginatedResponse {
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	return &models.PaginatedResponse{
+		Data: data,
+		Metadata: models.PaginationMetadata{
+			CurrentPage:     int64(page),
+			ItemsPerPage:    int64(limit),
+			TotalItems:      totalItems,
+			TotalPages:      totalPages,
+			HasNextPage:     int64(page) < totalPages,
+			HasPreviousPage: int64(page) > 1,
+		},
+	}
+}
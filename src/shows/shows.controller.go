@@ -0,0 +1,245 @@
+package shows
+
+import (
+	"github.com/epsierra/phinex-blog-api/src/feeds"
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShowsController handles HTTP requests for podcast shows
+type ShowsController struct {
+	service *ShowsService
+}
+
+// NewShowsController creates a new ShowsController instance
+func NewShowsController(service *ShowsService) *ShowsController {
+	return &ShowsController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the show routes to the Fiber app. Shows, their
+// podcast feeds and episode downloads can be read without signing in, since
+// podcast apps do not authenticate.
+func (c *ShowsController) RegisterRoutes(app *fiber.App) {
+	app.Post("/shows", middlewares.RequireAuthenticated(), c.CreateShow)
+	app.Get("/shows/:showId", c.FindShow)
+	app.Put("/shows/:showId", middlewares.RequireAuthenticated(), c.UpdateShow)
+	app.Delete("/shows/:showId", middlewares.RequireAuthenticated(), c.DeleteShow)
+	app.Post("/shows/:showId/episodes", middlewares.RequireAuthenticated(), c.AddEpisode)
+	app.Put("/shows/:showId/episodes/:blogId", middlewares.RequireAuthenticated(), c.UpdateEpisode)
+	app.Delete("/shows/:showId/episodes/:blogId", middlewares.RequireAuthenticated(), c.RemoveEpisode)
+	app.Get("/feeds/shows/:showId.rss", c.FindPodcastFeed)
+	app.Get("/feeds/episodes/:blogId.:extension", c.DownloadEpisode)
+}
+
+// @Summary Create a show
+// @Description Creates a podcast show owned by the current user. Category and subcategory must be Apple Podcasts categories.
+// @Tags Shows
+// @Accept json
+// @Produce json
+// @Param show body CreateShowDto true "Show to create"
+// @Success 201 {object} MutationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows [post]
+// @Security ApiKeyAuth
+func (c *ShowsController) CreateShow(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	var dto CreateShowDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.Create(dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// @Summary Get a show
+// @Description Returns a show with its published episodes; the owner and admins also see episodes that are not published yet
+// @Tags Shows
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Success 200 {object} ShowResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId} [get]
+func (c *ShowsController) FindShow(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.FindOne(ctx.Params("showId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Update a show
+// @Description Updates a show's metadata. Only the owner and admins can update a show.
+// @Tags Shows
+// @Accept json
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Param show body UpdateShowDto true "Fields to change"
+// @Success 202 {object} MutationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId} [put]
+// @Security ApiKeyAuth
+func (c *ShowsController) UpdateShow(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	var dto UpdateShowDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.Update(ctx.Params("showId"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// @Summary Delete a show
+// @Description Deletes a show and its episode list. The episodes' blogs are kept.
+// @Tags Shows
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Success 200 {object} MutationResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId} [delete]
+// @Security ApiKeyAuth
+func (c *ShowsController) DeleteShow(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.Delete(ctx.Params("showId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Add an episode
+// @Description Publishes one of the owner's blogs with audio as an episode of the show. The episode number defaults to the next one in the season, and the audio's size is looked up when audioLength is omitted.
+// @Tags Shows
+// @Accept json
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Param episode body AddEpisodeDto true "Episode to add"
+// @Success 201 {object} MutationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId}/episodes [post]
+// @Security ApiKeyAuth
+func (c *ShowsController) AddEpisode(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	var dto AddEpisodeDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.AddEpisode(ctx.Params("showId"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// @Summary Update an episode
+// @Description Changes an episode's numbering, type, explicit flag, audio size or duration
+// @Tags Shows
+// @Accept json
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Param blogId path string true "Blog ID of the episode"
+// @Param episode body UpdateEpisodeDto true "Fields to change"
+// @Success 202 {object} MutationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId}/episodes/{blogId} [put]
+// @Security ApiKeyAuth
+func (c *ShowsController) UpdateEpisode(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+	var dto UpdateEpisodeDto
+	if err := ctx.BodyParser(&dto); err != nil {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: "Invalid request body"}
+	}
+
+	response, err := c.service.UpdateEpisode(ctx.Params("showId"), ctx.Params("blogId"), dto, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// @Summary Remove an episode
+// @Description Takes a blog out of the show. The blog itself is kept.
+// @Tags Shows
+// @Produce json
+// @Param showId path string true "Show ID"
+// @Param blogId path string true "Blog ID of the episode"
+// @Success 200 {object} MutationResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shows/{showId}/episodes/{blogId} [delete]
+// @Security ApiKeyAuth
+func (c *ShowsController) RemoveEpisode(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.RemoveEpisode(ctx.Params("showId"), ctx.Params("blogId"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Podcast feed
+// @Description A show's published episodes as an RSS feed with iTunes and Podcasting 2.0 tags. Supports conditional GET with ETag and Last-Modified.
+// @Tags Shows
+// @Produce xml
+// @Param showId path string true "Show ID"
+// @Success 200 {string} string "The feed"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/shows/{showId}.rss [get]
+func (c *ShowsController) FindPodcastFeed(ctx *fiber.Ctx) error {
+	body, updated, err := c.service.PodcastFeed(ctx.Params("showId"), ctx.Path())
+	if err != nil {
+		return err
+	}
+	return feeds.SendConditional(ctx, body, "application/rss+xml; charset=utf-8", updated)
+}
+
+// @Summary Download an episode
+// @Description Counts a download of an episode's audio and redirects to it. HEAD requests are not counted.
+// @Tags Shows
+// @Param blogId path string true "Blog ID of the episode"
+// @Param extension path string true "The audio's file extension, e.g. mp3"
+// @Success 302 "Redirect to the audio"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/episodes/{blogId}.{extension} [get]
+func (c *ShowsController) DownloadEpisode(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	audioURL, err := c.service.DownloadEpisode(ctx.Params("blogId"), ctx.Method() == fiber.MethodGet, currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.Redirect(audioURL, fiber.StatusFound)
+}
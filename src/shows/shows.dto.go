@@ -0,0 +1,167 @@
+package shows
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+)
+
+// CreateShowDto defines the input for creating a show
+type CreateShowDto struct {
+	Title       string `json:"title" validate:"required" example:"The Phinex Podcast"`
+	Description string `json:"description" example:"Weekly conversations about building on the web."`
+	// Artwork is a square JPEG or PNG of 1400 to 3000 pixels
+	Artwork     string          `json:"artwork" example:"https://example.com/artwork.jpg"`
+	Category    string          `json:"category" example:"Technology"`
+	Subcategory string          `json:"subcategory,omitempty" example:""`
+	Explicit    bool            `json:"explicit" example:"false"`
+	Language    string          `json:"language,omitempty" example:"en"`
+	Type        models.ShowType `json:"type,omitempty" example:"episodic"`
+	// Author defaults to the current user's name
+	Author     string `json:"author,omitempty" example:"Jane Doe"`
+	OwnerEmail string `json:"ownerEmail,omitempty" example:"podcast@example.com"`
+}
+
+// UpdateShowDto defines the input for updating a show. Empty fields are left unchanged.
+type UpdateShowDto struct {
+	Title       string          `json:"title" example:"The Phinex Podcast"`
+	Description string          `json:"description" example:"Weekly conversations about building on the web."`
+	Artwork     string          `json:"artwork" example:"https://example.com/artwork.jpg"`
+	Category    string          `json:"category" example:"Technology"`
+	Subcategory *string         `json:"subcategory,omitempty" example:""`
+	Explicit    *bool           `json:"explicit,omitempty" example:"true"`
+	Language    string          `json:"language,omitempty" example:"en"`
+	Type        models.ShowType `json:"type,omitempty" example:"serial"`
+	Author      string          `json:"author,omitempty" example:"Jane Doe"`
+	OwnerEmail  *string         `json:"ownerEmail,omitempty" example:"podcast@example.com"`
+}
+
+// AddEpisodeDto defines the input for publishing an audio blog as an episode
+type AddEpisodeDto struct {
+	BlogId string `json:"blogId" validate:"required" example:"blog123"`
+	// SeasonNumber is 0 for shows without seasons
+	SeasonNumber int `json:"seasonNumber,omitempty" example:"1"`
+	// EpisodeNumber defaults to the next number in the season
+	EpisodeNumber int                `json:"episodeNumber,omitempty" example:"12"`
+	EpisodeType   models.EpisodeType `json:"episodeType,omitempty" example:"full"`
+	Explicit      bool               `json:"explicit,omitempty" example:"false"`
+	// AudioLength is the audio file's size in bytes; it is looked up when omitted
+	AudioLength     int64 `json:"audioLength,omitempty" example:"24986239"`
+	DurationSeconds int   `json:"durationSeconds,omitempty" example:"1830"`
+}
+
+// UpdateEpisodeDto defines the input for updating an episode. Omitted fields are left unchanged.
+type UpdateEpisodeDto struct {
+	SeasonNumber    *int               `json:"seasonNumber,omitempty" example:"1"`
+	EpisodeNumber   *int               `json:"episodeNumber,omitempty" example:"13"`
+	EpisodeType     models.EpisodeType `json:"episodeType,omitempty" example:"bonus"`
+	Explicit        *bool              `json:"explicit,omitempty" example:"true"`
+	AudioLength     *int64             `json:"audioLength,omitempty" example:"24986239"`
+	DurationSeconds *int               `json:"durationSeconds,omitempty" example:"1830"`
+}
+
+// Episode is an episode with the blog it publishes
+type Episode struct {
+	models.ShowEpisode
+	Title          string     `json:"title"`
+	Audio          string     `json:"audio"`
+	Status         string     `json:"status"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty"`
+	DownloadsCount int64      `json:"downloadsCount"`
+}
+
+// ShowWithEpisodes is a show with its episodes, newest first
+type ShowWithEpisodes struct {
+	Show     models.Show `json:"show"`
+	Episodes []Episode   `json:"episodes"`
+}
+
+// ShowResponse represents a single show
+type ShowResponse struct {
+	Data ShowWithEpisodes `json:"data"`
+}
+
+// MutationResponse represents the standard mutation response
+type MutationResponse struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// podcastFeed is an RSS 2.0 document with the iTunes and Podcasting 2.0 extensions
+type podcastFeed struct {
+	XMLName   xml.Name       `xml:"rss"`
+	Version   string         `xml:"version,attr"`
+	ItunesNS  string         `xml:"xmlns:itunes,attr"`
+	PodcastNS string         `xml:"xmlns:podcast,attr"`
+	AtomNS    string         `xml:"xmlns:atom,attr"`
+	ContentNS string         `xml:"xmlns:content,attr"`
+	Channel   podcastChannel `xml:"channel"`
+}
+
+type podcastChannel struct {
+	Title       string          `xml:"title"`
+	Link        string          `xml:"link"`
+	Description cdata           `xml:"description"`
+	Language    string          `xml:"language"`
+	SelfLink    podcastAtomLink `xml:"atom:link"`
+	Image       *itunesImage    `xml:"itunes:image,omitempty"`
+	Category    *itunesCategory `xml:"itunes:category,omitempty"`
+	Explicit    string          `xml:"itunes:explicit"`
+	Author      string          `xml:"itunes:author,omitempty"`
+	Owner       *itunesOwner    `xml:"itunes:owner,omitempty"`
+	Type        models.ShowType `xml:"itunes:type"`
+	GUID        string          `xml:"podcast:guid"`
+	Items       []podcastItem   `xml:"item"`
+}
+
+type podcastAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text        string          `xml:"text,attr"`
+	Subcategory *itunesCategory `xml:"itunes:category,omitempty"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"itunes:name"`
+	Email string `xml:"itunes:email"`
+}
+
+type podcastItem struct {
+	Title          string             `xml:"title"`
+	Link           string             `xml:"link"`
+	Description    cdata              `xml:"description"`
+	GUID           podcastGUID        `xml:"guid"`
+	PubDate        string             `xml:"pubDate"`
+	Enclosure      podcastEnclosure   `xml:"enclosure"`
+	Duration       int                `xml:"itunes:duration,omitempty"`
+	EpisodeType    models.EpisodeType `xml:"itunes:episodeType"`
+	Explicit       string             `xml:"itunes:explicit"`
+	Season         int                `xml:"itunes:season,omitempty"`
+	Episode        int                `xml:"itunes:episode"`
+	PodcastSeason  int                `xml:"podcast:season,omitempty"`
+	PodcastEpisode int                `xml:"podcast:episode"`
+}
+
+type podcastGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type podcastEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
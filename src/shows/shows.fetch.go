@@ -0,0 +1,68 @@
+package shows
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// errNotPublic is returned when a request would reach a host that is not on
+// the public internet
+var errNotPublic = errors.New("refusing to connect to a non-public address")
+
+// newAudioClient returns the client used to ask audio hosts for file sizes.
+// Audio URLs come from users, so it only speaks HTTPS and refuses to connect
+// to loopback, private, link-local and other non-public addresses. The check
+// runs on the resolved address of every connection, so redirects and DNS
+// answers cannot point it at internal services.
+func newAudioClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return errNotPublic
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return requireHTTPS(req.URL)
+		},
+	}
+}
+
+// requireHTTPS rejects URLs the audio client must not fetch
+func requireHTTPS(target *url.URL) error {
+	if target.Scheme != "https" || target.Host == "" {
+		return fmt.Errorf("refusing to fetch %q: only https URLs are allowed", target.Redacted())
+	}
+	return nil
+}
+
+// isPublicAddr reports whether an address is routable on the public internet
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate does not cover
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
@@ -0,0 +1,49 @@
+package shows
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:2800::1":    true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, public, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestAudioClientRefusesInternalHosts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1234")
+	}))
+	defer server.Close()
+
+	resp, err := newAudioClient().Head(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	assert.ErrorIs(t, err, errNotPublic)
+
+	s := &ShowsService{logger: log.New(io.Discard, "", 0), httpClient: newAudioClient()}
+	assert.Zero(t, s.audioLength(server.URL))
+	assert.Zero(t, s.audioLength("http://example.com/episode.mp3"))
+}
@@ -0,0 +1,152 @@
+package shows
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/markup"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// podcastNamespace is the UUID namespace Podcasting 2.0 derives podcast:guid from
+var podcastNamespace = [16]byte{0xea, 0xd4, 0xc2, 0x36, 0xbf, 0x58, 0x58, 0xc6, 0xa2, 0xc6, 0xa6, 0xb2, 0x8d, 0x12, 0x8c, 0xb6}
+
+// PodcastFeed renders a show's published episodes as an RSS feed for podcast
+// apps. selfPath is the path the feed is served at; enclosures point at the
+// download route, which counts each download before redirecting to the audio.
+// It also returns when the feed last changed.
+func (s *ShowsService) PodcastFeed(showId, selfPath string) ([]byte, time.Time, error) {
+	selfURL := s.baseURL + selfPath
+	downloadsURL := s.baseURL + "/feeds/episodes"
+	show, err := s.findShow(showId)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	episodes, err := s.findEpisodes(show, true)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	updated := show.UpdatedAt
+	channel := podcastChannel{
+		Title:       show.Title,
+		Link:        s.publicWebURL + "/shows/" + url.PathEscape(show.ShowId),
+		Description: cdata{Value: show.Description},
+		Language:    show.Language,
+		SelfLink:    podcastAtomLink{Href: selfURL, Rel: "self", Type: "application/rss+xml"},
+		Explicit:    fmt.Sprint(show.Explicit),
+		Author:      show.Author,
+		Type:        show.Type,
+		GUID:        podcastGUIDFor(selfURL),
+		Items:       []podcastItem{},
+	}
+	if show.Artwork != "" {
+		channel.Image = &itunesImage{Href: show.Artwork}
+	}
+	if show.Category != "" {
+		channel.Category = &itunesCategory{Text: show.Category}
+		if show.Subcategory != "" {
+			channel.Category.Subcategory = &itunesCategory{Text: show.Subcategory}
+		}
+	}
+	if show.OwnerEmail != "" {
+		channel.Owner = &itunesOwner{Name: show.Author, Email: show.OwnerEmail}
+	}
+
+	for _, episode := range episodes {
+		blog := episode.Blog
+		if blog == nil {
+			continue
+		}
+		if blog.UpdatedAt.After(updated) {
+			updated = blog.UpdatedAt
+		}
+		description := blog.Html
+		if description == "" && blog.Text != "" {
+			// Blogs saved before text was rendered on write
+			if rendered, err := markup.Render(blog.Format, blog.Text); err == nil {
+				description = rendered.HTML
+			}
+		}
+		published := blog.CreatedAt
+		if blog.PublishedAt != nil {
+			published = *blog.PublishedAt
+		}
+		channel.Items = append(channel.Items, podcastItem{
+			Title:       blog.Title,
+			Link:        s.blogLink(*blog),
+			Description: cdata{Value: description},
+			GUID:        podcastGUID{IsPermaLink: false, Value: blog.BlogId},
+			PubDate:     published.UTC().Format(time.RFC1123Z),
+			Enclosure: podcastEnclosure{
+				URL:    downloadsURL + "/" + url.PathEscape(blog.BlogId) + audioExtension(blog.Audio),
+				Length: episode.AudioLength,
+				Type:   episode.AudioType,
+			},
+			Duration:       episode.DurationSeconds,
+			EpisodeType:    episode.EpisodeType,
+			Explicit:       fmt.Sprint(show.Explicit || episode.Explicit),
+			Season:         episode.SeasonNumber,
+			Episode:        episode.EpisodeNumber,
+			PodcastSeason:  episode.SeasonNumber,
+			PodcastEpisode: episode.EpisodeNumber,
+		})
+	}
+
+	body, err := xml.MarshalIndent(podcastFeed{
+		Version:   "2.0",
+		ItunesNS:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		PodcastNS: "https://podcastindex.org/namespace/1.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	}, "", "  ")
+	if err != nil {
+		s.logger.Printf("Error encoding podcast feed: %v", err)
+		return nil, time.Time{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to render feed"}
+	}
+	return append([]byte(xml.Header), body...), updated, nil
+}
+
+// blogLink is the public address of a blog
+func (s *ShowsService) blogLink(blog models.Blog) string {
+	if blog.Url != "" {
+		return blog.Url
+	}
+	return s.publicWebURL + "/blogs/" + blog.BlogId
+}
+
+// podcastGUIDFor derives a show's podcast:guid, a version 5 UUID of its feed
+// URL without the scheme and trailing slashes
+func podcastGUIDFor(feedURL string) string {
+	name := feedURL
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = strings.TrimRight(name, "/")
+
+	hash := sha1.New()
+	hash.Write(podcastNamespace[:])
+	hash.Write([]byte(name))
+	sum := hash.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// audioExtension keeps the audio's file extension on the download URL, which
+// some podcast apps use to recognise the media
+func audioExtension(audioURL string) string {
+	if parsed, err := url.Parse(audioURL); err == nil {
+		if extension := strings.ToLower(path.Ext(parsed.Path)); extension != "" && len(extension) <= 5 {
+			return extension
+		}
+	}
+	return ".mp3"
+}
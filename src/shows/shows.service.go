@@ -0,0 +1,594 @@
+package shows
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/feeds"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/policies"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// categories are the Apple Podcasts categories and their subcategories
+var categories = map[string][]string{
+	"Arts":                    {"Books", "Design", "Fashion & Beauty", "Food", "Performing Arts", "Visual Arts"},
+	"Business":                {"Careers", "Entrepreneurship", "Investing", "Management", "Marketing", "Non-Profit"},
+	"Comedy":                  {"Comedy Interviews", "Improv", "Stand-Up"},
+	"Education":               {"Courses", "How To", "Language Learning", "Self-Improvement"},
+	"Fiction":                 {"Comedy Fiction", "Drama", "Science Fiction"},
+	"Government":              nil,
+	"History":                 nil,
+	"Health & Fitness":        {"Alternative Health", "Fitness", "Medicine", "Mental Health", "Nutrition", "Sexuality"},
+	"Kids & Family":           {"Education for Kids", "Parenting", "Pets & Animals", "Stories for Kids"},
+	"Leisure":                 {"Animation & Manga", "Automotive", "Aviation", "Crafts", "Games", "Hobbies", "Home & Garden", "Video Games"},
+	"Music":                   {"Music Commentary", "Music History", "Music Interviews"},
+	"News":                    {"Business News", "Daily News", "Entertainment News", "News Commentary", "Politics", "Sports News", "Tech News"},
+	"Religion & Spirituality": {"Buddhism", "Christianity", "Hinduism", "Islam", "Judaism", "Religion", "Spirituality"},
+	"Science":                 {"Astronomy", "Chemistry", "Earth Sciences", "Life Sciences", "Mathematics", "Natural Sciences", "Nature", "Physics", "Social Sciences"},
+	"Society & Culture":       {"Documentary", "Personal Journals", "Philosophy", "Places & Travel", "Relationships"},
+	"Sports":                  {"Baseball", "Basketball", "Cricket", "Fantasy Sports", "Football", "Golf", "Hockey", "Rugby", "Running", "Soccer", "Swimming", "Tennis", "Volleyball", "Wilderness", "Wrestling"},
+	"Technology":              nil,
+	"True Crime":              nil,
+	"TV & Film":               {"After Shows", "Film History", "Film Interviews", "Film Reviews", "TV Reviews"},
+}
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// ShowsService handles podcast shows and their episodes
+type ShowsService struct {
+	db           *gorm.DB
+	logger       *log.Logger
+	publicWebURL string
+	baseURL      string
+	httpClient   *http.Client
+}
+
+// NewShowsService creates a new ShowsService instance. Feeds link to
+// themselves and to episode downloads under the API's BASE_URL.
+func NewShowsService(db *gorm.DB) *ShowsService {
+	return &ShowsService{
+		db:           db,
+		logger:       log.New(os.Stderr, "shows-service: ", log.LstdFlags),
+		publicWebURL: strings.TrimRight(os.Getenv("PUBLIC_WEB_URL"), "/"),
+		baseURL:      strings.TrimRight(os.Getenv("BASE_URL"), "/"),
+		httpClient:   newAudioClient(),
+	}
+}
+
+// Create creates a show owned by the current user
+func (s *ShowsService) Create(dto CreateShowDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	now := time.Now().UTC()
+	show := models.Show{
+		ShowId:      utils.GenerateID(),
+		UserId:      currentUser.UserId,
+		Title:       strings.TrimSpace(dto.Title),
+		Description: strings.TrimSpace(dto.Description),
+		Artwork:     strings.TrimSpace(dto.Artwork),
+		Category:    dto.Category,
+		Subcategory: dto.Subcategory,
+		Explicit:    dto.Explicit,
+		Language:    dto.Language,
+		Type:        dto.Type,
+		Author:      strings.TrimSpace(dto.Author),
+		OwnerEmail:  strings.TrimSpace(dto.OwnerEmail),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   currentUser.FullName,
+		UpdatedBy:   currentUser.FullName,
+	}
+	if show.Language == "" {
+		show.Language = "en"
+	}
+	if show.Type == "" {
+		show.Type = models.ShowTypeEpisodic
+	}
+	if show.Author == "" {
+		show.Author = currentUser.FullName
+	}
+	if err := validateShow(show); err != nil {
+		return MutationResponse{}, err
+	}
+
+	if err := s.db.Create(&show).Error; err != nil {
+		s.logger.Printf("Error creating show: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to create show"}
+	}
+	return MutationResponse{Message: "Show created successfully", Data: show}, nil
+}
+
+// FindOne returns a show with its episodes. Episodes of unpublished blogs are
+// only listed for the show's owner and admins.
+func (s *ShowsService) FindOne(showId string, currentUser models.ICurrentUser) (ShowResponse, error) {
+	show, err := s.findShow(showId)
+	if err != nil {
+		return ShowResponse{}, err
+	}
+
+	episodes, err := s.findEpisodes(show, policies.CanManageShow(currentUser, show) != nil)
+	if err != nil {
+		return ShowResponse{}, err
+	}
+	return ShowResponse{Data: ShowWithEpisodes{Show: show, Episodes: episodes}}, nil
+}
+
+// Update changes a show's metadata
+func (s *ShowsService) Update(showId string, dto UpdateShowDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	show, err := s.findShow(showId)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if err := policies.CanManageShow(currentUser, show); err != nil {
+		return MutationResponse{}, err
+	}
+
+	if title := strings.TrimSpace(dto.Title); title != "" {
+		show.Title = title
+	}
+	if description := strings.TrimSpace(dto.Description); description != "" {
+		show.Description = description
+	}
+	if artwork := strings.TrimSpace(dto.Artwork); artwork != "" {
+		show.Artwork = artwork
+	}
+	if dto.Category != "" {
+		show.Category = dto.Category
+		// A new category drops a subcategory that belongs to the old one
+		if dto.Subcategory == nil {
+			show.Subcategory = ""
+		}
+	}
+	if dto.Subcategory != nil {
+		show.Subcategory = *dto.Subcategory
+	}
+	if dto.Explicit != nil {
+		show.Explicit = *dto.Explicit
+	}
+	if dto.Language != "" {
+		show.Language = dto.Language
+	}
+	if dto.Type != "" {
+		show.Type = dto.Type
+	}
+	if author := strings.TrimSpace(dto.Author); author != "" {
+		show.Author = author
+	}
+	if dto.OwnerEmail != nil {
+		show.OwnerEmail = strings.TrimSpace(*dto.OwnerEmail)
+	}
+	if err := validateShow(show); err != nil {
+		return MutationResponse{}, err
+	}
+
+	show.UpdatedAt = time.Now().UTC()
+	show.UpdatedBy = currentUser.FullName
+	if err := s.db.Save(&show).Error; err != nil {
+		s.logger.Printf("Error updating show: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to update show"}
+	}
+	return MutationResponse{Message: "Show updated successfully", Data: show}, nil
+}
+
+// Delete removes a show and its episodes. The blogs themselves are kept.
+func (s *ShowsService) Delete(showId string, currentUser models.ICurrentUser) (MutationResponse, error) {
+	show, err := s.findShow(showId)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if err := policies.CanManageShow(currentUser, show); err != nil {
+		return MutationResponse{}, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("show_id = ?", showId).Delete(&models.ShowEpisode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&show).Error
+	})
+	if err != nil {
+		s.logger.Printf("Error deleting show: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to delete show"}
+	}
+	return MutationResponse{Message: "Show deleted successfully"}, nil
+}
+
+// AddEpisode publishes one of the show owner's audio blogs as an episode. The
+// episode is numbered after the last one in its season unless a number is
+// given, and the audio's size is looked up when it is not.
+func (s *ShowsService) AddEpisode(showId string, dto AddEpisodeDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	show, err := s.findShow(showId)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if err := policies.CanManageShow(currentUser, show); err != nil {
+		return MutationResponse{}, err
+	}
+
+	var blog models.Blog
+	if err := s.db.Select("blog_id", "user_id", "audio").Where("blog_id = ?", dto.BlogId).First(&blog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return MutationResponse{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s does not exist", dto.BlogId)}
+		}
+		s.logger.Printf("Error fetching episode blog: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add episode"}
+	}
+	if blog.UserId != show.UserId {
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusForbidden, Message: "Forbidden: Episodes must be blogs by the show's owner"}
+	}
+	if blog.Audio == "" {
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Only blogs with audio can be episodes"}
+	}
+
+	now := time.Now().UTC()
+	episode := models.ShowEpisode{
+		ShowEpisodeId:   utils.GenerateID(),
+		ShowId:          showId,
+		BlogId:          blog.BlogId,
+		SeasonNumber:    dto.SeasonNumber,
+		EpisodeNumber:   dto.EpisodeNumber,
+		EpisodeType:     dto.EpisodeType,
+		Explicit:        dto.Explicit,
+		AudioLength:     dto.AudioLength,
+		AudioType:       audioType(blog.Audio),
+		DurationSeconds: dto.DurationSeconds,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CreatedBy:       currentUser.FullName,
+		UpdatedBy:       currentUser.FullName,
+	}
+	if episode.EpisodeType == "" {
+		episode.EpisodeType = models.EpisodeTypeFull
+	}
+	if err := validateEpisode(episode); err != nil {
+		return MutationResponse{}, err
+	}
+	if episode.AudioLength == 0 {
+		episode.AudioLength = s.audioLength(blog.Audio)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.ShowEpisode{}).Where("blog_id = ?", blog.BlogId).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return &fiber.Error{Code: fiber.StatusConflict, Message: "This blog is already an episode of a show"}
+		}
+
+		if episode.EpisodeNumber == 0 {
+			var last int
+			if err := tx.Model(&models.ShowEpisode{}).
+				Where("show_id = ? AND season_number = ?", showId, episode.SeasonNumber).
+				Select("COALESCE(MAX(episode_number), 0)").Scan(&last).Error; err != nil {
+				return err
+			}
+			episode.EpisodeNumber = last + 1
+		} else if err := episodeNumberFree(tx, episode); err != nil {
+			return err
+		}
+		return tx.Create(&episode).Error
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
+		s.logger.Printf("Error adding episode: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to add episode"}
+	}
+	return MutationResponse{Message: "Episode added successfully", Data: episode}, nil
+}
+
+// UpdateEpisode changes an episode's numbering and podcast metadata
+func (s *ShowsService) UpdateEpisode(showId, blogId string, dto UpdateEpisodeDto, currentUser models.ICurrentUser) (MutationResponse, error) {
+	episode, err := s.findEpisode(showId, blogId, currentUser)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+
+	if dto.SeasonNumber != nil {
+		episode.SeasonNumber = *dto.SeasonNumber
+	}
+	if dto.EpisodeNumber != nil {
+		episode.EpisodeNumber = *dto.EpisodeNumber
+	}
+	if dto.EpisodeType != "" {
+		episode.EpisodeType = dto.EpisodeType
+	}
+	if dto.Explicit != nil {
+		episode.Explicit = *dto.Explicit
+	}
+	if dto.AudioLength != nil {
+		episode.AudioLength = *dto.AudioLength
+	}
+	if dto.DurationSeconds != nil {
+		episode.DurationSeconds = *dto.DurationSeconds
+	}
+	if err := validateEpisode(episode); err != nil {
+		return MutationResponse{}, err
+	}
+
+	episode.UpdatedAt = time.Now().UTC()
+	episode.UpdatedBy = currentUser.FullName
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if dto.SeasonNumber != nil || dto.EpisodeNumber != nil {
+			if err := episodeNumberFree(tx, episode); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&episode).Error
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return MutationResponse{}, fiberErr
+		}
+		s.logger.Printf("Error updating episode: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to update episode"}
+	}
+	return MutationResponse{Message: "Episode updated successfully", Data: episode}, nil
+}
+
+// RemoveEpisode takes a blog out of a show. The blog itself is kept.
+func (s *ShowsService) RemoveEpisode(showId, blogId string, currentUser models.ICurrentUser) (MutationResponse, error) {
+	episode, err := s.findEpisode(showId, blogId, currentUser)
+	if err != nil {
+		return MutationResponse{}, err
+	}
+	if err := s.db.Delete(&episode).Error; err != nil {
+		s.logger.Printf("Error removing episode: %v", err)
+		return MutationResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to remove episode"}
+	}
+	return MutationResponse{Message: "Episode removed successfully"}, nil
+}
+
+// DownloadEpisode returns the audio URL of a published episode. When count is
+// set the download is recorded the way FindOne records a view; podcast apps
+// probing the file with HEAD requests are not counted.
+func (s *ShowsService) DownloadEpisode(blogId string, count bool, currentUser models.ICurrentUser) (string, error) {
+	var blog models.Blog
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Select("blogs.blog_id", "blogs.audio").
+			Joins("JOIN show_episodes ON show_episodes.blog_id = blogs.blog_id").
+			Where("blogs.blog_id = ? AND blogs.status = ?", blogId, models.BlogStatusPublished).
+			First(&blog).Error
+		if err != nil || !count {
+			return err
+		}
+
+		// Increment downloads_count for the blog
+		if err := tx.Model(&models.Blog{}).Where("blog_id = ?", blogId).Update("downloads_count", gorm.Expr("downloads_count + ?", 1)).Error; err != nil {
+			return err
+		}
+
+		// Create a new Download entry
+		download := models.Download{
+			DownloadId: utils.GenerateID(),
+			RefId:      blogId,
+			CreatedAt:  time.Now().UTC(),
+			CreatedBy:  "podcast",
+			UpdatedAt:  time.Now().UTC(),
+			UpdatedBy:  "podcast",
+		}
+		if currentUser.IsAuthenticated && currentUser.UserId != "" {
+			download.UserId = &currentUser.UserId
+			download.CreatedBy = currentUser.FullName
+			download.UpdatedBy = currentUser.FullName
+		}
+		return tx.Create(&download).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Episode with ID %s does not exist", blogId)}
+		}
+		s.logger.Printf("Error recording download: %v", err)
+		return "", &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch episode"}
+	}
+	return blog.Audio, nil
+}
+
+func (s *ShowsService) findShow(showId string) (models.Show, error) {
+	var show models.Show
+	if err := s.db.Where("show_id = ?", showId).First(&show).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Show{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Show with ID %s does not exist", showId)}
+		}
+		s.logger.Printf("Error fetching show: %v", err)
+		return models.Show{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch show"}
+	}
+	return show, nil
+}
+
+// findEpisode loads an episode the current user may manage
+func (s *ShowsService) findEpisode(showId, blogId string, currentUser models.ICurrentUser) (models.ShowEpisode, error) {
+	show, err := s.findShow(showId)
+	if err != nil {
+		return models.ShowEpisode{}, err
+	}
+	if err := policies.CanManageShow(currentUser, show); err != nil {
+		return models.ShowEpisode{}, err
+	}
+
+	var episode models.ShowEpisode
+	if err := s.db.Where("show_id = ? AND blog_id = ?", showId, blogId).First(&episode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ShowEpisode{}, &fiber.Error{Code: fiber.StatusNotFound, Message: fmt.Sprintf("Blog with ID %s is not an episode of this show", blogId)}
+		}
+		s.logger.Printf("Error fetching episode: %v", err)
+		return models.ShowEpisode{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch episode"}
+	}
+	return episode, nil
+}
+
+// findEpisodes lists a show's episodes, newest first. Serial shows list them
+// in order instead.
+func (s *ShowsService) findEpisodes(show models.Show, publishedOnly bool) ([]Episode, error) {
+	query := s.db.Model(&models.ShowEpisode{}).
+		Preload("Blog", func(db *gorm.DB) *gorm.DB {
+			return db.Select("blog_id", "user_id", "slug", "url", "title", "text", "format", "html", "audio", "status",
+				"created_at", "updated_at", "published_at", "downloads_count")
+		}).
+		Joins("JOIN blogs ON blogs.blog_id = show_episodes.blog_id").
+		Where("show_episodes.show_id = ?", show.ShowId)
+	if publishedOnly {
+		query = query.Where("blogs.status = ?", models.BlogStatusPublished)
+	}
+	if show.Type == models.ShowTypeSerial {
+		query = query.Order("show_episodes.season_number, show_episodes.episode_number")
+	} else {
+		query = query.Order("COALESCE(blogs.published_at, blogs.created_at) DESC")
+	}
+
+	var rows []models.ShowEpisode
+	if err := query.Find(&rows).Error; err != nil {
+		s.logger.Printf("Error fetching episodes: %v", err)
+		return nil, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch episodes"}
+	}
+
+	episodes := make([]Episode, 0, len(rows))
+	for _, row := range rows {
+		episode := Episode{ShowEpisode: row}
+		if row.Blog != nil {
+			episode.Title = row.Blog.Title
+			episode.Audio = row.Blog.Audio
+			episode.Status = string(row.Blog.Status)
+			episode.PublishedAt = row.Blog.PublishedAt
+			episode.DownloadsCount = row.Blog.DownloadsCount
+		}
+		episodes = append(episodes, episode)
+	}
+	return episodes, nil
+}
+
+// audioLength asks the audio's host for its size, which podcast apps need
+// before downloading. It returns 0 when the host does not say, or when the
+// audio is not on a public HTTPS host.
+func (s *ShowsService) audioLength(audioURL string) int64 {
+	target, err := url.Parse(audioURL)
+	if err == nil {
+		err = requireHTTPS(target)
+	}
+	if err != nil {
+		s.logger.Printf("Not fetching audio size of %s: %v", audioURL, err)
+		return 0
+	}
+	resp, err := s.httpClient.Head(target.String())
+	if err != nil {
+		s.logger.Printf("Error fetching audio size of %s: %v", audioURL, err)
+		return 0
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+		return 0
+	}
+	return resp.ContentLength
+}
+
+// episodeNumberFree rejects a number another episode in the season already has
+func episodeNumberFree(tx *gorm.DB, episode models.ShowEpisode) error {
+	var taken int64
+	err := tx.Model(&models.ShowEpisode{}).
+		Where("show_id = ? AND season_number = ? AND episode_number = ? AND blog_id <> ?",
+			episode.ShowId, episode.SeasonNumber, episode.EpisodeNumber, episode.BlogId).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return &fiber.Error{Code: fiber.StatusConflict, Message: fmt.Sprintf("Episode %d is already taken in this season", episode.EpisodeNumber)}
+	}
+	return nil
+}
+
+func validateShow(show models.Show) error {
+	badRequest := func(message string) error {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: message}
+	}
+
+	if show.Title == "" || len(show.Title) > 255 {
+		return badRequest("Title is required and must be at most 255 characters")
+	}
+	if show.Artwork != "" {
+		parsed, err := url.Parse(show.Artwork)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return badRequest("Artwork must be an http(s) URL")
+		}
+		switch strings.ToLower(path.Ext(parsed.Path)) {
+		case ".jpg", ".jpeg", ".png":
+		default:
+			return badRequest("Artwork must be a JPEG or PNG image")
+		}
+	}
+	if show.Category != "" {
+		subcategories, ok := categories[show.Category]
+		if !ok {
+			return badRequest(fmt.Sprintf("Unknown category %s", show.Category))
+		}
+		if show.Subcategory != "" && !slices.Contains(subcategories, show.Subcategory) {
+			return badRequest(fmt.Sprintf("Unknown subcategory %s of %s", show.Subcategory, show.Category))
+		}
+	} else if show.Subcategory != "" {
+		return badRequest("A subcategory needs a category")
+	}
+	if !languageCode.MatchString(show.Language) {
+		return badRequest("Language must be a language code such as en or en-US")
+	}
+	if show.Type != models.ShowTypeEpisodic && show.Type != models.ShowTypeSerial {
+		return badRequest(fmt.Sprintf("Type must be %s or %s", models.ShowTypeEpisodic, models.ShowTypeSerial))
+	}
+	if show.OwnerEmail != "" {
+		if _, err := mail.ParseAddress(show.OwnerEmail); err != nil {
+			return badRequest("Owner email is not a valid email address")
+		}
+	}
+	return nil
+}
+
+func validateEpisode(episode models.ShowEpisode) error {
+	badRequest := func(message string) error {
+		return &fiber.Error{Code: fiber.StatusBadRequest, Message: message}
+	}
+
+	switch episode.EpisodeType {
+	case models.EpisodeTypeFull, models.EpisodeTypeTrailer, models.EpisodeTypeBonus:
+	default:
+		return badRequest(fmt.Sprintf("Episode type must be %s, %s or %s", models.EpisodeTypeFull, models.EpisodeTypeTrailer, models.EpisodeTypeBonus))
+	}
+	if episode.SeasonNumber < 0 || episode.EpisodeNumber < 0 {
+		return badRequest("Season and episode numbers cannot be negative")
+	}
+	if episode.AudioLength < 0 || episode.DurationSeconds < 0 {
+		return badRequest("Audio length and duration cannot be negative")
+	}
+	return nil
+}
+
+// audioType is the media type podcast apps expect for an audio URL
+func audioType(audioURL string) string {
+	parsed, err := url.Parse(audioURL)
+	if err == nil {
+		switch strings.ToLower(path.Ext(parsed.Path)) {
+		case ".mp3":
+			return "audio/mpeg"
+		case ".m4a":
+			return "audio/x-m4a"
+		case ".aac":
+			return "audio/aac"
+		case ".ogg", ".oga":
+			return "audio/ogg"
+		case ".opus":
+			return "audio/opus"
+		}
+	}
+	return feeds.MediaType(audioURL, "audio/mpeg")
+}
@@ -0,0 +1,82 @@
+package sitemaps
+
+import (
+	"strconv"
+
+	"github.com/epsierra/phinex-blog-api/src/feeds"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SitemapsController handles HTTP requests for sitemaps
+type SitemapsController struct {
+	service *SitemapsService
+}
+
+// NewSitemapsController creates a new SitemapsController instance
+func NewSitemapsController(service *SitemapsService) *SitemapsController {
+	return &SitemapsController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the sitemap routes to the Fiber app. Sitemaps are
+// public, so no guard covers them.
+func (c *SitemapsController) RegisterRoutes(app *fiber.App) {
+	app.Get("/sitemap.xml", c.FindSitemapIndex)
+	app.Get("/sitemaps/blogs-:page.xml", c.FindBlogsSitemap)
+	app.Get("/sitemaps/users-:page.xml", c.FindUsersSitemap)
+}
+
+// @Summary Sitemap index
+// @Description Lists the paged child sitemaps of published blogs and user profiles, with the time each last changed
+// @Tags Sitemaps
+// @Produce xml
+// @Success 200 {string} string "The sitemap index"
+// @Success 304 "Not modified"
+// @Router /sitemap.xml [get]
+func (c *SitemapsController) FindSitemapIndex(ctx *fiber.Ctx) error {
+	body, updated, err := c.service.Index()
+	if err != nil {
+		return err
+	}
+	return feeds.SendConditional(ctx, body, "application/xml; charset=utf-8", updated)
+}
+
+// @Summary Blogs sitemap
+// @Description One page of published blogs, oldest first, with their canonical URL and last update
+// @Tags Sitemaps
+// @Produce xml
+// @Param page path int true "Page number, from 1"
+// @Success 200 {string} string "The sitemap"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sitemaps/blogs-{page}.xml [get]
+func (c *SitemapsController) FindBlogsSitemap(ctx *fiber.Ctx) error {
+	return c.send(ctx, KindBlogs)
+}
+
+// @Summary Users sitemap
+// @Description One page of user profiles, oldest first, with their public URL and last update
+// @Tags Sitemaps
+// @Produce xml
+// @Param page path int true "Page number, from 1"
+// @Success 200 {string} string "The sitemap"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sitemaps/users-{page}.xml [get]
+func (c *SitemapsController) FindUsersSitemap(ctx *fiber.Ctx) error {
+	return c.send(ctx, KindUsers)
+}
+
+func (c *SitemapsController) send(ctx *fiber.Ctx, kind string) error {
+	page, err := strconv.Atoi(ctx.Params("page"))
+	if err != nil {
+		return &fiber.Error{Code: fiber.StatusNotFound, Message: "Sitemap not found"}
+	}
+
+	body, updated, err := c.service.Sitemap(kind, page)
+	if err != nil {
+		return err
+	}
+	return feeds.SendConditional(ctx, body, "application/xml; charset=utf-8", updated)
+}
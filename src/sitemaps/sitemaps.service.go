@@ -0,0 +1,173 @@
+package sitemaps
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Child sitemap kinds
+const (
+	KindBlogs = "blogs"
+	KindUsers = "users"
+)
+
+// maxPageSize is the most URLs the sitemap protocol allows in one file
+const maxPageSize = 50000
+
+// page is one child sitemap and when its newest entry changed
+type page struct {
+	Page         int
+	LastModified time.Time
+}
+
+// entry is one URL of a child sitemap
+type entry struct {
+	Id        string
+	Slug      string
+	Url       string
+	UpdatedAt time.Time
+}
+
+// SitemapsService builds sitemaps of published blogs and user profiles
+type SitemapsService struct {
+	db           *gorm.DB
+	logger       *log.Logger
+	publicWebURL string
+	baseURL      string
+	pageSize     int
+}
+
+// NewSitemapsService creates a new SitemapsService instance. Child sitemaps
+// hold SITEMAP_PAGE_SIZE (default 10000) URLs each and are listed under the
+// API's BASE_URL.
+func NewSitemapsService(db *gorm.DB) *SitemapsService {
+	pageSize := utils.GetEnvInt("SITEMAP_PAGE_SIZE", 10000)
+	if pageSize < 1 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return &SitemapsService{
+		db:           db,
+		logger:       log.New(os.Stderr, "sitemaps-service: ", log.LstdFlags),
+		publicWebURL: strings.TrimRight(os.Getenv("PUBLIC_WEB_URL"), "/"),
+		baseURL:      strings.TrimRight(os.Getenv("BASE_URL"), "/"),
+		pageSize:     pageSize,
+	}
+}
+
+// Index renders the sitemap index, which lists every child sitemap. It also
+// returns when the newest entry changed.
+func (s *SitemapsService) Index() ([]byte, time.Time, error) {
+	index := sitemapIndex{Sitemaps: []sitemapEntry{}}
+	var updated time.Time
+	for _, kind := range []string{KindBlogs, KindUsers} {
+		pages, err := s.pages(kind)
+		if err != nil {
+			s.logger.Printf("Error paging %s sitemap: %v", kind, err)
+			return nil, time.Time{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to build sitemap"}
+		}
+		for _, page := range pages {
+			index.Sitemaps = append(index.Sitemaps, sitemapEntry{
+				Loc:     fmt.Sprintf("%s/sitemaps/%s-%d.xml", s.baseURL, kind, page.Page),
+				LastMod: page.LastModified.UTC().Format(time.RFC3339),
+			})
+			if page.LastModified.After(updated) {
+				updated = page.LastModified
+			}
+		}
+	}
+	return s.encode(index, updated)
+}
+
+// Sitemap renders one page of a child sitemap. Pages run oldest first, so
+// new blogs and users only change the last page.
+func (s *SitemapsService) Sitemap(kind string, pageNumber int) ([]byte, time.Time, error) {
+	query, idColumn, ok := s.query(kind)
+	if !ok || pageNumber < 1 {
+		return nil, time.Time{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "Sitemap not found"}
+	}
+
+	var entries []entry
+	err := query.Order("created_at, " + idColumn).Offset((pageNumber - 1) * s.pageSize).Limit(s.pageSize).Scan(&entries).Error
+	if err != nil {
+		s.logger.Printf("Error fetching %s sitemap: %v", kind, err)
+		return nil, time.Time{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to build sitemap"}
+	}
+	if len(entries) == 0 && pageNumber > 1 {
+		return nil, time.Time{}, &fiber.Error{Code: fiber.StatusNotFound, Message: "Sitemap not found"}
+	}
+
+	set := urlSet{URLs: make([]sitemapURL, 0, len(entries))}
+	var updated time.Time
+	for _, entry := range entries {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     s.location(kind, entry),
+			LastMod: entry.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		if entry.UpdatedAt.After(updated) {
+			updated = entry.UpdatedAt
+		}
+	}
+	return s.encode(set, updated)
+}
+
+// query selects the entries of a sitemap kind, published blogs or users who
+// are not banned, and returns the column that orders entries created at the
+// same time
+func (s *SitemapsService) query(kind string) (*gorm.DB, string, bool) {
+	switch kind {
+	case KindBlogs:
+		return s.db.Model(&models.Blog{}).
+			Select("blog_id AS id, slug, url, created_at, COALESCE(updated_at, created_at) AS updated_at").
+			Where("status = ?", models.BlogStatusPublished), "blog_id", true
+	case KindUsers:
+		return s.db.Model(&models.User{}).
+			Select("user_id AS id, created_at, COALESCE(updated_at, created_at) AS updated_at").
+			Where("status IS NULL OR status <> ?", models.UserStatusBanned), "user_id", true
+	}
+	return nil, "", false
+}
+
+// pages numbers the child sitemaps of a kind and finds when each last changed
+func (s *SitemapsService) pages(kind string) ([]page, error) {
+	query, idColumn, _ := s.query(kind)
+	numbered := query.Select("(ROW_NUMBER() OVER (ORDER BY created_at, "+idColumn+") - 1) / ? + 1 AS page, COALESCE(updated_at, created_at) AS updated_at", s.pageSize)
+
+	var pages []page
+	err := s.db.Table("(?) AS numbered", numbered).
+		Select("page, MAX(updated_at) AS last_modified").
+		Group("page").Order("page").
+		Scan(&pages).Error
+	return pages, err
+}
+
+// location is the public address of a sitemap entry
+func (s *SitemapsService) location(kind string, entry entry) string {
+	switch {
+	case kind == KindUsers:
+		return s.publicWebURL + "/users/" + url.PathEscape(entry.Id)
+	case entry.Url != "":
+		return entry.Url
+	case entry.Slug != "":
+		return s.publicWebURL + "/blogs/" + entry.Slug
+	}
+	return s.publicWebURL + "/blogs/" + url.PathEscape(entry.Id)
+}
+
+func (s *SitemapsService) encode(document interface{}, updated time.Time) ([]byte, time.Time, error) {
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		s.logger.Printf("Error encoding sitemap: %v", err)
+		return nil, time.Time{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to build sitemap"}
+	}
+	return append([]byte(xml.Header), body...), updated, nil
+}
@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender writes text messages to a logger instead of sending them, for local development
+type LogSender struct {
+	logger *log.Logger
+}
+
+// NewLogSender creates a new LogSender
+func NewLogSender(logger *log.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+// Send logs the message
+func (s *LogSender) Send(message Message) error {
+	s.logger.Printf("To: %s | %s", message.To, message.Body)
+	return nil
+}
+
+// FileSender appends text messages to a file instead of sending them, so tests can read them back
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSender creates a new FileSender
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+// Send appends the message to the file
+func (s *FileSender) Send(message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC3339), message.To, message.Body)
+	return err
+}
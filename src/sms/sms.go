@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/epsierra/phinex-blog-api/src/utils"
+)
+
+// Message is a text message to a phone number in E.164 format
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender delivers text messages
+type Sender interface {
+	Send(message Message) error
+}
+
+// NewSenderFromEnv builds the sender selected by SMS_DRIVER.
+//
+//	SMS_DRIVER file or log (default in development and test)
+//	SMS_FILE   file the file driver appends messages to
+//
+// Messages carry live verification codes, so outside development and test
+// the driver must be chosen explicitly.
+func NewSenderFromEnv() (Sender, error) {
+	switch driver := strings.ToLower(os.Getenv("SMS_DRIVER")); driver {
+	case "":
+		if !utils.IsDevelopmentEnv() {
+			return nil, fmt.Errorf("SMS_DRIVER environment variable not set")
+		}
+		return NewLogSender(log.New(os.Stderr, "sms: ", log.LstdFlags)), nil
+	case "log":
+		return NewLogSender(log.New(os.Stderr, "sms: ", log.LstdFlags)), nil
+	case "file":
+		path := os.Getenv("SMS_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("SMS_FILE environment variable not set")
+		}
+		return NewFileSender(path), nil
+	default:
+		return nil, fmt.Errorf("unsupported SMS_DRIVER %q", driver)
+	}
+}
@@ -0,0 +1,92 @@
+package tags
+
+import (
+	"strconv"
+
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TagsController handles HTTP requests for tags
+type TagsController struct {
+	service *TagsService
+}
+
+// NewTagsController creates a new TagsController instance
+func NewTagsController(service *TagsService) *TagsController {
+	return &TagsController{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the tag routes to the Fiber app
+func (c *TagsController) RegisterRoutes(app *fiber.App) {
+	app.Use("/tags/*", middlewares.RequireAuthenticated())
+	app.Get("/tags/popular", c.FindPopularTags)
+	app.Get("/tags/following", c.FindFollowedTags)
+	app.Put("/tags/:tag/follows", c.FollowUnfollowTag)
+}
+
+// @Summary List popular tags
+// @Description Lists the tags with the most published blogs, optionally only counting blogs published in the last N days
+// @Tags Tags
+// @Produce json
+// @Param limit query int false "Number of tags to return" default(20)
+// @Param days query int false "Only count blogs published in the last N days"
+// @Success 200 {object} PopularTagsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tags/popular [get]
+// @Security ApiKeyAuth
+func (c *TagsController) FindPopularTags(ctx *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(ctx.Query("limit", "20"))
+	days, _ := strconv.Atoi(ctx.Query("days", "0"))
+
+	response, err := c.service.FindPopular(limit, days)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary List followed tags
+// @Description Lists the tags the current user follows
+// @Tags Tags
+// @Produce json
+// @Success 200 {object} FollowedTagsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tags/following [get]
+// @Security ApiKeyAuth
+func (c *TagsController) FindFollowedTags(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.FindFollowedTags(currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
+
+// @Summary Follow or unfollow a tag
+// @Description Follows a tag, or unfollows it if the current user already follows it. Blogs with followed tags appear in the following feed.
+// @Tags Tags
+// @Produce json
+// @Param tag path string true "Tag name"
+// @Success 200 {object} FollowTagResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tags/{tag}/follows [put]
+// @Security ApiKeyAuth
+func (c *TagsController) FollowUnfollowTag(ctx *fiber.Ctx) error {
+	currentUser := ctx.Locals("user").(models.ICurrentUser)
+
+	response, err := c.service.FollowUnfollowTag(ctx.Params("tag"), currentUser)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response)
+}
@@ -0,0 +1,23 @@
+package tags
+
+// TagCount is a tag with the number of published blogs filed under it
+type TagCount struct {
+	Name       string `json:"name" example:"machine-learning"`
+	BlogsCount int64  `json:"blogsCount" example:"42"`
+}
+
+// PopularTagsResponse represents the list of popular tags
+type PopularTagsResponse struct {
+	Data []TagCount `json:"data"`
+}
+
+// FollowTagResponse represents the response for following or unfollowing a tag
+type FollowTagResponse struct {
+	Tag      string `json:"tag" example:"machine-learning"`
+	Followed bool   `json:"followed"`
+}
+
+// FollowedTagsResponse represents the tags a user follows
+type FollowedTagsResponse struct {
+	Data []string `json:"data"`
+}
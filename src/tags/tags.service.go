@@ -0,0 +1,232 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxTagsPerBlog is how many tags a blog can be filed under
+	MaxTagsPerBlog = 5
+	// maxTagLength is the longest normalised tag name
+	maxTagLength = 30
+)
+
+// TagsService handles tag-related operations
+type TagsService struct {
+	db     *gorm.DB
+	logger *log.Logger
+}
+
+// NewTagsService creates a new TagsService instance
+func NewTagsService(db *gorm.DB) *TagsService {
+	return &TagsService{
+		db:     db,
+		logger: log.New(os.Stderr, "tags-service: ", log.LstdFlags),
+	}
+}
+
+// FindPopular returns the tags with the most published blogs, optionally only
+// counting blogs published in the last days
+func (s *TagsService) FindPopular(limit, days int) (PopularTagsResponse, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := s.db.Model(&models.BlogTag{}).
+		Select("tags.name AS name, COUNT(blog_tags.blog_id) AS blogs_count").
+		Joins("JOIN tags ON tags.tag_id = blog_tags.tag_id").
+		Joins("JOIN blogs ON blogs.blog_id = blog_tags.blog_id").
+		Where("blogs.status = ?", models.BlogStatusPublished)
+	if days > 0 {
+		query = query.Where("blogs.published_at >= ?", time.Now().UTC().AddDate(0, 0, -days))
+	}
+
+	tags := []TagCount{}
+	err := query.Group("tags.name").Order("blogs_count DESC, tags.name").Limit(limit).Scan(&tags).Error
+	if err != nil {
+		s.logger.Printf("Error counting popular tags: %v", err)
+		return PopularTagsResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch tags"}
+	}
+	return PopularTagsResponse{Data: tags}, nil
+}
+
+// FollowUnfollowTag follows a tag, or unfollows it if the user already does
+func (s *TagsService) FollowUnfollowTag(name string, currentUser models.ICurrentUser) (FollowTagResponse, error) {
+	name, err := NormalizeName(name)
+	if err != nil {
+		return FollowTagResponse{}, err
+	}
+
+	var followed bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("name = ?", name).First(&tag).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &fiber.Error{Code: fiber.StatusNotFound, Message: "Tag not found"}
+			}
+			return err
+		}
+
+		deleted := tx.Where("user_id = ? AND tag_id = ?", currentUser.UserId, tag.TagId).Delete(&models.TagFollow{})
+		if deleted.Error != nil || deleted.RowsAffected > 0 {
+			return deleted.Error
+		}
+
+		followed = true
+		return tx.Create(&models.TagFollow{
+			TagFollowId: utils.GenerateID(),
+			UserId:      currentUser.UserId,
+			TagId:       tag.TagId,
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+			CreatedBy:   currentUser.FullName,
+			UpdatedBy:   currentUser.FullName,
+		}).Error
+	})
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return FollowTagResponse{}, fiberErr
+		}
+		s.logger.Printf("Error following tag: %v", err)
+		return FollowTagResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to follow tag"}
+	}
+	return FollowTagResponse{Tag: name, Followed: followed}, nil
+}
+
+// FindFollowedTags returns the names of the tags the current user follows
+func (s *TagsService) FindFollowedTags(currentUser models.ICurrentUser) (FollowedTagsResponse, error) {
+	names := []string{}
+	err := s.db.Model(&models.TagFollow{}).
+		Joins("JOIN tags ON tags.tag_id = tag_follows.tag_id").
+		Where("tag_follows.user_id = ?", currentUser.UserId).
+		Order("tags.name").
+		Pluck("tags.name", &names).Error
+	if err != nil {
+		s.logger.Printf("Error fetching followed tags: %v", err)
+		return FollowedTagsResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Unable to fetch followed tags"}
+	}
+	return FollowedTagsResponse{Data: names}, nil
+}
+
+// NormalizeName turns user input such as "#Machine Learning" into a tag name
+// ("machine-learning")
+func NormalizeName(name string) (string, error) {
+	normalized := utils.Slugify(strings.TrimLeft(strings.TrimSpace(name), "#"))
+	if normalized == "" {
+		return "", &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Tag %q has no letters or digits", name)}
+	}
+	if len(normalized) > maxTagLength {
+		return "", &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("Tags can be at most %d characters", maxTagLength)}
+	}
+	return normalized, nil
+}
+
+// Normalize normalises a blog's tags, dropping duplicates and enforcing
+// MaxTagsPerBlog
+func Normalize(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag, err := NormalizeName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTagsPerBlog {
+		return nil, &fiber.Error{Code: fiber.StatusBadRequest, Message: fmt.Sprintf("A blog can have at most %d tags", MaxTagsPerBlog)}
+	}
+	return normalized, nil
+}
+
+// SetBlogTags replaces a blog's tags with the given normalised names
+func SetBlogTags(tx *gorm.DB, blogId string, names []string, currentUser models.ICurrentUser) error {
+	if err := tx.Where("blog_id = ?", blogId).Delete(&models.BlogTag{}).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags, err := findOrCreateTags(tx, names, currentUser)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	blogTags := make([]models.BlogTag, 0, len(tags))
+	for _, tag := range tags {
+		blogTags = append(blogTags, models.BlogTag{
+			BlogTagId: utils.GenerateID(),
+			BlogId:    blogId,
+			TagId:     tag.TagId,
+			CreatedAt: now,
+			UpdatedAt: now,
+			CreatedBy: currentUser.FullName,
+			UpdatedBy: currentUser.FullName,
+		})
+	}
+	return tx.Create(&blogTags).Error
+}
+
+// BlogTagNames returns the tag names of each of the given blogs
+func BlogTagNames(tx *gorm.DB, blogIds []string) (map[string][]string, error) {
+	var rows []struct {
+		BlogId string
+		Name   string
+	}
+	err := tx.Model(&models.BlogTag{}).
+		Select("blog_tags.blog_id AS blog_id, tags.name AS name").
+		Joins("JOIN tags ON tags.tag_id = blog_tags.tag_id").
+		Where("blog_tags.blog_id IN ?", blogIds).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string][]string, len(blogIds))
+	for _, row := range rows {
+		names[row.BlogId] = append(names[row.BlogId], row.Name)
+	}
+	return names, nil
+}
+
+// findOrCreateTags returns the tags with the given names, creating missing ones
+func findOrCreateTags(tx *gorm.DB, names []string, currentUser models.ICurrentUser) ([]models.Tag, error) {
+	now := time.Now().UTC()
+	newTags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, models.Tag{
+			TagId:     utils.GenerateID(),
+			Name:      name,
+			CreatedAt: now,
+			UpdatedAt: now,
+			CreatedBy: currentUser.FullName,
+			UpdatedBy: currentUser.FullName,
+		})
+	}
+	// Another request may create the same tag at the same time
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&newTags).Error; err != nil {
+		return nil, err
+	}
+
+	var tags []models.Tag
+	if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
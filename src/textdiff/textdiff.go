@@ -0,0 +1,133 @@
+// Package textdiff computes line-by-line differences between two texts.
+package textdiff
+
+import (
+	"strings"
+)
+
+// Op says what happened to a line
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxCells bounds the memory of the LCS table. Longer texts whose changed
+// middle part exceeds it are diffed as a whole replacement.
+const maxCells = 4_000_000
+
+// Lines returns the edits that turn a into b: every line of both texts in
+// order, marked equal, deleted from a or inserted from b. Deletions come
+// before insertions within a changed block.
+func Lines(a, b string) []Line {
+	as, bs := splitLines(a), splitLines(b)
+
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(as) && prefix < len(bs) && as[prefix] == bs[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(as)-prefix && suffix < len(bs)-prefix && as[len(as)-1-suffix] == bs[len(bs)-1-suffix] {
+		suffix++
+	}
+
+	diff := make([]Line, 0, len(as)+len(bs))
+	for _, line := range as[:prefix] {
+		diff = append(diff, Line{Op: OpEqual, Text: line})
+	}
+	diff = append(diff, middle(as[prefix:len(as)-suffix], bs[prefix:len(bs)-suffix])...)
+	for _, line := range as[len(as)-suffix:] {
+		diff = append(diff, Line{Op: OpEqual, Text: line})
+	}
+	return diff
+}
+
+// Unified renders a diff with "-", "+" and " " line prefixes
+func Unified(diff []Line) string {
+	var b strings.Builder
+	for _, line := range diff {
+		switch line.Op {
+		case OpInsert:
+			b.WriteString("+")
+		case OpDelete:
+			b.WriteString("-")
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(line.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// middle diffs the part of two texts between their common prefix and suffix
+// using a longest common subsequence table
+func middle(as, bs []string) []Line {
+	var diff []Line
+	if len(as)*len(bs) > maxCells {
+		for _, line := range as {
+			diff = append(diff, Line{Op: OpDelete, Text: line})
+		}
+		for _, line := range bs {
+			diff = append(diff, Line{Op: OpInsert, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of as[i:] and bs[j:]
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(as) && j < len(bs) {
+		switch {
+		case as[i] == bs[j]:
+			diff = append(diff, Line{Op: OpEqual, Text: as[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, Line{Op: OpDelete, Text: as[i]})
+			i++
+		default:
+			diff = append(diff, Line{Op: OpInsert, Text: bs[j]})
+			j++
+		}
+	}
+	for ; i < len(as); i++ {
+		diff = append(diff, Line{Op: OpDelete, Text: as[i]})
+	}
+	for ; j < len(bs); j++ {
+		diff = append(diff, Line{Op: OpInsert, Text: bs[j]})
+	}
+	return diff
+}
+
+// splitLines splits a text into lines, treating CRLF like LF. An empty text
+// has no lines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
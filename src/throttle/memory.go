@@ -0,0 +1,109 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often AddFailure drops expired records
+const sweepInterval = time.Minute
+
+// MemoryStore keeps failure records in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastSweep time.Time
+}
+
+type memoryRecord struct {
+	Record
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]memoryRecord{}}
+}
+
+// Get returns the record for the key
+func (s *MemoryStore) Get(key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.records[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return Record{}, nil
+	}
+	return entry.Record, nil
+}
+
+// AddFailure counts a failure for the key
+func (s *MemoryStore) AddFailure(key string, now time.Time, window time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	entry := s.records[key]
+	if now.Sub(entry.LastFailureAt) > window && now.After(entry.LockedUntil) {
+		entry = memoryRecord{}
+	}
+	entry.Failures++
+	entry.LastFailureAt = now
+	entry.expiresAt = latest(now.Add(window), entry.LockedUntil)
+	s.records[key] = entry
+	return entry.Record, nil
+}
+
+// RemoveFailure takes back one failure counted for the key
+func (s *MemoryStore) RemoveFailure(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.records[key]
+	if ok && entry.Failures > 0 {
+		entry.Failures--
+		s.records[key] = entry
+	}
+	return nil
+}
+
+// Lock locks the key until the given time
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.records[key]
+	entry.LockedUntil = until
+	entry.expiresAt = latest(entry.expiresAt, until)
+	s.records[key] = entry
+	return nil
+}
+
+// Delete forgets the key
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records so memory does not grow with every key seen.
+// It walks every record, so it runs at most once per sweepInterval.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.records {
+		if now.After(entry.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,155 @@
+// Package throttle counts failed attempts per key (an IP address or an
+// account) and slows them down: exponential backoff once the free attempts are
+// used up, then a temporary lockout.
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/utils"
+)
+
+// Record is the failure history of one key
+type Record struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store keeps failure records. MemoryStore serves a single instance; a shared
+// store lets several replicas enforce the same limits.
+type Store interface {
+	// Get returns the record for the key, or a zero Record when there is none
+	Get(key string) (Record, error)
+	// AddFailure counts a failure at now, first forgetting the record if its
+	// last failure is older than window, and returns the updated record
+	AddFailure(key string, now time.Time, window time.Duration) (Record, error)
+	// RemoveFailure takes back one failure counted by AddFailure
+	RemoveFailure(key string) error
+	// Lock locks the key until the given time
+	Lock(key string, until time.Time) error
+	// Delete forgets the key
+	Delete(key string) error
+}
+
+// Policy sets how quickly failures are slowed down and locked out
+type Policy struct {
+	FreeAttempts     int           // failures allowed before any delay
+	BaseDelay        time.Duration // delay after the first failure past FreeAttempts, doubled for each further one
+	MaxDelay         time.Duration // longest backoff delay
+	LockoutThreshold int           // failures that lock the key
+	LockoutDuration  time.Duration // how long a lockout lasts
+	Window           time.Duration // failures are forgotten after this long without another
+}
+
+// PolicyFromEnv reads a policy from <prefix>_FREE_ATTEMPTS, _BACKOFF_BASE,
+// _BACKOFF_MAX, _LOCKOUT_THRESHOLD, _LOCKOUT_DURATION and _WINDOW, using the
+// given defaults for unset variables.
+func PolicyFromEnv(prefix string, defaults Policy) Policy {
+	return Policy{
+		FreeAttempts:     utils.GetEnvInt(prefix+"_FREE_ATTEMPTS", defaults.FreeAttempts),
+		BaseDelay:        utils.GetEnvDuration(prefix+"_BACKOFF_BASE", defaults.BaseDelay),
+		MaxDelay:         utils.GetEnvDuration(prefix+"_BACKOFF_MAX", defaults.MaxDelay),
+		LockoutThreshold: utils.GetEnvInt(prefix+"_LOCKOUT_THRESHOLD", defaults.LockoutThreshold),
+		LockoutDuration:  utils.GetEnvDuration(prefix+"_LOCKOUT_DURATION", defaults.LockoutDuration),
+		Window:           utils.GetEnvDuration(prefix+"_WINDOW", defaults.Window),
+	}
+}
+
+// Limiter applies a Policy to the keys in a Store
+type Limiter struct {
+	store  Store
+	policy Policy
+	prefix string
+
+	// mu makes Attempt's check and count atomic within this process
+	mu sync.Mutex
+}
+
+// NewLimiter creates a Limiter. The prefix namespaces its keys, so limiters
+// with different policies can share a store.
+func NewLimiter(store Store, policy Policy, prefix string) *Limiter {
+	return &Limiter{store: store, policy: policy, prefix: prefix}
+}
+
+// Check reports how long the key must wait before its next attempt, and
+// whether that is because it is locked out. A zero duration means go ahead.
+func (l *Limiter) Check(key string, now time.Time) (time.Duration, bool, error) {
+	record, err := l.store.Get(l.prefix + key)
+	if err != nil {
+		return 0, false, err
+	}
+	if now.Before(record.LockedUntil) {
+		return record.LockedUntil.Sub(now), true, nil
+	}
+	if now.Sub(record.LastFailureAt) > l.policy.Window {
+		return 0, false, nil
+	}
+	if wait := record.LastFailureAt.Add(l.backoff(record.Failures)).Sub(now); wait > 0 {
+		return wait, false, nil
+	}
+	return 0, false, nil
+}
+
+// Attempt checks the key like Check and, when it may go ahead, counts the
+// attempt as a failure straight away, so that concurrent attempts are held back
+// by it instead of all passing the same check. Once the outcome is known, call
+// Fail if the attempt failed and Cancel otherwise.
+func (l *Limiter) Attempt(key string, now time.Time) (time.Duration, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	wait, locked, err := l.Check(key, now)
+	if err != nil || wait > 0 {
+		return wait, locked, err
+	}
+	if _, err := l.store.AddFailure(l.prefix+key, now, l.policy.Window); err != nil {
+		return 0, false, err
+	}
+	return 0, false, nil
+}
+
+// Fail settles an attempt that failed, locking the key once its failures reach
+// the threshold. It returns the record and whether this failure locked the key.
+func (l *Limiter) Fail(key string, now time.Time) (Record, bool, error) {
+	record, err := l.store.Get(l.prefix + key)
+	if err != nil {
+		return Record{}, false, err
+	}
+	if l.policy.LockoutThreshold > 0 && record.Failures >= l.policy.LockoutThreshold && !now.Before(record.LockedUntil) {
+		record.LockedUntil = now.Add(l.policy.LockoutDuration)
+		if err := l.store.Lock(l.prefix+key, record.LockedUntil); err != nil {
+			return Record{}, false, err
+		}
+		return record, true, nil
+	}
+	return record, false, nil
+}
+
+// Cancel takes back the failure counted by Attempt, for an attempt that
+// succeeded or was turned down before its credentials were checked
+func (l *Limiter) Cancel(key string) error {
+	return l.store.RemoveFailure(l.prefix + key)
+}
+
+// Reset forgets the key's failures and lifts any lockout
+func (l *Limiter) Reset(key string) error {
+	return l.store.Delete(l.prefix + key)
+}
+
+// backoff returns the delay required after the given number of failures
+func (l *Limiter) backoff(failures int) time.Duration {
+	excess := failures - l.policy.FreeAttempts
+	if excess <= 0 {
+		return 0
+	}
+	delay := l.policy.BaseDelay
+	for i := 1; i < excess && delay < l.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.policy.MaxDelay {
+		delay = l.policy.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,174 @@
+package throttle
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:     2,
+	BaseDelay:        time.Second,
+	MaxDelay:         4 * time.Second,
+	LockoutThreshold: 5,
+	LockoutDuration:  time.Minute,
+	Window:           time.Hour,
+}
+
+// failAt counts a failed attempt for the key at now
+func failAt(t *testing.T, l *Limiter, key string, now time.Time) (Record, bool) {
+	t.Helper()
+	wait, _, err := l.Attempt(key, now)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+	record, locked, err := l.Fail(key, now)
+	require.NoError(t, err)
+	return record, locked
+}
+
+func TestBackoff(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), testPolicy, "test:")
+
+	assert.Equal(t, time.Duration(0), l.backoff(0))
+	assert.Equal(t, time.Duration(0), l.backoff(2))
+	assert.Equal(t, time.Second, l.backoff(3))
+	assert.Equal(t, 2*time.Second, l.backoff(4))
+	assert.Equal(t, 4*time.Second, l.backoff(5))
+	assert.Equal(t, 4*time.Second, l.backoff(50))
+}
+
+func TestLimiterBacksOffThenLocksOut(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), testPolicy, "test:")
+	now := time.Now()
+
+	// The free attempts go through without a delay
+	for i := 0; i < testPolicy.FreeAttempts; i++ {
+		failAt(t, l, "key", now)
+	}
+	wait, locked, err := l.Check("key", now)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	assert.False(t, locked)
+
+	// Then each failure must wait out a growing delay
+	failAt(t, l, "key", now)
+	wait, locked, err = l.Check("key", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, wait)
+	assert.False(t, locked)
+
+	now = now.Add(time.Second)
+	failAt(t, l, "key", now)
+	now = now.Add(2 * time.Second)
+	record, locked := failAt(t, l, "key", now)
+	assert.Equal(t, testPolicy.LockoutThreshold, record.Failures)
+	assert.True(t, locked)
+
+	wait, locked, err = l.Attempt("key", now)
+	require.NoError(t, err)
+	assert.Equal(t, testPolicy.LockoutDuration, wait)
+	assert.True(t, locked)
+
+	// A failure after the lockout ends locks the key again
+	now = now.Add(testPolicy.LockoutDuration)
+	record, locked = failAt(t, l, "key", now)
+	assert.Equal(t, testPolicy.LockoutThreshold+1, record.Failures)
+	assert.True(t, locked)
+}
+
+func TestLimiterForgetsOldFailures(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), testPolicy, "test:")
+	now := time.Now()
+
+	for i := 0; i < testPolicy.FreeAttempts+1; i++ {
+		failAt(t, l, "key", now)
+	}
+	now = now.Add(testPolicy.Window + time.Second)
+	wait, _, err := l.Check("key", now)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	record, _ := failAt(t, l, "key", now)
+	assert.Equal(t, 1, record.Failures)
+}
+
+func TestMemoryStoreSweepsExpiredRecordsPeriodically(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	_, err := store.AddFailure("old", now, time.Second)
+	require.NoError(t, err)
+	// Within the sweep interval expired records are left alone
+	_, err = store.AddFailure("new", now.Add(2*time.Second), time.Second)
+	require.NoError(t, err)
+	assert.Len(t, store.records, 2)
+
+	_, err = store.AddFailure("new", now.Add(sweepInterval+time.Second), time.Second)
+	require.NoError(t, err)
+	assert.Len(t, store.records, 1)
+	assert.Contains(t, store.records, "new")
+}
+
+func TestLimiterCancelAndReset(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), testPolicy, "test:")
+	now := time.Now()
+
+	failAt(t, l, "key", now)
+	_, _, err := l.Attempt("key", now)
+	require.NoError(t, err)
+	require.NoError(t, l.Cancel("key"))
+	record, _, err := l.Fail("key", now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.Failures)
+
+	// Keys are independent, and Reset forgets everything
+	wait, _, err := l.Check("other", now)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	require.NoError(t, l.Reset("key"))
+	record, _, err = l.Fail("key", now)
+	require.NoError(t, err)
+	assert.Zero(t, record.Failures)
+}
+
+func TestLimiterPrefixesKeys(t *testing.T) {
+	store := NewMemoryStore()
+	accounts := NewLimiter(store, testPolicy, "account:")
+	ips := NewLimiter(store, testPolicy, "ip:")
+	now := time.Now()
+
+	for i := 0; i < testPolicy.LockoutThreshold; i++ {
+		failAt(t, accounts, "shared", now.Add(time.Duration(i)*testPolicy.MaxDelay))
+	}
+	wait, _, err := ips.Check("shared", now)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func TestConcurrentAttemptsAreThrottled(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), testPolicy, "test:")
+	now := time.Now()
+
+	// Attempts that are still running count, so only the free attempts and
+	// the first delayed one can start at once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, _, err := l.Attempt("key", now)
+			if err == nil && wait == 0 {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, testPolicy.FreeAttempts+1, started)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/epsierra/phinex-blog-api/src/middlewares"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -23,7 +24,7 @@ func NewUsersController(service *UsersService) *UsersController {
 // RegisterRoutes registers the user-related routes to the Fiber app
 func (c *UsersController) RegisterRoutes(app *fiber.App) {
 	// Guard
-	app.Use("/users/*", middlewares.AuthenticatedGuard(c.service.db))
+	app.Use("/users/*", middlewares.RequireAuthenticated())
 
 	app.Post("/users", c.CreateUser)
 	app.Get("/users", c.FindAllUsers)
@@ -109,7 +110,7 @@ func (c *UsersController) FindUserById(ctx *fiber.Ctx) error {
 }
 
 // @Summary Update a user
-// @Description Update a user's details by their ID
+// @Description Update a user's details by their ID. Users can only update their own account; admins can update any.
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -117,6 +118,7 @@ func (c *UsersController) FindUserById(ctx *fiber.Ctx) error {
 // @Param user body UpdateUserDto true "User object with updated fields"
 // @Success 202 {object} UserResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/{userId} [put]
@@ -219,6 +221,8 @@ func (c *UsersController) FindUsersNotFollowing(ctx *fiber.Ctx) error {
 // @Param userId path string true "User ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the users after it instead of a page. Not supported with search."
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Param search query string false "Search term to filter users by full name, email, username, or bio"
 // @Success 200 {object} models.PaginatedResponse
 // @Failure 400 {object} models.ErrorResponse
@@ -227,12 +231,14 @@ func (c *UsersController) FindUsersNotFollowing(ctx *fiber.Ctx) error {
 // @Security ApiKeyAuth
 func (c *UsersController) FindUserFollowers(ctx *fiber.Ctx) error {
 	userId := ctx.Params("userId")
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 	search := ctx.Query("search", "")
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
 
-	users, err := c.service.FindUserFollowers(userId, page, limit, search, currentUser)
+	users, err := c.service.FindUserFollowers(userId, params, search, currentUser)
 	if err != nil {
 		return err
 	}
@@ -247,6 +253,8 @@ func (c *UsersController) FindUserFollowers(ctx *fiber.Ctx) error {
 // @Param userId path string true "User ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor from metadata.nextCursor; returns the users after it instead of a page. Not supported with search."
+// @Param count query bool false "Whether to count the total items; defaults to true for pages and false for cursors"
 // @Param search query string false "Search term to filter users by full name, email, username, or bio"
 // @Success 200 {object} models.PaginatedResponse
 // @Failure 400 {object} models.ErrorResponse
@@ -255,12 +263,14 @@ func (c *UsersController) FindUserFollowers(ctx *fiber.Ctx) error {
 // @Security ApiKeyAuth
 func (c *UsersController) FindUserFollowings(ctx *fiber.Ctx) error {
 	userId := ctx.Params("userId")
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	params, err := pagination.FromQuery(ctx, 10)
+	if err != nil {
+		return err
+	}
 	search := ctx.Query("search", "")
 	currentUser := ctx.Locals("user").(models.ICurrentUser)
 
-	users, err := c.service.FindUserFollowings(userId, page, limit, search, currentUser)
+	users, err := c.service.FindUserFollowings(userId, params, search, currentUser)
 	if err != nil {
 		return err
 	}
@@ -15,7 +15,6 @@ type UpdateUserDto struct {
 	Gender    string `json:"gender,omitempty" example:"Male"`
 	Dob       string `json:"dob,omitempty" example:"1990-01-01"`
 	Email     string `json:"email" validate:"email" example:"john.doe@example.com"`
-	Password  string `json:"password" validate:"min=6" example:"newpassword123"`
 }
 
 // UserResponse represents the response for user operations
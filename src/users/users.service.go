@@ -9,7 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/middlewares"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
+	"github.com/epsierra/phinex-blog-api/src/policies"
+	"github.com/epsierra/phinex-blog-api/src/search"
 	"github.com/epsierra/phinex-blog-api/src/utils"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -18,15 +23,17 @@ import (
 
 // UsersService handles user-related operations
 type UsersService struct {
-	db     *gorm.DB
-	logger *log.Logger
+	db          *gorm.DB
+	logger      *log.Logger
+	authService *auth.AuthService
 }
 
 // NewUsersService creates a new UsersService instance
-func NewUsersService(db *gorm.DB) *UsersService {
+func NewUsersService(db *gorm.DB, authService *auth.AuthService) *UsersService {
 	return &UsersService{
-		db:     db,
-		logger: log.New(os.Stderr, "users-service: ", log.LstdFlags),
+		db:          db,
+		logger:      log.New(os.Stderr, "users-service: ", log.LstdFlags),
+		authService: authService,
 	}
 }
 
@@ -61,6 +68,11 @@ func (s *UsersService) CreateUser(dto CreateUserDto, currentUser models.ICurrent
 		return UserResponse{}, err
 	}
 
+	// A failed email is not fatal; the user can ask for it to be resent
+	if err := s.authService.SendEmailVerification(user); err != nil {
+		s.logger.Printf("Error sending verification email: %v", err)
+	}
+
 	return UserResponse{
 		Message: "User created successfully",
 		Data:    user,
@@ -84,7 +96,7 @@ func (s *UsersService) createUserStats(userId string, currentUser models.ICurren
 }
 
 // FindAllUsers retrieves all users with pagination and optional search, ordered randomly with seed
-func (s *UsersService) FindAllUsers(page, limit int, search string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+func (s *UsersService) FindAllUsers(page, limit int, searchText string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
 	// Set default values for page and limit if not provided or invalid
 	if page < 1 {
 		page = 1
@@ -98,22 +110,21 @@ func (s *UsersService) FindAllUsers(page, limit int, search string, currentUser
 
 	var totalItems int64
 	query := s.db.Model(&models.User{})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("full_name LIKE ? OR email LIKE ? OR user_name LIKE ? OR bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	searching := isSearch(searchText)
+	if searching {
+		query = query.Scopes(search.MatchUsers(searchText))
 	}
 	query.Count(&totalItems)
 
 	offset := (page - 1) * limit
+	// Best matches first when searching, otherwise a random selection
+	if searching {
+		query = query.Order(search.ByUserRank(searchText))
+	} else {
+		query = query.Order("RANDOM()")
+	}
 	var users []models.User
-	if err := query.Order("RANDOM()").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+	if err := query.Limit(limit).Offset(offset).Find(&users).Error; err != nil {
 		s.logger.Printf("Error fetching users: %v", err)
 		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch users"}
 	}
@@ -172,6 +183,9 @@ func (s *UsersService) UpdateUser(userId string, dto UpdateUserDto, currentUser
 		s.logger.Printf("Error fetching user for update: %v", err)
 		return UserResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to update user"}
 	}
+	if err := policies.CanUpdateUser(currentUser, user); err != nil {
+		return UserResponse{}, err
+	}
 
 	updateData := map[string]interface{}{
 		"updated_at": time.Now().UTC(),
@@ -181,22 +195,27 @@ func (s *UsersService) UpdateUser(userId string, dto UpdateUserDto, currentUser
 	if dto.FullName != "" {
 		updateData["full_name"] = dto.FullName
 	}
-	if dto.Email != "" {
+	// A new address is unverified until its owner confirms it, so it cannot
+	// receive password resets for the account before then
+	emailChanged := dto.Email != "" && dto.Email != user.Email
+	if emailChanged {
 		updateData["email"] = dto.Email
-	}
-	if dto.Password != "" {
-		hashedPassword, err := utils.HashData(dto.Password)
-		if err != nil {
-			s.logger.Printf("Error hashing password for update: %v", err)
-			return UserResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to hash password"}
-		}
-		updateData["password"] = hashedPassword
+		updateData["verified"] = false
+		updateData["email_is_verified"] = false
 	}
 
 	if err := s.db.Model(&user).Updates(updateData).Error; err != nil {
 		s.logger.Printf("Error updating user: %v", err)
 		return UserResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to update user"}
 	}
+	middlewares.InvalidateUser(userId)
+
+	if emailChanged {
+		// A failed email is not fatal; the user can ask for it to be resent
+		if err := s.authService.SendEmailVerification(user); err != nil {
+			s.logger.Printf("Error sending verification email: %v", err)
+		}
+	}
 
 	user.Password = ""
 	return UserResponse{
@@ -220,6 +239,7 @@ func (s *UsersService) DeleteUser(userId string, currentUser models.ICurrentUser
 		s.logger.Printf("Error deleting user: %v", err)
 		return UserResponse{}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to delete user"}
 	}
+	middlewares.InvalidateUser(userId)
 
 	return UserResponse{
 		Message: "User deleted successfully",
@@ -285,7 +305,7 @@ func (s *UsersService) FollowUnfollowUser(dto FollowUnfollowDto, currentUser mod
 }
 
 // FindUsersNotFollowing retrieves users who are not followed by a specific user, ordered randomly with seed
-func (s *UsersService) FindUsersNotFollowing(userId string, page, limit int, search string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+func (s *UsersService) FindUsersNotFollowing(userId string, page, limit int, searchText string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
 	// Set default values for page and limit if not provided or invalid
 	if page < 1 {
 		page = 1
@@ -299,16 +319,9 @@ func (s *UsersService) FindUsersNotFollowing(userId string, page, limit int, sea
 
 	var totalItems int64
 	query := s.db.Model(&models.User{})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("full_name LIKE ? OR email LIKE ? OR user_name LIKE ? OR bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	searching := isSearch(searchText)
+	if searching {
+		query = query.Scopes(search.MatchUsers(searchText))
 	}
 	query.Count(&totalItems)
 
@@ -318,18 +331,12 @@ func (s *UsersService) FindUsersNotFollowing(userId string, page, limit int, sea
 
 	var users []models.User
 	query = s.db.Clauses(clause.Where{Exprs: []clause.Expression{clause.Not(clause.IN{Column: "user_id", Values: ids})}})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("full_name LIKE ? OR email LIKE ? OR user_name LIKE ? OR bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	if searching {
+		query = query.Scopes(search.MatchUsers(searchText)).Order(search.ByUserRank(searchText))
+	} else {
+		query = query.Order("RANDOM()")
 	}
-	err := query.Order("RANDOM()").Limit(limit).Offset(offset).Find(&users).Error
+	err := query.Limit(limit).Offset(offset).Find(&users).Error
 
 	if err != nil {
 		s.logger.Printf("Error fetching users not following: %v", err)
@@ -356,54 +363,31 @@ func (s *UsersService) FindUsersNotFollowing(userId string, page, limit int, sea
 	}, nil
 }
 
-// FindUserFollowers retrieves the followers of a specific user, ordered randomly with seed
-func (s *UsersService) FindUserFollowers(userId string, page, limit int, search string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
-	// Set default values for page and limit if not provided or invalid
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 20
+// FindUserFollowers retrieves a user's followers, most recent first, or by
+// relevance when searching
+func (s *UsersService) FindUserFollowers(userId string, params pagination.Params, searchText string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+	if isSearch(searchText) {
+		return s.searchFollows(models.Follow{FollowingId: userId}, "follower_id", params, searchText, currentUser)
 	}
 
-	// Set random seed based on current time for reproducible results
-	rand.Seed(time.Now().UnixNano())
-
 	var totalItems int64
-	query := s.db.Model(&models.Follow{}).Where(&models.Follow{FollowingId: userId})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		query = query.Joins("JOIN users ON users.user_id = follows.follower_id")
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("users.full_name LIKE ? OR users.email LIKE ? OR users.user_name LIKE ? OR users.bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	if params.Count {
+		s.db.Model(&models.Follow{}).Where(&models.Follow{FollowingId: userId}).Count(&totalItems)
 	}
-	query.Count(&totalItems)
-
-	offset := (page - 1) * limit
-	var followerIds []interface{}
-	s.db.Model(&models.Follow{}).Where(&models.Follow{FollowingId: userId}).Pluck("follower_id", &followerIds)
 
-	var users []models.User
-	query = s.db.Clauses(clause.Where{Exprs: []clause.Expression{clause.IN{Column: "user_id", Values: followerIds}}})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("full_name LIKE ? OR email LIKE ? OR user_name LIKE ? OR bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	var follows []models.Follow
+	err := s.db.Where(&models.Follow{FollowingId: userId}).Scopes(params.Keyset("follows.created_at", "follows.follow_id")).Find(&follows).Error
+	if err != nil {
+		s.logger.Printf("Error fetching user followers: %v", err)
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch followers"}
 	}
-	err := query.Order("RANDOM()").Limit(limit).Offset(offset).Find(&users).Error
+	follows, metadata := pagination.Trim(params, follows, totalItems, followCursor)
 
+	userIds := make([]string, len(follows))
+	for i, follow := range follows {
+		userIds[i] = follow.FollowerId
+	}
+	users, err := s.findUsersInOrder(userIds)
 	if err != nil {
 		s.logger.Printf("Error fetching user followers: %v", err)
 		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch followers"}
@@ -414,69 +398,37 @@ func (s *UsersService) FindUserFollowers(userId string, page, limit int, search
 		return models.PaginatedResponse{Data: []models.User{}}, err
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
 	return models.PaginatedResponse{
-		Data: enrichedUsers,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     enrichedUsers,
+		Metadata: metadata,
 	}, nil
 }
 
-// FindUserFollowings retrieves a user's followings, ordered randomly with seed
-func (s *UsersService) FindUserFollowings(userId string, page, limit int, search string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
-	// Set default values for page and limit if not provided or invalid
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 20
+// FindUserFollowings retrieves the users a user follows, most recently followed
+// first, or by relevance when searching
+func (s *UsersService) FindUserFollowings(userId string, params pagination.Params, searchText string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+	if isSearch(searchText) {
+		return s.searchFollows(models.Follow{FollowerId: userId}, "following_id", params, searchText, currentUser)
 	}
 
-	// Set random seed based on current time for reproducible results
-	rand.Seed(time.Now().UnixNano())
-
 	var totalItems int64
-	query := s.db.Model(&models.Follow{}).Where(&models.Follow{FollowerId: userId})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		query = query.Joins("JOIN users ON users.user_id = follows.following_id")
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("users.full_name LIKE ? OR users.email LIKE ? OR users.user_name LIKE ? OR users.bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	if params.Count {
+		s.db.Model(&models.Follow{}).Where(&models.Follow{FollowerId: userId}).Count(&totalItems)
 	}
-	query.Count(&totalItems)
 
-	offset := (page - 1) * limit
-	var followingIds []interface{}
-	s.db.Model(&models.Follow{}).Where(&models.Follow{FollowerId: userId}).Pluck("following_id", &followingIds)
-
-	var users []models.User
-	query = s.db.Clauses(clause.Where{Exprs: []clause.Expression{clause.IN{Column: "user_id", Values: followingIds}}})
-	if search != "" && len(search) <= 100 && search != "undefined" {
-		// Normalize search term: trim and split into words
-		searchTerms := strings.Fields(strings.TrimSpace(search))
-		for _, term := range searchTerms {
-			if term != "" {
-				searchPattern := "%" + term + "%"
-				query = query.Where("full_name LIKE ? OR email LIKE ? OR user_name LIKE ? OR bio LIKE ?",
-					searchPattern, searchPattern, searchPattern, searchPattern)
-			}
-		}
+	var follows []models.Follow
+	err := s.db.Where(&models.Follow{FollowerId: userId}).Scopes(params.Keyset("follows.created_at", "follows.follow_id")).Find(&follows).Error
+	if err != nil {
+		s.logger.Printf("Error fetching user followings: %v", err)
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch followings"}
 	}
-	err := query.Order("RANDOM()").Limit(limit).Offset(offset).Find(&users).Error
+	follows, metadata := pagination.Trim(params, follows, totalItems, followCursor)
 
+	userIds := make([]string, len(follows))
+	for i, follow := range follows {
+		userIds[i] = follow.FollowingId
+	}
+	users, err := s.findUsersInOrder(userIds)
 	if err != nil {
 		s.logger.Printf("Error fetching user followings: %v", err)
 		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to fetch followings"}
@@ -487,21 +439,73 @@ func (s *UsersService) FindUserFollowings(userId string, page, limit int, search
 		return models.PaginatedResponse{Data: []models.User{}}, err
 	}
 
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	return models.PaginatedResponse{
+		Data:     enrichedUsers,
+		Metadata: metadata,
+	}, nil
+}
+
+// searchFollows pages the users on the other side of a user's follows that
+// match a search, best match first. Relevance has no stable position to
+// resume from, so search results are paged by number only.
+func (s *UsersService) searchFollows(follows models.Follow, otherColumn string, params pagination.Params, searchText string, currentUser models.ICurrentUser) (models.PaginatedResponse, error) {
+	if params.After != nil {
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusBadRequest, Message: "Search results cannot be paged by cursor"}
+	}
+	followedIds := s.db.Model(&models.Follow{}).Select(otherColumn).Where(&follows)
+
+	var totalItems int64
+	if params.Count {
+		s.db.Model(&models.User{}).Where("user_id IN (?)", followedIds).Scopes(search.MatchUsers(searchText)).Count(&totalItems)
+	}
+
+	var users []models.User
+	err := s.db.Where("user_id IN (?)", followedIds).Scopes(search.MatchUsers(searchText)).Order(search.ByUserRank(searchText)).
+		Scopes(params.Numbered).Find(&users).Error
+	if err != nil {
+		s.logger.Printf("Error searching follows: %v", err)
+		return models.PaginatedResponse{Data: []models.User{}}, &fiber.Error{Code: fiber.StatusInternalServerError, Message: "Failed to search users"}
+	}
+	users, metadata := pagination.Trim(params, users, totalItems, nil)
+
+	enrichedUsers, err := s.enrichUsersWithFollowing(users, currentUser)
+	if err != nil {
+		return models.PaginatedResponse{Data: []models.User{}}, err
+	}
 
 	return models.PaginatedResponse{
-		Data: enrichedUsers,
-		Metadata: models.PaginationMetadata{
-			CurrentPage:     int64(page),
-			ItemsPerPage:    int64(limit),
-			TotalItems:      totalItems,
-			TotalPages:      totalPages,
-			HasNextPage:     int64(page) < totalPages,
-			HasPreviousPage: int64(page) > 1,
-		},
+		Data:     enrichedUsers,
+		Metadata: metadata,
 	}, nil
 }
 
+// findUsersInOrder loads users by ID, keeping the order of the IDs
+func (s *UsersService) findUsersInOrder(userIds []string) ([]models.User, error) {
+	if len(userIds) == 0 {
+		return []models.User{}, nil
+	}
+	var found []models.User
+	if err := s.db.Where("user_id IN ?", userIds).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byId := make(map[string]models.User, len(found))
+	for _, user := range found {
+		byId[user.UserId] = user
+	}
+	users := make([]models.User, 0, len(userIds))
+	for _, userId := range userIds {
+		if user, ok := byId[userId]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// followCursor is a follow's position in a list ordered by when it was made
+func followCursor(follow models.Follow) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: follow.CreatedAt, Id: follow.FollowId}
+}
+
 // enrichUsersWithFollowing enriches users with following status
 func (s *UsersService) enrichUsersWithFollowing(users []models.User, currentUser models.ICurrentUser) ([]models.User, error) {
 	if !currentUser.IsAuthenticated {
@@ -520,3 +524,9 @@ func (s *UsersService) enrichUsersWithFollowing(users []models.User, currentUser
 	}
 	return enrichedUsers, nil
 }
+
+// isSearch reports whether a user list request carries a usable search term
+func isSearch(searchText string) bool {
+	searchText = strings.TrimSpace(searchText)
+	return searchText != "" && len(searchText) <= 100 && searchText != "undefined"
+}
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingKey is one JWT key, identified by the "kid" header of the tokens it signs.
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// keyRing holds the active signing key and every key tokens may still be verified with.
+type keyRing struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+var (
+	signingKeysOnce sync.Once
+	signingKeysErr  error
+	signingKeys     *keyRing
+)
+
+// LoadSigningKeys loads the JWT keys from the environment. It is safe to call
+// repeatedly; the configuration is read once and any error is returned each time.
+//
+//	JWT_ALGORITHM        HS256 (default), RS256 or EdDSA
+//	JWT_KEY_ID           kid of the active key (default "default")
+//	JWT_SECRET           HS256 signing secret
+//	JWT_PRIVATE_KEY_FILE PEM private key for RS256/EdDSA
+//	JWT_PREVIOUS_SECRETS retired HS256 keys still accepted, as "kid:secret,..."
+//	JWT_PREVIOUS_KEYS    retired RS256/EdDSA keys still accepted, as "kid:/path/key.pem,..."
+func LoadSigningKeys() error {
+	signingKeysOnce.Do(func() {
+		signingKeys, signingKeysErr = loadKeyRing()
+	})
+	return signingKeysErr
+}
+
+func loadKeyRing() (*keyRing, error) {
+	ring := &keyRing{keys: map[string]*signingKey{}}
+
+	kid := os.Getenv("JWT_KEY_ID")
+	if kid == "" {
+		kid = "default"
+	}
+
+	var active *signingKey
+	var err error
+	switch algorithm := strings.ToUpper(os.Getenv("JWT_ALGORITHM")); algorithm {
+	case "", "HS256":
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET environment variable not set")
+		}
+		active = hmacKey(kid, secret)
+	case "RS256", "EDDSA":
+		path := os.Getenv("JWT_PRIVATE_KEY_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE environment variable not set for %s", algorithm)
+		}
+		active, err = pemKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		if active.signKey == nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE %s does not contain a private key", path)
+		}
+		if !strings.EqualFold(active.method.Alg(), algorithm) {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE %s is not a %s key", path, algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", algorithm)
+	}
+	ring.active = active
+
+	for _, entry := range splitKeyList(os.Getenv("JWT_PREVIOUS_SECRETS")) {
+		ring.keys[entry[0]] = hmacKey(entry[0], entry[1])
+	}
+	for _, entry := range splitKeyList(os.Getenv("JWT_PREVIOUS_KEYS")) {
+		key, err := pemKey(entry[0], entry[1])
+		if err != nil {
+			return nil, err
+		}
+		// Retired keys only verify
+		key.signKey = nil
+		ring.keys[entry[0]] = key
+	}
+	// The active key must not be shadowed by a retired key with the same kid
+	ring.keys[active.kid] = active
+
+	return ring, nil
+}
+
+func hmacKey(kid, secret string) *signingKey {
+	return &signingKey{
+		kid:       kid,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// pemKey reads an RSA or Ed25519 key from a PEM file. Private keys can sign and
+// verify; public keys can only verify.
+func pemKey(kid, path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWT key %s: %w", kid, err)
+	}
+
+	if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: &privateKey.PublicKey}, nil
+	}
+	if publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, verifyKey: publicKey}, nil
+	}
+	if privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		edKey := privateKey.(ed25519.PrivateKey)
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()}, nil
+	}
+	if publicKey, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, verifyKey: publicKey}, nil
+	}
+	return nil, fmt.Errorf("JWT key %s in %s is not an RSA or Ed25519 PEM key", kid, path)
+}
+
+// splitKeyList parses "kid:value,kid:value" into pairs, skipping malformed entries.
+func splitKeyList(value string) [][2]string {
+	var entries [][2]string
+	for _, item := range strings.Split(value, ",") {
+		kid, keyValue, found := strings.Cut(strings.TrimSpace(item), ":")
+		if !found || kid == "" || keyValue == "" {
+			continue
+		}
+		entries = append(entries, [2]string{kid, keyValue})
+	}
+	return entries
+}
+
+// loadedKeyRing returns the key ring, loading it on first use.
+func loadedKeyRing() (*keyRing, error) {
+	if err := LoadSigningKeys(); err != nil {
+		return nil, err
+	}
+	return signingKeys, nil
+}
+
+// lookupVerifyKey resolves the key for a token being parsed from its "kid"
+// header. Tokens without a kid are checked against the active key.
+func lookupVerifyKey(token *jwt.Token) (interface{}, error) {
+	ring, err := loadedKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	key := ring.active
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = ring.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+	// Ensure that the method used for signing is the one this key is for
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// PublicJWKS returns the public halves of all asymmetric keys, for publishing as
+// a JWKS. HS256 secrets are never included.
+func PublicJWKS() ([]JWK, error) {
+	ring, err := loadedKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := []JWK{}
+	for _, kid := range kids {
+		key := ring.keys[kid]
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	return jwks, nil
+}
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var signingEnv = []string{"JWT_ALGORITHM", "JWT_KEY_ID", "JWT_SECRET", "JWT_PRIVATE_KEY_FILE", "JWT_PREVIOUS_SECRETS", "JWT_PREVIOUS_KEYS"}
+
+// loadKeyRingFrom loads a key ring from the given signing settings only
+func loadKeyRingFrom(t *testing.T, env map[string]string) (*keyRing, error) {
+	t.Helper()
+	for _, key := range signingEnv {
+		t.Setenv(key, env[key])
+	}
+	return loadKeyRing()
+}
+
+// useSigningKeys makes JwtEncode and JwtDecode use the given signing settings
+// for the rest of the test
+func useSigningKeys(t *testing.T, env map[string]string) {
+	t.Helper()
+	ring, err := loadKeyRingFrom(t, env)
+	require.NoError(t, err)
+
+	signingKeysOnce.Do(func() {})
+	previous, previousErr := signingKeys, signingKeysErr
+	signingKeys, signingKeysErr = ring, nil
+	t.Cleanup(func() { signingKeys, signingKeysErr = previous, previousErr })
+}
+
+// writePEM writes a PEM block to a temporary file and returns its path
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// rsaKeyFiles writes a new RSA key pair and returns the private and public key files
+func rsaKeyFiles(t *testing.T) (string, string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)), writePEM(t, "PUBLIC KEY", public), key
+}
+
+// ed25519KeyFiles writes a new Ed25519 key pair and returns the private and public key files
+func ed25519KeyFiles(t *testing.T) (string, string, ed25519.PublicKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	private, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	public, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return writePEM(t, "PRIVATE KEY", private), writePEM(t, "PUBLIC KEY", public), publicKey
+}
+
+// encodeTestToken signs an access token for a fixed user with the active key
+func encodeTestToken(t *testing.T) string {
+	t.Helper()
+	token, err := JwtEncode(map[string]interface{}{"userId": "user-1", "typ": TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+	return token
+}
+
+// tokenKid returns the "kid" header of a token without verifying it
+func tokenKid(t *testing.T, token string) interface{} {
+	t.Helper()
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	return parsed.Header["kid"]
+}
+
+func TestHS256SecretRotation(t *testing.T) {
+	useSigningKeys(t, map[string]string{"JWT_KEY_ID": "2024-01", "JWT_SECRET": "old-secret"})
+	oldToken := encodeTestToken(t)
+	assert.Equal(t, "2024-01", tokenKid(t, oldToken))
+
+	// After the rotation new tokens name the new key, and old ones still verify
+	useSigningKeys(t, map[string]string{"JWT_KEY_ID": "2024-06", "JWT_SECRET": "new-secret", "JWT_PREVIOUS_SECRETS": "2024-01:old-secret"})
+	newToken := encodeTestToken(t)
+	assert.Equal(t, "2024-06", tokenKid(t, newToken))
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := JwtDecode(token)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims["userId"])
+	}
+
+	// Once the old secret is retired, its tokens are refused
+	useSigningKeys(t, map[string]string{"JWT_KEY_ID": "2024-06", "JWT_SECRET": "new-secret"})
+	_, err := JwtDecode(oldToken)
+	assert.Error(t, err)
+	_, err = JwtDecode(newToken)
+	assert.NoError(t, err)
+}
+
+func TestVerifyKeySelection(t *testing.T) {
+	useSigningKeys(t, map[string]string{"JWT_SECRET": "secret"})
+	sign := func(kid string, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userId": "user-1"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(secret))
+		require.NoError(t, err)
+		return signed
+	}
+
+	// Tokens from before kids existed are checked against the active key
+	_, err := JwtDecode(sign("", "secret"))
+	assert.NoError(t, err)
+	_, err = JwtDecode(sign("default", "secret"))
+	assert.NoError(t, err)
+
+	_, err = JwtDecode(sign("unknown", "secret"))
+	assert.ErrorContains(t, err, `unknown signing key "unknown"`)
+	_, err = JwtDecode(sign("default", "another-secret"))
+	assert.Error(t, err)
+}
+
+func TestRS256Keys(t *testing.T) {
+	oldPrivate, oldPublic, oldKey := rsaKeyFiles(t)
+	useSigningKeys(t, map[string]string{"JWT_ALGORITHM": "RS256", "JWT_KEY_ID": "rsa-1", "JWT_PRIVATE_KEY_FILE": oldPrivate})
+	oldToken := encodeTestToken(t)
+
+	newPrivate, _, _ := rsaKeyFiles(t)
+	useSigningKeys(t, map[string]string{"JWT_ALGORITHM": "RS256", "JWT_KEY_ID": "rsa-2", "JWT_PRIVATE_KEY_FILE": newPrivate, "JWT_PREVIOUS_KEYS": "rsa-1:" + oldPublic})
+	_, err := JwtDecode(oldToken)
+	assert.NoError(t, err)
+	_, err = JwtDecode(encodeTestToken(t))
+	assert.NoError(t, err)
+	assert.Nil(t, signingKeys.keys["rsa-1"].signKey, "retired keys must not sign")
+
+	// An HS256 token keyed with the public key is refused, not verified with it
+	publicPEM, err := os.ReadFile(oldPublic)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userId": "admin"})
+	forged.Header["kid"] = "rsa-1"
+	forgedToken, err := forged.SignedString(publicPEM)
+	require.NoError(t, err)
+	_, err = JwtDecode(forgedToken)
+	assert.ErrorContains(t, err, "unexpected signing method")
+
+	// Both public keys are published, in kid order
+	jwks, err := PublicJWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks, 2)
+	assert.Equal(t, []string{"rsa-1", "rsa-2"}, []string{jwks[0].Kid, jwks[1].Kid})
+	assert.Equal(t, "RSA", jwks[0].Kty)
+	assert.Equal(t, "RS256", jwks[0].Alg)
+	assert.Equal(t, "sig", jwks[0].Use)
+	n, err := base64.RawURLEncoding.DecodeString(jwks[0].N)
+	require.NoError(t, err)
+	assert.Zero(t, new(big.Int).SetBytes(n).Cmp(oldKey.N))
+	assert.Equal(t, "AQAB", jwks[0].E)
+}
+
+func TestEdDSAKeys(t *testing.T) {
+	private, _, publicKey := ed25519KeyFiles(t)
+	useSigningKeys(t, map[string]string{"JWT_ALGORITHM": "EdDSA", "JWT_KEY_ID": "ed-1", "JWT_PRIVATE_KEY_FILE": private})
+
+	claims, err := JwtDecode(encodeTestToken(t))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims["userId"])
+
+	jwks, err := PublicJWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks, 1)
+	assert.Equal(t, JWK{
+		Kty: "OKP",
+		Kid: "ed-1",
+		Use: "sig",
+		Alg: "EdDSA",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(publicKey),
+	}, jwks[0])
+}
+
+func TestHS256SecretsAreNotPublished(t *testing.T) {
+	useSigningKeys(t, map[string]string{"JWT_SECRET": "secret", "JWT_PREVIOUS_SECRETS": "old:old-secret"})
+	jwks, err := PublicJWKS()
+	require.NoError(t, err)
+	assert.Empty(t, jwks)
+}
+
+func TestSigningKeyConfigErrors(t *testing.T) {
+	rsaPrivate, rsaPublic, _ := rsaKeyFiles(t)
+	edPrivate, _, _ := ed25519KeyFiles(t)
+
+	for name, env := range map[string]map[string]string{
+		"missing secret":         {},
+		"unsupported algorithm":  {"JWT_ALGORITHM": "HS512", "JWT_SECRET": "secret"},
+		"missing key file":       {"JWT_ALGORITHM": "RS256"},
+		"unreadable key file":    {"JWT_ALGORITHM": "RS256", "JWT_PRIVATE_KEY_FILE": filepath.Join(t.TempDir(), "missing.pem")},
+		"public key only":        {"JWT_ALGORITHM": "RS256", "JWT_PRIVATE_KEY_FILE": rsaPublic},
+		"algorithm mismatch":     {"JWT_ALGORITHM": "RS256", "JWT_PRIVATE_KEY_FILE": edPrivate},
+		"bad previous key":       {"JWT_SECRET": "secret", "JWT_PREVIOUS_KEYS": "old:" + filepath.Join(t.TempDir(), "missing.pem")},
+		"eddsa with an rsa file": {"JWT_ALGORITHM": "EdDSA", "JWT_PRIVATE_KEY_FILE": rsaPrivate},
+	} {
+		_, err := loadKeyRingFrom(t, env)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSplitKeyList(t *testing.T) {
+	assert.Equal(t, [][2]string{{"a", "one"}, {"b", "two:three"}}, splitKeyList(" a:one, :skipped,missing,b:two:three,c:"))
+	assert.Empty(t, splitKeyList(""))
+}
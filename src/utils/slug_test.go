@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	for title, slug := range map[string]string{
+		"Hello World":             "hello-world",
+		"  Hello,   World!  ":     "hello-world",
+		"Crème Brûlée & Friends":  "creme-brulee-and-friends",
+		"Don't Stop Me Now":       "dont-stop-me-now",
+		"It’s 2024":               "its-2024",
+		"Straße über Ærø":         "strasse-uber-aero",
+		"Łódź":                    "lodz",
+		"Привет мир":              "privet-mir",
+		"Αθήνα":                   "athina",
+		"ﬁne ＡＢＣ":                 "fine-abc",
+		"C++ / Go -- Rust":        "c-go-rust",
+		"東京タワー":                   "",
+		"---":                     "",
+		"":                        "",
+		"Go 東京 Tips":              "go-tips",
+		"Hello\u200b\tWorld\nNow": "hello-world-now",
+	} {
+		assert.Equal(t, slug, Slugify(title), title)
+	}
+}
+
+func TestSlugifyTruncatesAtWordBoundary(t *testing.T) {
+	slug := Slugify(strings.Repeat("word ", 30))
+	assert.LessOrEqual(t, len(slug), MaxSlugLength)
+	assert.False(t, strings.HasSuffix(slug, "-"))
+	assert.True(t, strings.HasSuffix(slug, "word"))
+
+	// Without a hyphen in the second half, the slug is cut mid-word
+	long := Slugify(strings.Repeat("a", 200))
+	assert.Equal(t, strings.Repeat("a", MaxSlugLength), long)
+}
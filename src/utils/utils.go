@@ -1,9 +1,18 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	_ "github.com/joho/godotenv"
@@ -11,11 +20,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// JWT Secret Key - Assuming it's stored in an environment variable
-var appSecretKey = "phinex"
+// TokenTypeAccess is the "typ" claim of access tokens; guards reject any other token type.
+const TokenTypeAccess = "access"
 
-// JwtEncode generates a JWT token with a payload similar to the TypeScript example
-func JwtEncode(data map[string]interface{}) (string, error) {
+// JwtEncode generates a JWT token with a payload similar to the TypeScript example.
+// Every token gets an issued-at time and a unique ID; a positive ttl also sets an expiry.
+func JwtEncode(data map[string]interface{}, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
 
 	// Creating a new JWT token with the claims
 	claims := jwt.MapClaims{
@@ -23,11 +34,23 @@ func JwtEncode(data map[string]interface{}) (string, error) {
 		"isAuthenticated": data["isAuthenticated"],
 		"email":           data["email"],
 		"roles":           data["roles"],
+		"sid":             data["sid"],
+		"typ":             data["typ"],
+		"jti":             GenerateID(),
+		"iat":             now.Unix(),
+	}
+	if ttl > 0 {
+		claims["exp"] = now.Add(ttl).Unix()
 	}
 
-	// Create token using secret key
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	encodedToken, err := token.SignedString([]byte(appSecretKey))
+	// Create token using the active signing key
+	ring, err := loadedKeyRing()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(ring.active.method, claims)
+	token.Header["kid"] = ring.active.kid
+	encodedToken, err := token.SignedString(ring.active.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -37,15 +60,8 @@ func JwtEncode(data map[string]interface{}) (string, error) {
 
 // JwtDecode decodes the JWT token and returns the claims
 func JwtDecode(tokenString string) (map[string]interface{}, error) {
-	// Parse and validate the token using the secret key
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Ensure that the method used for signing is correct
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		// Return the secret key to validate the token
-		return []byte(appSecretKey), nil
-	})
+	// Parse and validate the token using the key named by its "kid" header
+	token, err := jwt.Parse(tokenString, lookupVerifyKey)
 	if err != nil {
 		fmt.Printf("%v \n", err)
 		return nil, err
@@ -67,6 +83,15 @@ func JwtDecode(tokenString string) (map[string]interface{}, error) {
 		if email, exists := claims["email"]; exists {
 			data["email"] = email
 		}
+		if sessionId, exists := claims["sid"]; exists {
+			data["sid"] = sessionId
+		}
+		if tokenType, exists := claims["typ"]; exists {
+			data["typ"] = tokenType
+		}
+		if tokenId, exists := claims["jti"]; exists {
+			data["jti"] = tokenId
+		}
 
 		return data, nil
 	}
@@ -74,6 +99,34 @@ func JwtDecode(tokenString string) (map[string]interface{}, error) {
 	return nil, errors.New("invalid token")
 }
 
+// GenerateToken returns a URL-safe random token with 256 bits of entropy,
+// suitable for refresh tokens and other opaque one-time secrets.
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateNumericCode returns a random code of the given number of decimal
+// digits, for codes users type in such as SMS OTPs.
+func GenerateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// HashToken returns the hex SHA-256 digest of a high-entropy token. Unlike
+// HashData it is deterministic, so stored tokens can be looked up by hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // HashData hashes sensitive data (like passwords)
 func HashData(data interface{}) (string, error) {
 	strData := String(data)
@@ -98,6 +151,46 @@ func MatchWithHashedData(data interface{}, hashedData string) (bool, error) {
 	return true, nil
 }
 
+// GetEnvDuration reads a Go duration (e.g. "15m", "720h") from the environment,
+// falling back to the given default when the variable is unset or invalid.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+	return duration
+}
+
+// GetEnvInt reads a positive integer from the environment, falling back to the
+// given default when the variable is unset or invalid.
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvFloat reads a non-negative number from the environment, falling back
+// to the given default when the variable is unset or invalid.
+func GetEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fallback
+	}
+	return value
+}
+
+// IsDevelopmentEnv reports whether NODE_ENV is development or test
+func IsDevelopmentEnv() bool {
+	env := os.Getenv("NODE_ENV")
+	return env == "development" || env == "test"
+}
+
 // Helper function to convert any data to a string
 func String(data interface{}) string {
 	// Ensure we can safely convert data into string
@@ -0,0 +1,230 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/audit"
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AdminControllerSuite struct {
+	suite.Suite
+	app         *fiber.App
+	db          *gorm.DB
+	authService *auth.AuthService
+	adminUser   *models.User
+	targetUser  *models.User
+	adminToken  string
+}
+
+func TestAdminController(t *testing.T) {
+	suite.Run(t, &AdminControllerSuite{})
+}
+
+// createUserWithRole creates a verified user holding the given role
+func (adSuite *AdminControllerSuite) createUserWithRole(email string, roleName models.RoleName) *models.User {
+	user := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     email,
+		Password:  "password123",
+		FullName:  "Admin Test User",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	adSuite.db.Create(&user)
+
+	role := models.Role{
+		RoleId:    utils.GenerateID(),
+		RoleName:  roleName,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	adSuite.db.FirstOrCreate(&role, models.Role{RoleName: roleName})
+
+	userRole := models.UserRole{
+		UserRoleId: utils.GenerateID(),
+		UserId:     user.UserId,
+		RoleId:     role.RoleId,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		CreatedBy:  "test",
+		UpdatedBy:  "test",
+	}
+	adSuite.db.Create(&userRole)
+	return &user
+}
+
+func (adSuite *AdminControllerSuite) SetupSuite() {
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		adSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	adSuite.db = db
+	adSuite.app = app.AppSetup(db)
+	adSuite.authService = auth.NewAuthService(db)
+
+	adSuite.adminUser = adSuite.createUserWithRole("admin_user@example.com", models.RoleNameAdmin)
+	adSuite.targetUser = adSuite.createUserWithRole("admin_target@example.com", models.RoleNameAuthenticated)
+
+	tokenResponse, err := adSuite.authService.GetTokenByEmail(adSuite.adminUser.Email)
+	if err != nil {
+		adSuite.FailNowf("Failed to get auth token", "%v", err.Error())
+	}
+	adSuite.adminToken = tokenResponse.Token
+}
+
+func (adSuite *AdminControllerSuite) TearDownSuite() {
+	// Clean up test data
+	if adSuite.db != nil {
+		for _, user := range []*models.User{adSuite.adminUser, adSuite.targetUser} {
+			adSuite.db.Where("user_id = ?", user.UserId).Delete(&models.RefreshToken{})
+			adSuite.db.Where("user_id = ?", user.UserId).Delete(&models.UserRole{})
+			adSuite.db.Where("target_id = ?", user.UserId).Delete(&models.AuditLog{})
+			adSuite.db.Delete(user)
+		}
+	}
+}
+
+// request sends a JSON request with a bearer token and returns the status code
+func (adSuite *AdminControllerSuite) request(method, path string, payload interface{}, token string) int {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := adSuite.app.Test(req, -1)
+	adSuite.Require().NoError(err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// targetToken issues a fresh token for the target user
+func (adSuite *AdminControllerSuite) targetToken() string {
+	tokenResponse, err := adSuite.authService.GetTokenByEmail(adSuite.targetUser.Email)
+	adSuite.Require().NoError(err)
+	return tokenResponse.Token
+}
+
+func (adSuite *AdminControllerSuite) TestSuspendedUserIsReadOnly() {
+	assert := adSuite.Assert()
+	defer adSuite.request(http.MethodPost, "/admin/users/"+adSuite.targetUser.UserId+"/reinstate", nil, adSuite.adminToken)
+
+	oldToken := adSuite.targetToken()
+	status := adSuite.request(http.MethodPost, "/admin/users/"+adSuite.targetUser.UserId+"/suspend", map[string]interface{}{
+		"reason":        "Testing",
+		"durationHours": 24,
+	}, adSuite.adminToken)
+	adSuite.Require().Equal(http.StatusOK, status)
+
+	// Existing sessions are revoked
+	assert.Equal(http.StatusUnauthorized, adSuite.request(http.MethodGet, "/users", nil, oldToken))
+
+	// A suspended user can read but not write
+	token := adSuite.targetToken()
+	assert.Equal(http.StatusOK, adSuite.request(http.MethodGet, "/users", nil, token))
+	assert.Equal(http.StatusForbidden, adSuite.request(http.MethodPost, "/users/follows", map[string]string{
+		"followerId":  adSuite.targetUser.UserId,
+		"followingId": adSuite.adminUser.UserId,
+	}, token))
+}
+
+func (adSuite *AdminControllerSuite) TestBannedUserIsRefused() {
+	assert := adSuite.Assert()
+	defer adSuite.request(http.MethodPost, "/admin/users/"+adSuite.targetUser.UserId+"/reinstate", nil, adSuite.adminToken)
+
+	status := adSuite.request(http.MethodPost, "/admin/users/"+adSuite.targetUser.UserId+"/ban", map[string]string{
+		"reason": "Testing",
+	}, adSuite.adminToken)
+	adSuite.Require().Equal(http.StatusOK, status)
+
+	_, err := adSuite.authService.GetTokenByEmail(adSuite.targetUser.Email)
+	assert.Error(err)
+}
+
+func (adSuite *AdminControllerSuite) TestNonAdminCannotChangeStatus() {
+	status := adSuite.request(http.MethodPost, "/admin/users/"+adSuite.adminUser.UserId+"/ban", map[string]string{
+		"reason": "Testing",
+	}, adSuite.targetToken())
+	adSuite.Assert().Equal(http.StatusForbidden, status)
+}
+
+func (adSuite *AdminControllerSuite) TestAdminCannotGrantAdmin() {
+	status := adSuite.request(http.MethodPost, "/admin/roles/Admin/users", map[string]string{
+		"userId": adSuite.targetUser.UserId,
+	}, adSuite.adminToken)
+	adSuite.Assert().Equal(http.StatusForbidden, status)
+}
+
+func (adSuite *AdminControllerSuite) TestGrantAndRevokeRoleIsAudited() {
+	assert := adSuite.Assert()
+	path := "/admin/roles/BusinessOwner/users"
+
+	status := adSuite.request(http.MethodPost, path, map[string]string{
+		"userId": adSuite.targetUser.UserId,
+	}, adSuite.adminToken)
+	adSuite.Require().Equal(http.StatusCreated, status)
+
+	// Granting the same role twice conflicts
+	assert.Equal(http.StatusConflict, adSuite.request(http.MethodPost, path, map[string]string{
+		"userId": adSuite.targetUser.UserId,
+	}, adSuite.adminToken))
+
+	assert.Equal(http.StatusOK, adSuite.request(http.MethodGet, path, nil, adSuite.adminToken))
+	assert.Equal(http.StatusOK, adSuite.request(http.MethodDelete, path+"/"+adSuite.targetUser.UserId, nil, adSuite.adminToken))
+	assert.Equal(http.StatusNotFound, adSuite.request(http.MethodDelete, path+"/"+adSuite.targetUser.UserId, nil, adSuite.adminToken))
+
+	var entries int64
+	adSuite.db.Model(&models.AuditLog{}).
+		Where("target_id = ? AND action IN ?", adSuite.targetUser.UserId, []string{audit.ActionRoleGranted, audit.ActionRoleRevoked}).
+		Count(&entries)
+	assert.Equal(int64(2), entries)
+}
+
+func (adSuite *AdminControllerSuite) TestUnknownRoleIsRejected() {
+	status := adSuite.request(http.MethodGet, "/admin/roles/Overlord/users", nil, adSuite.adminToken)
+	adSuite.Assert().Equal(http.StatusBadRequest, status)
+}
+
+func (adSuite *AdminControllerSuite) TestUnlockClearsFailedLogins() {
+	assert := adSuite.Assert()
+	credentials := map[string]string{"email": adSuite.targetUser.Email, "password": "not-the-password"}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(http.StatusUnauthorized, adSuite.request(http.MethodPost, "/auth/login", credentials, ""))
+	}
+	assert.Equal(http.StatusTooManyRequests, adSuite.request(http.MethodPost, "/auth/login", credentials, ""))
+
+	path := "/admin/users/" + adSuite.targetUser.UserId + "/unlock"
+	assert.Equal(http.StatusForbidden, adSuite.request(http.MethodPost, path, nil, adSuite.targetToken()))
+	assert.Equal(http.StatusOK, adSuite.request(http.MethodPost, path, nil, adSuite.adminToken))
+
+	// The account accepts attempts again
+	assert.Equal(http.StatusUnauthorized, adSuite.request(http.MethodPost, "/auth/login", credentials, ""))
+
+	var entries int64
+	adSuite.db.Model(&models.AuditLog{}).
+		Where("target_id = ? AND action = ?", adSuite.targetUser.UserId, audit.ActionUserUnlocked).
+		Count(&entries)
+	assert.Equal(int64(1), entries)
+}
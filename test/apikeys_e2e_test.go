@@ -0,0 +1,169 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ApiKeysControllerSuite struct {
+	suite.Suite
+	app       *fiber.App
+	db        *gorm.DB
+	testUser  *models.User
+	authToken string
+}
+
+func TestApiKeysController(t *testing.T) {
+	suite.Run(t, &ApiKeysControllerSuite{})
+}
+
+func (akSuite *ApiKeysControllerSuite) SetupSuite() {
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		akSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	akSuite.db = db
+	akSuite.app = app.AppSetup(db)
+
+	testUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     "api_keys_user@example.com",
+		Password:  "password123",
+		FullName:  "Api Keys User",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	akSuite.db.Create(&testUser)
+	akSuite.testUser = &testUser
+
+	tokenResponse, err := auth.NewAuthService(db).GetTokenByEmail(testUser.Email)
+	if err != nil {
+		akSuite.FailNowf("Failed to get auth token", "%v", err.Error())
+	}
+	akSuite.authToken = tokenResponse.Token
+}
+
+func (akSuite *ApiKeysControllerSuite) TearDownSuite() {
+	// Clean up test data
+	if akSuite.db != nil {
+		akSuite.db.Where("user_id = ?", akSuite.testUser.UserId).Delete(&models.Blog{})
+		akSuite.db.Where("user_id = ?", akSuite.testUser.UserId).Delete(&models.ApiKey{})
+		akSuite.db.Where("user_id = ?", akSuite.testUser.UserId).Delete(&models.RefreshToken{})
+		akSuite.db.Delete(akSuite.testUser)
+	}
+}
+
+// request sends a JSON request with the given headers and returns the status code and body
+func (akSuite *ApiKeysControllerSuite) request(method, path string, payload interface{}, headers map[string]string) (int, []byte) {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := akSuite.app.Test(req, -1)
+	akSuite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	akSuite.Require().NoError(err)
+	return resp.StatusCode, responseBody
+}
+
+// createKey creates an API key with the given scopes and returns its ID and secret
+func (akSuite *ApiKeysControllerSuite) createKey(scopes ...string) (string, string) {
+	status, body := akSuite.request(http.MethodPost, "/api-keys", map[string]interface{}{
+		"name":   "Test key",
+		"scopes": scopes,
+	}, map[string]string{"Authorization": "Bearer " + akSuite.authToken})
+	akSuite.Require().Equal(http.StatusCreated, status, string(body))
+
+	var response map[string]interface{}
+	akSuite.Require().NoError(json.Unmarshal(body, &response))
+	data := response["data"].(map[string]interface{})
+	return data["apiKeyId"].(string), response["key"].(string)
+}
+
+func (akSuite *ApiKeysControllerSuite) TestScopesAreEnforced() {
+	assert := akSuite.Assert()
+	_, key := akSuite.createKey(models.ScopeBlogsRead)
+
+	// Readable with either header
+	status, _ := akSuite.request(http.MethodGet, "/blogs", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusOK, status)
+	status, _ = akSuite.request(http.MethodGet, "/blogs", nil, map[string]string{"Authorization": "ApiKey " + key})
+	assert.Equal(http.StatusOK, status)
+
+	// But not writable, and other resources are out of scope
+	status, _ = akSuite.request(http.MethodPost, "/blogs", map[string]string{"title": "From a bot"}, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusForbidden, status)
+	status, _ = akSuite.request(http.MethodGet, "/users", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusForbidden, status)
+
+	// Keys cannot manage keys or sessions
+	status, _ = akSuite.request(http.MethodGet, "/api-keys", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusForbidden, status)
+	status, _ = akSuite.request(http.MethodPost, "/auth/logout-all", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusForbidden, status)
+
+	// Public routes outside the key's scopes are served as to anyone signed out
+	status, _ = akSuite.request(http.MethodGet, "/feeds/latest.rss", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusOK, status)
+
+	// A Bearer token and an API key together are ambiguous
+	status, _ = akSuite.request(http.MethodGet, "/blogs", nil, map[string]string{"X-API-Key": key, "Authorization": "Bearer " + akSuite.authToken})
+	assert.Equal(http.StatusBadRequest, status)
+}
+
+func (akSuite *ApiKeysControllerSuite) TestRevokedKeyIsRejected() {
+	assert := akSuite.Assert()
+	apiKeyId, key := akSuite.createKey(models.ScopeBlogsRead, models.ScopeBlogsWrite)
+
+	status, _ := akSuite.request(http.MethodPost, "/blogs", map[string]string{"title": "Posted by a bot", "text": "Automated post"}, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusCreated, status)
+
+	status, _ = akSuite.request(http.MethodDelete, "/api-keys/"+apiKeyId, nil, map[string]string{"Authorization": "Bearer " + akSuite.authToken})
+	assert.Equal(http.StatusOK, status)
+
+	status, _ = akSuite.request(http.MethodGet, "/blogs", nil, map[string]string{"X-API-Key": key})
+	assert.Equal(http.StatusUnauthorized, status)
+}
+
+func (akSuite *ApiKeysControllerSuite) TestKeysAreStoredHashed() {
+	assert := akSuite.Assert()
+	apiKeyId, key := akSuite.createKey(models.ScopeCommentsRead)
+
+	var stored models.ApiKey
+	assert.NoError(akSuite.db.Where("api_key_id = ?", apiKeyId).First(&stored).Error)
+	assert.NotEqual(key, stored.KeyHash)
+	assert.Equal(utils.HashToken(key), stored.KeyHash)
+
+	// Unknown scopes are refused
+	status, _ := akSuite.request(http.MethodPost, "/api-keys", map[string]interface{}{
+		"name":   "Too powerful",
+		"scopes": []string{"admin:write"},
+	}, map[string]string{"Authorization": "Bearer " + akSuite.authToken})
+	assert.Equal(http.StatusBadRequest, status)
+}
@@ -0,0 +1,614 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AuthControllerSuite struct {
+	suite.Suite
+	app      *fiber.App
+	db       *gorm.DB
+	testUser *models.User
+	password string
+	mailFile string
+	smsFile  string
+	roleId   string
+}
+
+func TestAuthController(t *testing.T) {
+	suite.Run(t, &AuthControllerSuite{})
+}
+
+func (acSuite *AuthControllerSuite) SetupSuite() {
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		acSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	acSuite.db = db
+
+	// Capture outgoing emails and text messages in files the tests can read
+	acSuite.mailFile = filepath.Join(acSuite.T().TempDir(), "mail.log")
+	os.Setenv("MAILER_DRIVER", "file")
+	os.Setenv("MAILER_FILE", acSuite.mailFile)
+	acSuite.smsFile = filepath.Join(acSuite.T().TempDir(), "sms.log")
+	os.Setenv("SMS_DRIVER", "file")
+	os.Setenv("SMS_FILE", acSuite.smsFile)
+	acSuite.app = app.AppSetup(db)
+
+	// Create a test user with a real password hash
+	acSuite.password = "password123"
+	hashedPassword, err := utils.HashData(acSuite.password)
+	if err != nil {
+		acSuite.FailNowf("Failed to hash password", "%v", err.Error())
+	}
+	testUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     "auth_user@example.com",
+		Password:  hashedPassword,
+		FullName:  "Auth Test User",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	acSuite.db.Create(&testUser)
+	acSuite.testUser = &testUser
+
+	// Assign a role to the test user
+	testRole := models.Role{
+		RoleId:    utils.GenerateID(),
+		RoleName:  models.RoleNameAuthenticated,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	acSuite.db.FirstOrCreate(&testRole, models.Role{RoleName: models.RoleNameAuthenticated})
+	acSuite.roleId = testRole.RoleId
+
+	userRole := models.UserRole{
+		UserRoleId: utils.GenerateID(),
+		UserId:     testUser.UserId,
+		RoleId:     testRole.RoleId,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		CreatedBy:  "test",
+		UpdatedBy:  "test",
+	}
+	acSuite.db.Create(&userRole)
+}
+
+func (acSuite *AuthControllerSuite) TearDownSuite() {
+	// Clean up test data
+	if acSuite.db != nil {
+		// Delete the test user's sessions and roles
+		acSuite.db.Where("user_id = ?", acSuite.testUser.UserId).Delete(&models.RefreshToken{})
+		acSuite.db.Where("user_id = ?", acSuite.testUser.UserId).Delete(&models.UserRole{})
+		// Delete the test user
+		acSuite.db.Delete(acSuite.testUser)
+	}
+}
+
+// login posts the given credentials to /auth/login and returns the raw response body
+func (acSuite *AuthControllerSuite) login(email, password string) (int, []byte) {
+	jsonPayload, _ := json.Marshal(map[string]interface{}{
+		"email":    email,
+		"password": password,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := acSuite.app.Test(req, -1)
+	acSuite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	acSuite.Require().NoError(err)
+	return resp.StatusCode, body
+}
+
+func (acSuite *AuthControllerSuite) TestLogin() {
+	assert := acSuite.Assert()
+
+	status, body := acSuite.login(acSuite.testUser.Email, acSuite.password)
+	assert.Equal(http.StatusOK, status)
+
+	var responseBody map[string]interface{}
+	err := json.Unmarshal(body, &responseBody)
+	assert.NoError(err)
+	assert.NotEmpty(responseBody["token"])
+
+	// The password hash must never be returned
+	userData := responseBody["user"].(map[string]interface{})
+	assert.Equal(acSuite.testUser.Email, userData["email"])
+	assert.Empty(userData["password"])
+}
+
+func (acSuite *AuthControllerSuite) TestLoginUniformErrors() {
+	assert := acSuite.Assert()
+
+	// Wrong password and unknown email must be indistinguishable
+	wrongStatus, wrongBody := acSuite.login(acSuite.testUser.Email, "not-the-password")
+	unknownStatus, unknownBody := acSuite.login("nobody@example.com", "not-the-password")
+
+	assert.Equal(http.StatusUnauthorized, wrongStatus)
+	assert.Equal(wrongStatus, unknownStatus)
+	assert.Equal(string(wrongBody), string(unknownBody))
+}
+
+func (acSuite *AuthControllerSuite) TestRepeatedFailedLoginsAreThrottled() {
+	assert := acSuite.Assert()
+	user := acSuite.createUser("auth_throttled@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	// The first failures are answered normally
+	for i := 0; i < 3; i++ {
+		status, _ := acSuite.login(user.Email, "not-the-password")
+		assert.Equal(http.StatusUnauthorized, status)
+	}
+
+	// Then the account backs off, even for the right password
+	status, _ := acSuite.login(user.Email, acSuite.password)
+	assert.Equal(http.StatusTooManyRequests, status)
+
+	// Other accounts are unaffected
+	status, _ = acSuite.login(acSuite.testUser.Email, acSuite.password)
+	assert.Equal(http.StatusOK, status)
+}
+
+// postJSON sends a JSON POST request with an optional bearer token and returns the raw response body
+func (acSuite *AuthControllerSuite) postJSON(path string, payload interface{}, token string) (int, []byte) {
+	jsonPayload, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := acSuite.app.Test(req, -1)
+	acSuite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	acSuite.Require().NoError(err)
+	return resp.StatusCode, body
+}
+
+func (acSuite *AuthControllerSuite) TestRefreshRotationAndReuse() {
+	assert := acSuite.Assert()
+
+	_, body := acSuite.login(acSuite.testUser.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	firstRefreshToken := loginResponse["refreshToken"].(string)
+
+	// Rotating returns a new refresh token
+	status, body := acSuite.postJSON("/auth/refresh", map[string]string{"refreshToken": firstRefreshToken}, "")
+	assert.Equal(http.StatusOK, status)
+	var refreshResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &refreshResponse))
+	secondRefreshToken := refreshResponse["refreshToken"].(string)
+	assert.NotEqual(firstRefreshToken, secondRefreshToken)
+
+	// Reusing the rotated token is rejected and revokes the whole family
+	status, _ = acSuite.postJSON("/auth/refresh", map[string]string{"refreshToken": firstRefreshToken}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+	status, _ = acSuite.postJSON("/auth/refresh", map[string]string{"refreshToken": secondRefreshToken}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+}
+
+func (acSuite *AuthControllerSuite) TestConcurrentRefreshRevokesSession() {
+	assert := acSuite.Assert()
+
+	_, body := acSuite.login(acSuite.testUser.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	jsonPayload, _ := json.Marshal(map[string]string{"refreshToken": loginResponse["refreshToken"].(string)})
+
+	// Presenting one token several times at once is reuse: only one wins the rotation
+	const count = 4
+	var wg sync.WaitGroup
+	statuses := make([]int, count)
+	bodies := make([][]byte, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := acSuite.app.Test(req, -1)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			statuses[i] = resp.StatusCode
+			bodies[i], _ = io.ReadAll(resp.Body)
+		}(i)
+	}
+	wg.Wait()
+
+	var winner string
+	succeeded := 0
+	for i, status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+			var refreshResponse map[string]interface{}
+			acSuite.Require().NoError(json.Unmarshal(bodies[i], &refreshResponse))
+			winner = refreshResponse["refreshToken"].(string)
+		} else {
+			assert.Equal(http.StatusUnauthorized, status)
+		}
+	}
+	acSuite.Require().Equal(1, succeeded)
+
+	// The losers revoked the session, so the winner's token is dead too
+	status, _ := acSuite.postJSON("/auth/refresh", map[string]string{"refreshToken": winner}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+}
+
+func (acSuite *AuthControllerSuite) TestLogoutRevokesAccessToken() {
+	assert := acSuite.Assert()
+
+	_, body := acSuite.login(acSuite.testUser.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	accessToken := loginResponse["token"].(string)
+
+	status, _ := acSuite.postJSON("/auth/logout", nil, accessToken)
+	assert.Equal(http.StatusOK, status)
+
+	// The access token of a logged out session is no longer accepted
+	status, _ = acSuite.postJSON("/auth/logout", nil, accessToken)
+	assert.Equal(http.StatusUnauthorized, status)
+}
+
+// createUser creates an authenticated-role user with the suite password, optionally verified
+func (acSuite *AuthControllerSuite) createUser(email string, verified bool) models.User {
+	user := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     email,
+		Password:  acSuite.testUser.Password,
+		FullName:  "Auth Test User",
+		Verified:  verified,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	acSuite.Require().NoError(acSuite.db.Create(&user).Error)
+
+	userRole := models.UserRole{
+		UserRoleId: utils.GenerateID(),
+		UserId:     user.UserId,
+		RoleId:     acSuite.roleId,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		CreatedBy:  "test",
+		UpdatedBy:  "test",
+	}
+	acSuite.Require().NoError(acSuite.db.Create(&userRole).Error)
+	return user
+}
+
+// deleteUser removes a user created by createUser and everything issued to them
+func (acSuite *AuthControllerSuite) deleteUser(user models.User) {
+	acSuite.db.Where("user_id = ?", user.UserId).Delete(&models.RefreshToken{})
+	acSuite.db.Where("user_id = ?", user.UserId).Delete(&models.OneTimeToken{})
+	acSuite.db.Where("user_id = ?", user.UserId).Delete(&models.MfaRecoveryCode{})
+	acSuite.db.Where("user_id = ?", user.UserId).Delete(&models.UserMfa{})
+	acSuite.db.Where("user_id = ?", user.UserId).Delete(&models.UserRole{})
+	acSuite.db.Delete(&user)
+}
+
+// lastMailedToken returns the token from the most recent email sent to the given address
+func (acSuite *AuthControllerSuite) lastMailedToken(email string) string {
+	content, err := os.ReadFile(acSuite.mailFile)
+	acSuite.Require().NoError(err)
+
+	pattern := regexp.MustCompile(`To: ` + regexp.QuoteMeta(email) + `\n(?:.*\n)*?.*token: (\S+)`)
+	matches := pattern.FindAllStringSubmatch(string(content), -1)
+	acSuite.Require().NotEmpty(matches, "no email sent to %s", email)
+	return matches[len(matches)-1][1]
+}
+
+func (acSuite *AuthControllerSuite) TestEmailVerification() {
+	assert := acSuite.Assert()
+
+	unverifiedUser := acSuite.createUser("auth_unverified@example.com", false)
+	defer acSuite.deleteUser(unverifiedUser)
+
+	// Unknown and unverified emails get the same response
+	unknownStatus, unknownBody := acSuite.postJSON("/auth/verify-email/resend", map[string]string{"email": "nobody@example.com"}, "")
+	status, body := acSuite.postJSON("/auth/verify-email/resend", map[string]string{"email": unverifiedUser.Email}, "")
+	assert.Equal(http.StatusOK, status)
+	assert.Equal(unknownStatus, status)
+	assert.Equal(string(unknownBody), string(body))
+
+	token := acSuite.lastMailedToken(unverifiedUser.Email)
+	status, _ = acSuite.postJSON("/auth/verify-email", map[string]string{"token": token}, "")
+	assert.Equal(http.StatusOK, status)
+
+	var user models.User
+	acSuite.Require().NoError(acSuite.db.Where("user_id = ?", unverifiedUser.UserId).First(&user).Error)
+	assert.True(user.Verified)
+	assert.True(user.EmailIsVerified)
+
+	// Tokens are single use
+	status, _ = acSuite.postJSON("/auth/verify-email", map[string]string{"token": token}, "")
+	assert.Equal(http.StatusBadRequest, status)
+}
+
+func (acSuite *AuthControllerSuite) TestPasswordReset() {
+	assert := acSuite.Assert()
+
+	user := acSuite.createUser("auth_reset@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	_, body := acSuite.login(user.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+
+	status, _ := acSuite.postJSON("/auth/forgot-password", map[string]string{"email": user.Email}, "")
+	assert.Equal(http.StatusOK, status)
+	token := acSuite.lastMailedToken(user.Email)
+
+	status, _ = acSuite.postJSON("/auth/reset-password", map[string]string{"token": token, "newPassword": "brand-new-password"}, "")
+	assert.Equal(http.StatusOK, status)
+
+	// Existing sessions are revoked and only the new password works
+	status, _ = acSuite.postJSON("/auth/refresh", map[string]string{"refreshToken": loginResponse["refreshToken"].(string)}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+	status, _ = acSuite.login(user.Email, acSuite.password)
+	assert.Equal(http.StatusUnauthorized, status)
+	status, _ = acSuite.login(user.Email, "brand-new-password")
+	assert.Equal(http.StatusOK, status)
+
+	// Reset tokens are single use
+	status, _ = acSuite.postJSON("/auth/reset-password", map[string]string{"token": token, "newPassword": "another-password"}, "")
+	assert.Equal(http.StatusBadRequest, status)
+}
+
+func (acSuite *AuthControllerSuite) TestChangePasswordRequiresCurrentPassword() {
+	assert := acSuite.Assert()
+
+	_, body := acSuite.login(acSuite.testUser.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	accessToken := loginResponse["token"].(string)
+
+	status, _ := acSuite.postJSON("/auth/change-password", map[string]string{
+		"currentPassword": "not-the-password",
+		"newPassword":     "brand-new-password",
+	}, accessToken)
+	assert.Equal(http.StatusUnauthorized, status)
+
+	// The password is unchanged
+	status, _ = acSuite.login(acSuite.testUser.Email, acSuite.password)
+	assert.Equal(http.StatusOK, status)
+}
+
+func (acSuite *AuthControllerSuite) TestTwoFactorLogin() {
+	assert := acSuite.Assert()
+	require := acSuite.Require()
+
+	user := acSuite.createUser("auth_2fa@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	_, body := acSuite.login(user.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &loginResponse))
+	accessToken := loginResponse["token"].(string)
+
+	code, recoveryCodes := acSuite.enableTwoFactor(accessToken)
+	assert.Len(recoveryCodes, 10)
+
+	// Login now stops at a challenge
+	_, body = acSuite.login(user.Email, acSuite.password)
+	var challengeResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &challengeResponse))
+	assert.Equal(true, challengeResponse["mfaRequired"])
+	assert.Empty(challengeResponse["token"])
+	challengeToken := challengeResponse["challengeToken"].(string)
+
+	// The code used to confirm cannot be replayed
+	status, _ := acSuite.postJSON("/auth/2fa/verify", map[string]string{"challengeToken": challengeToken, "code": code}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+
+	// A recovery code completes the login once
+	status, body = acSuite.postJSON("/auth/2fa/verify", map[string]string{"challengeToken": challengeToken, "code": recoveryCodes[0].(string)}, "")
+	assert.Equal(http.StatusOK, status)
+	var tokenResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &tokenResponse))
+	assert.NotEmpty(tokenResponse["token"])
+
+	status, _ = acSuite.postJSON("/auth/2fa/verify", map[string]string{"challengeToken": challengeToken, "code": recoveryCodes[1].(string)}, "")
+	assert.Equal(http.StatusUnauthorized, status)
+
+	// Racing two recovery codes against one challenge uses up only the winner's
+	_, body = acSuite.login(user.Email, acSuite.password)
+	require.NoError(json.Unmarshal(body, &challengeResponse))
+	challengeToken = challengeResponse["challengeToken"].(string)
+	var wg sync.WaitGroup
+	statuses := make([]int, 2)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jsonPayload, _ := json.Marshal(map[string]string{"challengeToken": challengeToken, "code": recoveryCodes[i+1].(string)})
+			req := httptest.NewRequest(http.MethodPost, "/auth/2fa/verify", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := acSuite.app.Test(req, -1)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+	assert.ElementsMatch([]int{http.StatusOK, http.StatusUnauthorized}, statuses)
+
+	var usedCodes int64
+	acSuite.db.Model(&models.MfaRecoveryCode{}).Where("user_id = ? AND used_at IS NOT NULL", user.UserId).Count(&usedCodes)
+	assert.Equal(int64(2), usedCodes)
+}
+
+// enableTwoFactor enrolls the signed-in user in 2FA and confirms it, returning
+// the code used to confirm and the recovery codes
+func (acSuite *AuthControllerSuite) enableTwoFactor(accessToken string) (string, []interface{}) {
+	require := acSuite.Require()
+
+	status, body := acSuite.postJSON("/auth/2fa/enroll", nil, accessToken)
+	require.Equal(http.StatusOK, status)
+	var enrollResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &enrollResponse))
+	acSuite.Assert().Contains(enrollResponse["otpauthUri"], "otpauth://totp/")
+
+	code, err := utils.TOTPCode(enrollResponse["secret"].(string), time.Now())
+	require.NoError(err)
+	status, body = acSuite.postJSON("/auth/2fa/confirm", map[string]string{"code": code}, accessToken)
+	require.Equal(http.StatusOK, status)
+	var confirmResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &confirmResponse))
+	return code, confirmResponse["recoveryCodes"].([]interface{})
+}
+
+func (acSuite *AuthControllerSuite) TestTwoFactorGuessesAreThrottled() {
+	assert := acSuite.Assert()
+	require := acSuite.Require()
+
+	user := acSuite.createUser("auth_2fa_guess@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	_, body := acSuite.login(user.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	require.NoError(json.Unmarshal(body, &loginResponse))
+	acSuite.enableTwoFactor(loginResponse["token"].(string))
+
+	// Wrong codes count against the account, and a correct password that only
+	// yields a new challenge does not clear them
+	for i := 0; i < 3; i++ {
+		_, body = acSuite.login(user.Email, acSuite.password)
+		var challengeResponse map[string]interface{}
+		require.NoError(json.Unmarshal(body, &challengeResponse))
+		require.Equal(true, challengeResponse["mfaRequired"])
+		status, _ := acSuite.postJSON("/auth/2fa/verify", map[string]string{"challengeToken": challengeResponse["challengeToken"].(string), "code": "wrong-code"}, "")
+		assert.Equal(http.StatusUnauthorized, status)
+	}
+
+	status, _ := acSuite.login(user.Email, acSuite.password)
+	assert.Equal(http.StatusTooManyRequests, status)
+}
+
+func (acSuite *AuthControllerSuite) TestSecondFactorChecksAreThrottled() {
+	assert := acSuite.Assert()
+
+	user := acSuite.createUser("auth_2fa_disable@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	_, body := acSuite.login(user.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	accessToken := loginResponse["token"].(string)
+	acSuite.enableTwoFactor(accessToken)
+
+	// A session alone cannot keep guessing codes for sensitive actions
+	disable := map[string]string{"password": acSuite.password, "code": "wrong-code"}
+	for i := 0; i < 3; i++ {
+		status, _ := acSuite.postJSON("/auth/2fa/disable", disable, accessToken)
+		assert.Equal(http.StatusUnauthorized, status)
+	}
+	status, _ := acSuite.postJSON("/auth/2fa/disable", disable, accessToken)
+	assert.Equal(http.StatusTooManyRequests, status)
+
+	var mfaCount int64
+	acSuite.db.Model(&models.UserMfa{}).Where("user_id = ? AND enabled = ?", user.UserId, true).Count(&mfaCount)
+	assert.Equal(int64(1), mfaCount)
+}
+
+// lastTextedCode returns the verification code from the most recent text message sent to the given number
+func (acSuite *AuthControllerSuite) lastTextedCode(phoneNumber string) string {
+	content, err := os.ReadFile(acSuite.smsFile)
+	acSuite.Require().NoError(err)
+
+	pattern := regexp.MustCompile(`To: ` + regexp.QuoteMeta(phoneNumber) + `\n\n.*code is (\d{6})`)
+	matches := pattern.FindAllStringSubmatch(string(content), -1)
+	acSuite.Require().NotEmpty(matches, "no text message sent to %s", phoneNumber)
+	return matches[len(matches)-1][1]
+}
+
+func (acSuite *AuthControllerSuite) TestPhoneVerification() {
+	assert := acSuite.Assert()
+
+	user := acSuite.createUser("auth_phone@example.com", true)
+	defer acSuite.deleteUser(user)
+
+	_, body := acSuite.login(user.Email, acSuite.password)
+	var loginResponse map[string]interface{}
+	acSuite.Require().NoError(json.Unmarshal(body, &loginResponse))
+	accessToken := loginResponse["token"].(string)
+
+	status, _ := acSuite.postJSON("/auth/phone", map[string]string{"phoneNumber": "12345"}, accessToken)
+	assert.Equal(http.StatusBadRequest, status)
+
+	phoneNumber := "+23276000001"
+	status, _ = acSuite.postJSON("/auth/phone", map[string]string{"phoneNumber": phoneNumber}, accessToken)
+	acSuite.Require().Equal(http.StatusOK, status)
+	code := acSuite.lastTextedCode(phoneNumber)
+
+	// Codes cannot be requested again straight away
+	status, _ = acSuite.postJSON("/auth/phone", map[string]string{"phoneNumber": phoneNumber}, accessToken)
+	assert.Equal(http.StatusTooManyRequests, status)
+
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+	status, _ = acSuite.postJSON("/auth/phone/verify", map[string]string{"code": wrongCode}, accessToken)
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, _ = acSuite.postJSON("/auth/phone/verify", map[string]string{"code": code}, accessToken)
+	assert.Equal(http.StatusOK, status)
+
+	var updated models.User
+	acSuite.Require().NoError(acSuite.db.Where("user_id = ?", user.UserId).First(&updated).Error)
+	assert.Equal(phoneNumber, updated.PhoneNumber)
+	assert.True(updated.PhoneNumberIsVerified)
+}
+
+func (acSuite *AuthControllerSuite) TestStaleTokenDoesNotBlockPublicRoutes() {
+	assert := acSuite.Assert()
+
+	// Public routes ignore a token that cannot be accepted
+	status, _ := acSuite.postJSON("/auth/forgot-password", map[string]string{"email": acSuite.testUser.Email}, "not-a-jwt")
+	assert.Equal(http.StatusOK, status)
+
+	// Protected routes report why it was refused
+	status, body := acSuite.postJSON("/auth/logout", nil, "not-a-jwt")
+	assert.Equal(http.StatusUnauthorized, status)
+	assert.Contains(string(body), "Invalid or expired token")
+
+	status, body = acSuite.postJSON("/auth/logout", nil, "")
+	assert.Equal(http.StatusUnauthorized, status)
+	assert.Contains(string(body), "No token provided")
+}
@@ -7,13 +7,18 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/epsierra/phinex-blog-api/src/app"
 	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/blogs"
 	"github.com/epsierra/phinex-blog-api/src/database"
 	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/pagination"
 	"github.com/epsierra/phinex-blog-api/src/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
@@ -27,14 +32,17 @@ func init() {
 	if err != nil {
 		log.Fatal("Error loading .env file", err)
 	}
+	// The suites sign in with email-only tokens, which the shipped .env keeps disabled
+	os.Setenv("AUTH_EMAIL_TOKEN_ENABLED", "true")
 }
 
 type BlogControllerSuite struct {
 	suite.Suite
-	app       *fiber.App
-	db        *gorm.DB
-	testUser  *models.User
-	authToken string
+	app         *fiber.App
+	db          *gorm.DB
+	testUser    *models.User
+	authToken   string
+	authService *auth.AuthService
 }
 
 func TestBlogController(t *testing.T) {
@@ -89,8 +97,8 @@ func (bcSuite *BlogControllerSuite) SetupSuite() {
 	bcSuite.db.Create(&userRole)
 
 	// Get an auth token for the test user
-	authService := auth.NewAuthService(db)
-	tokenResponse, err := authService.GetTokenByEmail(testUser.Email)
+	bcSuite.authService = auth.NewAuthService(db)
+	tokenResponse, err := bcSuite.authService.GetTokenByEmail(testUser.Email)
 	if err != nil {
 		bcSuite.FailNowf("Failed to get auth token", "%v", err.Error())
 	}
@@ -206,6 +214,71 @@ func (bcSuite *BlogControllerSuite) TestFindAllBlogs() {
 	bcSuite.db.Delete(&seededBlogs)
 }
 
+func (bcSuite *BlogControllerSuite) TestFindPinnedBlogsPaging() {
+	assert := bcSuite.Assert()
+
+	now := time.Now().UTC()
+	var pins []models.PinnedBlog
+	for i, title := range []string{"Pinned 1", "Pinned 2"} {
+		blog := models.Blog{
+			BlogId:    utils.GenerateID(),
+			UserId:    bcSuite.testUser.UserId,
+			Title:     title,
+			Text:      "Pinned content",
+			Status:    models.BlogStatusPublished,
+			CreatedAt: now,
+			UpdatedAt: now,
+			CreatedBy: bcSuite.testUser.FullName,
+			UpdatedBy: bcSuite.testUser.FullName,
+		}
+		bcSuite.Require().NoError(bcSuite.db.Create(&blog).Error)
+		defer bcSuite.db.Delete(&blog)
+		pins = append(pins, models.PinnedBlog{
+			PinnedBlogId: utils.GenerateID(),
+			BlogId:       blog.BlogId,
+			UserId:       bcSuite.testUser.UserId,
+			StartDate:    now,
+			EndDate:      now.Add(24 * time.Hour),
+			CreatedAt:    now.Add(time.Duration(i) * time.Second),
+			UpdatedAt:    now,
+			CreatedBy:    bcSuite.testUser.FullName,
+			UpdatedBy:    bcSuite.testUser.FullName,
+		})
+	}
+	bcSuite.Require().NoError(bcSuite.db.Create(&pins).Error)
+	defer bcSuite.db.Delete(&pins)
+
+	get := func(path string) (int, models.PaginatedResponse) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+		resp, err := bcSuite.app.Test(req, -1)
+		assert.NoError(err)
+		defer resp.Body.Close()
+		var body models.PaginatedResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	// Out of range paging falls back to the defaults instead of failing
+	status, page := get("/pinned-blogs?page=0&limit=0")
+	assert.Equal(http.StatusOK, status)
+	assert.Len(page.Data, 2)
+
+	// The newest pin comes first, and the cursor continues after it
+	status, page = get("/pinned-blogs?limit=1")
+	assert.Equal(http.StatusOK, status)
+	bcSuite.Require().Len(page.Data, 1)
+	assert.Contains(fmt.Sprint(page.Data), "Pinned 2")
+	assert.True(page.Metadata.HasNextPage)
+	bcSuite.Require().NotEmpty(page.Metadata.NextCursor)
+
+	status, page = get("/pinned-blogs?limit=1&after=" + page.Metadata.NextCursor)
+	assert.Equal(http.StatusOK, status)
+	bcSuite.Require().Len(page.Data, 1)
+	assert.Contains(fmt.Sprint(page.Data), "Pinned 1")
+	assert.False(page.Metadata.HasNextPage)
+}
+
 func (bcSuite *BlogControllerSuite) TestFindBlogById() {
 	assert := bcSuite.Assert()
 
@@ -985,3 +1058,916 @@ func (bcSuite *BlogControllerSuite) TestFindLikesAndFollowers() {
 	bcSuite.db.Delete(&otherUser)
 	bcSuite.db.Delete(&follow)
 }
+
+// createOtherUser creates a second verified user and returns it with a token
+func (bcSuite *BlogControllerSuite) createOtherUser(email string) (*models.User, string) {
+	otherUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     email,
+		Password:  "password123",
+		FullName:  "Other User",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	bcSuite.db.Create(&otherUser)
+
+	var role models.Role
+	bcSuite.db.Where("role_name = ?", models.RoleNameAuthenticated).First(&role)
+	bcSuite.db.Create(&models.UserRole{
+		UserRoleId: utils.GenerateID(),
+		UserId:     otherUser.UserId,
+		RoleId:     role.RoleId,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		CreatedBy:  "test",
+		UpdatedBy:  "test",
+	})
+
+	tokenResponse, err := auth.NewAuthService(bcSuite.db).GetTokenByEmail(otherUser.Email)
+	bcSuite.Require().NoError(err)
+	return &otherUser, tokenResponse.Token
+}
+
+// deleteOtherUser removes a user created by createOtherUser
+func (bcSuite *BlogControllerSuite) deleteOtherUser(user *models.User) {
+	bcSuite.db.Where("user_id = ?", user.UserId).Delete(&models.RefreshToken{})
+	bcSuite.db.Where("user_id = ?", user.UserId).Delete(&models.UserRole{})
+	bcSuite.db.Delete(user)
+}
+
+func (bcSuite *BlogControllerSuite) TestOnlyAuthorCanChangeBlog() {
+	assert := bcSuite.Assert()
+	otherUser, otherToken := bcSuite.createOtherUser("blog_other@example.com")
+	defer bcSuite.deleteOtherUser(otherUser)
+
+	blog := models.Blog{
+		BlogId:    utils.GenerateID(),
+		UserId:    bcSuite.testUser.UserId,
+		Title:     "Protected Blog",
+		Text:      "Only the author may change this",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: bcSuite.testUser.FullName,
+		UpdatedBy: bcSuite.testUser.FullName,
+	}
+	bcSuite.db.Create(&blog)
+	defer bcSuite.db.Delete(&blog)
+
+	jsonPayload, _ := json.Marshal(map[string]string{"title": "Hijacked"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/blogs/%s", blog.BlogId), bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/blogs/%s", blog.BlogId), nil)
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+
+	var unchanged models.Blog
+	assert.NoError(bcSuite.db.Where("blog_id = ?", blog.BlogId).First(&unchanged).Error)
+	assert.Equal("Protected Blog", unchanged.Title)
+}
+
+func (bcSuite *BlogControllerSuite) TestBlogAuthorCanDeleteCommentsOnOwnBlog() {
+	assert := bcSuite.Assert()
+	otherUser, otherToken := bcSuite.createOtherUser("comment_other@example.com")
+	defer bcSuite.deleteOtherUser(otherUser)
+
+	blog := models.Blog{
+		BlogId:    utils.GenerateID(),
+		UserId:    bcSuite.testUser.UserId,
+		Title:     "Blog With Comments",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: bcSuite.testUser.FullName,
+		UpdatedBy: bcSuite.testUser.FullName,
+	}
+	bcSuite.db.Create(&blog)
+	defer bcSuite.db.Delete(&blog)
+
+	comment := models.Comment{
+		CommentId: utils.GenerateID(),
+		RefId:     blog.BlogId,
+		UserId:    otherUser.UserId,
+		Text:      "A comment by someone else",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: otherUser.FullName,
+		UpdatedBy: otherUser.FullName,
+	}
+	bcSuite.db.Create(&comment)
+	defer bcSuite.db.Where("comment_id = ?", comment.CommentId).Delete(&models.Comment{})
+
+	// The blog author cannot edit someone else's comment
+	jsonPayload, _ := json.Marshal(map[string]string{"text": "Edited"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/comments/%s", comment.CommentId), bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+
+	// The commenter cannot delete the blog they commented on
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/blogs/%s", blog.BlogId), nil)
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+
+	// But the blog author can remove the comment
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/comments/%s", comment.CommentId), nil)
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+}
+
+// createBlog creates a blog through the API as the test user and returns it
+func (bcSuite *BlogControllerSuite) createBlog(title string) models.Blog {
+	jsonPayload, _ := json.Marshal(map[string]string{"title": title, "text": "Slug test content"})
+	req := httptest.NewRequest(http.MethodPost, "/blogs", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	bcSuite.Require().NoError(err)
+	defer resp.Body.Close()
+	bcSuite.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var responseBody struct {
+		Data models.Blog `json:"data"`
+	}
+	bcSuite.Require().NoError(json.NewDecoder(resp.Body).Decode(&responseBody))
+	return responseBody.Data
+}
+
+func (bcSuite *BlogControllerSuite) TestBlogSlugs() {
+	assert := bcSuite.Assert()
+
+	first := bcSuite.createBlog("Crème Brûlée & Friends")
+	defer bcSuite.db.Delete(&first)
+	second := bcSuite.createBlog("Creme Brulee and Friends!")
+	defer bcSuite.db.Delete(&second)
+
+	assert.Equal("creme-brulee-and-friends", first.Slug)
+	assert.Equal("creme-brulee-and-friends-2", second.Slug)
+	assert.True(strings.HasSuffix(first.Url, "/blogs/creme-brulee-and-friends"))
+
+	req := httptest.NewRequest(http.MethodGet, "/blogs/slug/"+first.Slug, nil)
+	resp, err := bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	// Retitling moves the blog to a new slug and the old one redirects
+	jsonPayload, _ := json.Marshal(map[string]string{"title": "Tarte Tatin"})
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/blogs/%s", first.BlogId), bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/blogs/slug/creme-brulee-and-friends", nil)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal("/blogs/slug/tarte-tatin", resp.Header.Get("Location"))
+
+	// The old slug stays reserved for the blog that had it
+	third := bcSuite.createBlog("Crème brûlée and friends")
+	defer bcSuite.db.Delete(&third)
+	assert.Equal("creme-brulee-and-friends-3", third.Slug)
+
+	req = httptest.NewRequest(http.MethodGet, "/blogs/slug/no-such-blog", nil)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// Blogs created before slugs existed get the next free one
+	legacy := models.Blog{
+		BlogId:    utils.GenerateID(),
+		UserId:    bcSuite.testUser.UserId,
+		Title:     "Tarte Tatin",
+		Text:      "Written before slugs",
+		Status:    models.BlogStatusPublished,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	bcSuite.Require().NoError(bcSuite.db.Create(&legacy).Error)
+	defer bcSuite.db.Delete(&legacy)
+	_, err = blogs.NewBlogsService(bcSuite.db, bcSuite.authService).BackfillSlugs()
+	bcSuite.Require().NoError(err)
+	bcSuite.db.Where("blog_id = ?", legacy.BlogId).First(&legacy)
+	assert.Equal("tarte-tatin-2", legacy.Slug)
+	assert.True(strings.HasSuffix(legacy.Url, "/blogs/tarte-tatin-2"))
+	var history int64
+	bcSuite.db.Model(&models.BlogSlug{}).Where("blog_id = ? AND slug = ?", legacy.BlogId, legacy.Slug).Count(&history)
+	assert.Equal(int64(1), history)
+}
+
+func (bcSuite *BlogControllerSuite) TestConcurrentBlogsGetDistinctSlugs() {
+	assert := bcSuite.Assert()
+
+	const count = 5
+	var wg sync.WaitGroup
+	results := make([]models.Blog, count)
+	statuses := make([]int, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jsonPayload, _ := json.Marshal(map[string]string{"title": "Simultaneous Slug", "text": "Race"})
+			req := httptest.NewRequest(http.MethodPost, "/blogs", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+			resp, err := bcSuite.app.Test(req, -1)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			var responseBody struct {
+				Data models.Blog `json:"data"`
+			}
+			json.NewDecoder(resp.Body).Decode(&responseBody)
+			statuses[i], results[i] = resp.StatusCode, responseBody.Data
+		}(i)
+	}
+	wg.Wait()
+
+	slugs := map[string]bool{}
+	for i, blog := range results {
+		if blog.BlogId != "" {
+			defer bcSuite.db.Delete(&models.Blog{}, "blog_id = ?", blog.BlogId)
+		}
+		assert.Equal(http.StatusCreated, statuses[i])
+		slugs[blog.Slug] = true
+	}
+	assert.Len(slugs, count)
+}
+
+func (bcSuite *BlogControllerSuite) TestDraftsAndScheduledPublishing() {
+	assert := bcSuite.Assert()
+	otherUser, otherToken := bcSuite.createOtherUser("drafts_other@example.com")
+	defer bcSuite.deleteOtherUser(otherUser)
+
+	get := func(path, token string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	jsonPayload, _ := json.Marshal(map[string]interface{}{"title": "Work In Progress", "text": "Not ready", "status": "draft"})
+	req := httptest.NewRequest(http.MethodPost, "/blogs", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	bcSuite.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var draft models.Blog
+	bcSuite.Require().NoError(bcSuite.db.Where("title = ?", "Work In Progress").First(&draft).Error)
+	defer bcSuite.db.Delete(&draft)
+	assert.Equal(models.BlogStatusDraft, draft.Status)
+
+	// Drafts stay out of feeds and are only visible to their author
+	_, feed := get("/blogs", bcSuite.authToken)
+	assert.NotContains(fmt.Sprint(feed["data"]), draft.BlogId)
+	_, drafts := get("/blogs/drafts", bcSuite.authToken)
+	assert.Contains(fmt.Sprint(drafts["data"]), draft.BlogId)
+	// Out of range paging falls back to the defaults instead of failing
+	status, drafts := get("/blogs/drafts?page=0&limit=0", bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(fmt.Sprint(drafts["data"]), draft.BlogId)
+	status, _ = get("/blogs/"+draft.BlogId, otherToken)
+	assert.Equal(http.StatusNotFound, status)
+
+	// Nor can anyone else read its comments, like, comment on or repost it
+	status, _ = get("/blogs/"+draft.BlogId+"/comments", otherToken)
+	assert.Equal(http.StatusNotFound, status)
+	send := func(method, path string, payload interface{}) int {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+otherToken)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(http.StatusNotFound, send(http.MethodPut, "/blogs/"+draft.BlogId+"/likes", nil))
+	assert.Equal(http.StatusNotFound, send(http.MethodPost, "/blogs/"+draft.BlogId+"/comments", map[string]string{"text": "Sneak peek"}))
+	assert.Equal(http.StatusNotFound, send(http.MethodPost, "/blogs", map[string]string{"title": "Repost", "text": "Look", "RepostedFromBlogId": draft.BlogId}))
+	var interactions int64
+	bcSuite.db.Model(&models.Like{}).Where("ref_id = ?", draft.BlogId).Count(&interactions)
+	assert.Zero(interactions)
+	bcSuite.db.Model(&models.Comment{}).Where("ref_id = ?", draft.BlogId).Count(&interactions)
+	assert.Zero(interactions)
+	bcSuite.db.Model(&models.Share{}).Where("ref_id = ?", draft.BlogId).Count(&interactions)
+	assert.Zero(interactions)
+
+	// The author's own comments on it are just as hidden
+	jsonPayload, _ = json.Marshal(map[string]string{"text": "Note to self"})
+	req = httptest.NewRequest(http.MethodPost, "/blogs/"+draft.BlogId+"/comments", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	bcSuite.Require().NoError(err)
+	resp.Body.Close()
+	bcSuite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	var note models.Comment
+	bcSuite.Require().NoError(bcSuite.db.Where("ref_id = ?", draft.BlogId).First(&note).Error)
+	defer bcSuite.db.Delete(&note)
+
+	status, _ = get("/comments/"+note.CommentId+"/replies", otherToken)
+	assert.Equal(http.StatusNotFound, status)
+	status, _ = get("/comments/"+note.CommentId+"/likes", otherToken)
+	assert.Equal(http.StatusNotFound, status)
+	status, _ = get("/blogs/"+draft.BlogId+"/follows/likes", otherToken)
+	assert.Equal(http.StatusNotFound, status)
+	assert.Equal(http.StatusNotFound, send(http.MethodPut, "/comments/"+note.CommentId+"/likes", nil))
+	assert.Equal(http.StatusNotFound, send(http.MethodPost, "/comments/"+note.CommentId+"/replies", map[string]string{"text": "Sneak peek"}))
+	bcSuite.db.Model(&models.Like{}).Where("ref_id = ?", note.CommentId).Count(&interactions)
+	assert.Zero(interactions)
+	bcSuite.db.Model(&models.Comment{}).Where("ref_id = ?", note.CommentId).Count(&interactions)
+	assert.Zero(interactions)
+
+	// The author still sees them
+	status, _ = get("/comments/"+note.CommentId+"/replies", bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+
+	// Scheduling needs a future time
+	jsonPayload, _ = json.Marshal(map[string]interface{}{"status": "scheduled", "publishAt": time.Now().Add(-time.Hour)})
+	req = httptest.NewRequest(http.MethodPut, "/blogs/"+draft.BlogId, bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	jsonPayload, _ = json.Marshal(map[string]interface{}{"status": "scheduled", "publishAt": time.Now().Add(time.Hour)})
+	req = httptest.NewRequest(http.MethodPut, "/blogs/"+draft.BlogId, bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err = bcSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	// Once due, the scheduler publishes it
+	bcSuite.db.Model(&models.Blog{}).Where("blog_id = ?", draft.BlogId).Update("publish_at", time.Now().Add(-time.Minute))
+	published, err := blogs.NewBlogsService(bcSuite.db, bcSuite.authService).PublishDueBlogs()
+	assert.NoError(err)
+	assert.GreaterOrEqual(published, 1)
+
+	status, _ = get("/blogs/"+draft.BlogId, otherToken)
+	assert.Equal(http.StatusOK, status)
+	_, feed = get("/blogs", bcSuite.authToken)
+	assert.Contains(fmt.Sprint(feed["data"]), draft.BlogId)
+}
+
+func (bcSuite *BlogControllerSuite) TestBlogRevisions() {
+	assert := bcSuite.Assert()
+	otherUser, otherToken := bcSuite.createOtherUser("revisions_other@example.com")
+	defer bcSuite.deleteOtherUser(otherUser)
+
+	blog := bcSuite.createBlog("Revision History")
+	defer bcSuite.db.Delete(&blog)
+
+	request := func(method, path string, payload interface{}, token string) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		if payload != nil {
+			json.NewEncoder(&body).Encode(payload)
+		}
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, response
+	}
+	revisionsPath := fmt.Sprintf("/blogs/%s/revisions", blog.BlogId)
+
+	status, _ := request(http.MethodPut, "/blogs/"+blog.BlogId, map[string]string{"text": "Slug test content\nA second line"}, bcSuite.authToken)
+	bcSuite.Require().Equal(http.StatusOK, status)
+
+	status, list := request(http.MethodGet, revisionsPath, nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(list["data"], 2)
+
+	// Paging is clamped instead of dividing by a zero limit
+	status, list = request(http.MethodGet, revisionsPath+"?page=-1&limit=0", nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(list["data"], 2)
+	status, list = request(http.MethodGet, revisionsPath+"?limit=1", nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(list["data"], 1)
+	assert.Equal(true, list["metadata"].(map[string]interface{})["hasNextPage"])
+
+	status, diff := request(http.MethodGet, revisionsPath+"/diff?from=1&to=2", nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Equal([]interface{}{"text"}, diff["changedFields"])
+	assert.Equal(" Slug test content\n+A second line\n", diff["unified"])
+
+	// Only the author and admins can read the history
+	status, _ = request(http.MethodGet, revisionsPath, nil, otherToken)
+	assert.Equal(http.StatusForbidden, status)
+
+	// Restoring saves a new revision with the old content
+	status, _ = request(http.MethodPost, revisionsPath+"/1/restore", nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	status, restored := request(http.MethodGet, revisionsPath+"/3", nil, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Equal(float64(1), restored["restoredFrom"])
+	assert.Equal("Slug test content", restored["text"])
+
+	status, _ = request(http.MethodPost, revisionsPath+"/1/restore", nil, bcSuite.authToken)
+	assert.Equal(http.StatusBadRequest, status)
+}
+
+func (bcSuite *BlogControllerSuite) TestBlogTags() {
+	assert := bcSuite.Assert()
+	otherUser, otherToken := bcSuite.createOtherUser("tags_other@example.com")
+	defer bcSuite.deleteOtherUser(otherUser)
+
+	request := func(method, path string, payload interface{}, token string) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		if payload != nil {
+			json.NewEncoder(&body).Encode(payload)
+		}
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, response
+	}
+
+	status, created := request(http.MethodPost, "/blogs", map[string]interface{}{
+		"title": "Tagged Blog",
+		"text":  "Tag test content",
+		"tags":  []string{"#Go Lang", "go-lang", "Tag Test"},
+	}, bcSuite.authToken)
+	bcSuite.Require().Equal(http.StatusCreated, status)
+	blog := created["data"].(map[string]interface{})
+	defer bcSuite.db.Delete(&models.Blog{}, "blog_id = ?", blog["blogId"])
+	assert.Equal([]interface{}{"go-lang", "tag-test"}, blog["tags"])
+
+	status, _ = request(http.MethodPost, "/blogs", map[string]interface{}{
+		"title": "Too Many Tags",
+		"text":  "Tag test content",
+		"tags":  []string{"a", "b", "c", "d", "e", "f"},
+	}, bcSuite.authToken)
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, tagBlogs := request(http.MethodGet, "/tags/Tag%20Test/blogs", nil, otherToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(tagBlogs["data"], 1)
+
+	status, popular := request(http.MethodGet, "/tags/popular?limit=100", nil, otherToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(popular["data"], map[string]interface{}{"name": "tag-test", "blogsCount": float64(1)})
+
+	// Following a tag brings its blogs into the following feed
+	status, followed := request(http.MethodPut, "/tags/tag-test/follows", nil, otherToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Equal(true, followed["followed"])
+	status, feed := request(http.MethodGet, "/following-blogs", nil, otherToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(feed["data"], 1)
+
+	// An empty list removes the blog's tags
+	status, updated := request(http.MethodPut, fmt.Sprintf("/blogs/%s", blog["blogId"]), map[string]interface{}{"tags": []string{}}, bcSuite.authToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Equal([]interface{}{}, updated["data"].(map[string]interface{})["tags"])
+
+	status, followed = request(http.MethodPut, "/tags/tag-test/follows", nil, otherToken)
+	assert.Equal(http.StatusOK, status)
+	assert.Equal(false, followed["followed"])
+
+	// Tags that no blog has used cannot be followed
+	status, _ = request(http.MethodPut, "/tags/never-used-tag/follows", nil, otherToken)
+	assert.Equal(http.StatusNotFound, status)
+	var unknownTags int64
+	bcSuite.db.Model(&models.Tag{}).Where("name = ?", "never-used-tag").Count(&unknownTags)
+	assert.Zero(unknownTags)
+}
+
+func (bcSuite *BlogControllerSuite) TestMarkdownRendering() {
+	assert := bcSuite.Assert()
+
+	request := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		json.NewEncoder(&body).Encode(payload)
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, response
+	}
+
+	status, created := request(http.MethodPost, "/blogs", map[string]interface{}{
+		"title":  "Markdown Blog",
+		"format": "markdown",
+		"text":   "# Hello\n\nSee [the docs](https://example.com/docs) <script>alert(1)</script>\n\n![cover](https://example.com/cover.png)",
+		"images": []string{"https://example.com/cover.png"},
+	})
+	bcSuite.Require().Equal(http.StatusCreated, status)
+	blog := created["data"].(map[string]interface{})
+	defer bcSuite.db.Delete(&models.Blog{}, "blog_id = ?", blog["blogId"])
+	assert.Equal("markdown", blog["format"])
+	assert.Equal("<h1>Hello</h1>\n"+
+		`<p>See <a href="https://example.com/docs" rel="nofollow noopener noreferrer" target="_blank">the docs</a> &lt;script&gt;alert(1)&lt;/script&gt;</p>`+"\n"+
+		`<p><img src="https://example.com/cover.png" alt="cover"></p>`+"\n", blog["html"])
+
+	// Embedded images must be attached to the blog
+	status, _ = request(http.MethodPost, "/blogs", map[string]interface{}{
+		"title":  "Hotlinked image",
+		"format": "markdown",
+		"text":   "![elsewhere](https://tracker.example.com/pixel.png)",
+	})
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, _ = request(http.MethodPost, "/blogs", map[string]interface{}{"title": "Unknown format", "format": "html", "text": "<b>hi</b>"})
+	assert.Equal(http.StatusBadRequest, status)
+
+	// Switching to plain text re-renders the saved text
+	status, updated := request(http.MethodPut, fmt.Sprintf("/blogs/%s", blog["blogId"]), map[string]string{"format": "plain", "text": "**not bold**"})
+	assert.Equal(http.StatusOK, status)
+	assert.Equal("<p>**not bold**</p>\n", updated["data"].(map[string]interface{})["html"])
+
+	status, comment := request(http.MethodPost, fmt.Sprintf("/blogs/%s/comments", blog["blogId"]), map[string]string{"text": "Nice *post* [x](javascript:alert(1))", "format": "markdown"})
+	assert.Equal(http.StatusCreated, status)
+	assert.Equal("<p>Nice <em>post</em> x</p>\n", comment["data"].(map[string]interface{})["comment"].(map[string]interface{})["html"])
+}
+
+func (bcSuite *BlogControllerSuite) TestFeeds() {
+	assert := bcSuite.Assert()
+
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]interface{}{
+		"title": "Feed Blog",
+		"text":  "Blog with a video",
+		"video": "https://example.com/feed-video.mp4",
+		"tags":  []string{"feeds"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/blogs", &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	bcSuite.Require().NoError(err)
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	bcSuite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	blogId := created["data"].(map[string]interface{})["blogId"].(string)
+	defer bcSuite.db.Delete(&models.Blog{}, "blog_id = ?", blogId)
+
+	// Feeds are public, so no auth header is sent
+	fetch := func(path, ifNoneMatch string) (*http.Response, string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var content bytes.Buffer
+		content.ReadFrom(resp.Body)
+		return resp, content.String()
+	}
+
+	resp, rss := fetch("/feeds/latest.rss", "")
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/rss+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(rss, "<title>Feed Blog</title>")
+	// RSS enclosures need a size, which is unknown for video
+	assert.NotContains(rss, "https://example.com/feed-video.mp4")
+
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(etag)
+	resp, _ = fetch("/feeds/latest.rss", etag)
+	assert.Equal(http.StatusNotModified, resp.StatusCode)
+
+	resp, atom := fetch(fmt.Sprintf("/feeds/users/%s.atom", bcSuite.testUser.UserId), "")
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/atom+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(atom, "<id>urn:phinex:blog:"+blogId+"</id>")
+
+	resp, jsonFeed := fetch("/feeds/tags/feeds.json", "")
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/feed+json; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(jsonFeed, `"id": "`+blogId+`"`)
+
+	resp, _ = fetch("/feeds/latest.html", "")
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = fetch("/feeds/users/unknown-user.rss", "")
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (bcSuite *BlogControllerSuite) TestBlogMetaAndSitemaps() {
+	assert := bcSuite.Assert()
+
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]interface{}{
+		"title":  "Meta Blog",
+		"format": "markdown",
+		"text":   "# Heading\n\nA **short** introduction to the post.",
+		"images": []string{"https://example.com/meta-cover.png", "https://example.com/meta-second.png"},
+		"tags":   []string{"seo"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/blogs", &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+	resp, err := bcSuite.app.Test(req, -1)
+	bcSuite.Require().NoError(err)
+	var created struct {
+		Data models.Blog `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	bcSuite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	blog := created.Data
+	defer bcSuite.db.Delete(&models.Blog{}, "blog_id = ?", blog.BlogId)
+
+	// Metadata is public, so it is fetched without credentials
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/blogs/%s/meta", blog.BlogId), nil)
+	resp, err = bcSuite.app.Test(req, -1)
+	bcSuite.Require().NoError(err)
+	var meta blogs.BlogMetaResponse
+	json.NewDecoder(resp.Body).Decode(&meta)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("Meta Blog", meta.Data.Title)
+	assert.Equal("Heading A short introduction to the post.", meta.Data.Description)
+	assert.Equal("https://example.com/meta-cover.png", meta.Data.Image)
+	assert.Equal(blog.Url, meta.Data.CanonicalUrl)
+	assert.Equal("article", meta.Data.OpenGraph.Type)
+	assert.Equal([]string{"seo"}, meta.Data.OpenGraph.Tags)
+	assert.Equal("Test User", meta.Data.OpenGraph.Author)
+	assert.Equal("summary_large_image", meta.Data.Twitter.Card)
+
+	// Fetching metadata does not count as a view
+	var stored models.Blog
+	bcSuite.db.Where("blog_id = ?", blog.BlogId).First(&stored)
+	assert.EqualValues(0, stored.ViewsCount)
+
+	// Drafts stay hidden from anonymous callers
+	draft := bcSuite.createBlog("Meta Draft")
+	defer bcSuite.db.Delete(&draft)
+	bcSuite.Require().NoError(bcSuite.db.Model(&draft).Update("status", models.BlogStatusDraft).Error)
+	resp, err = bcSuite.app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/blogs/%s/meta", draft.BlogId), nil), -1)
+	bcSuite.Require().NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// Sitemaps are public
+	fetch := func(path string) (int, string) {
+		resp, err := bcSuite.app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+		var content bytes.Buffer
+		content.ReadFrom(resp.Body)
+		return resp.StatusCode, content.String()
+	}
+
+	status, index := fetch("/sitemap.xml")
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(index, "<loc>http://example.com/sitemaps/blogs-1.xml</loc>")
+	assert.Contains(index, "<loc>http://example.com/sitemaps/users-1.xml</loc>")
+
+	// New blogs land on the last page
+	pages := strings.Count(index, "/sitemaps/blogs-")
+	status, sitemap := fetch(fmt.Sprintf("/sitemaps/blogs-%d.xml", pages))
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(sitemap, "<loc>"+blog.Url+"</loc>")
+	assert.Contains(sitemap, "<lastmod>"+stored.UpdatedAt.UTC().Format(time.RFC3339)+"</lastmod>")
+
+	status, _ = fetch(fmt.Sprintf("/sitemaps/blogs-%d.xml", pages+1))
+	assert.Equal(http.StatusNotFound, status)
+	status, _ = fetch("/sitemaps/blogs-first.xml")
+	assert.Equal(http.StatusNotFound, status)
+}
+
+func (bcSuite *BlogControllerSuite) TestCursorPagination() {
+	assert := bcSuite.Assert()
+
+	blog := bcSuite.createBlog("Cursor Blog")
+	defer bcSuite.db.Delete(&blog)
+
+	// Comments created in the same instant are told apart by their IDs
+	createdAt := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		bcSuite.Require().NoError(bcSuite.db.Create(&models.Comment{
+			CommentId: utils.GenerateID(),
+			RefId:     blog.BlogId,
+			UserId:    bcSuite.testUser.UserId,
+			Text:      fmt.Sprintf("Cursor comment %d", i),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			CreatedBy: bcSuite.testUser.FullName,
+			UpdatedBy: bcSuite.testUser.FullName,
+		}).Error)
+	}
+	defer bcSuite.db.Where("ref_id = ?", blog.BlogId).Delete(&models.Comment{})
+
+	fetch := func(query string) (int, models.PaginationMetadata, []string) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/blogs/%s/comments?%s", blog.BlogId, query), nil)
+		req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		var page struct {
+			Data     []models.Comment          `json:"data"`
+			Metadata models.PaginationMetadata `json:"metadata"`
+		}
+		json.NewDecoder(resp.Body).Decode(&page)
+		ids := []string{}
+		for _, comment := range page.Data {
+			ids = append(ids, comment.CommentId)
+		}
+		return resp.StatusCode, page.Metadata, ids
+	}
+
+	// Numbered pages still count the total
+	status, metadata, _ := fetch("page=1&limit=2")
+	assert.Equal(http.StatusOK, status)
+	assert.EqualValues(5, metadata.TotalItems)
+	assert.EqualValues(3, metadata.TotalPages)
+	assert.True(metadata.HasNextPage)
+	assert.NotEmpty(metadata.NextCursor)
+
+	// A comment added while scrolling does not shift the cursor pages
+	seen := map[string]bool{}
+	status, metadata, ids := fetch("limit=2")
+	assert.Equal(http.StatusOK, status)
+	bcSuite.Require().Len(ids, 2)
+	for _, id := range ids {
+		seen[id] = true
+	}
+	bcSuite.Require().NoError(bcSuite.db.Create(&models.Comment{
+		CommentId: utils.GenerateID(),
+		RefId:     blog.BlogId,
+		UserId:    bcSuite.testUser.UserId,
+		Text:      "Newer comment",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: bcSuite.testUser.FullName,
+		UpdatedBy: bcSuite.testUser.FullName,
+	}).Error)
+
+	for pages := 1; metadata.NextCursor != ""; pages++ {
+		bcSuite.Require().Less(pages, 5)
+		status, metadata, ids = fetch("limit=2&after=" + metadata.NextCursor)
+		assert.Equal(http.StatusOK, status)
+		assert.EqualValues(0, metadata.TotalItems)
+		assert.True(metadata.HasPreviousPage)
+		for _, id := range ids {
+			assert.False(seen[id], "comment %s was returned twice", id)
+			seen[id] = true
+		}
+	}
+	assert.Len(seen, 5)
+	assert.False(metadata.HasNextPage)
+
+	status, metadata, _ = fetch("limit=2&count=true&after=" + pagination.Cursor{CreatedAt: time.Now(), Id: "~"}.Encode())
+	assert.Equal(http.StatusOK, status)
+	assert.EqualValues(6, metadata.TotalItems)
+
+	status, _, _ = fetch("after=not-a-cursor")
+	assert.Equal(http.StatusBadRequest, status)
+}
+
+func (bcSuite *BlogControllerSuite) TestRankedFeeds() {
+	assert := bcSuite.Assert()
+
+	quiet := bcSuite.createBlog("Ranked quiet blog")
+	defer bcSuite.db.Delete(&quiet)
+	popular := bcSuite.createBlog("Ranked popular blog")
+	defer bcSuite.db.Delete(&popular)
+	bcSuite.db.Model(&popular).Updates(map[string]interface{}{"likes_count": 40, "comments_count": 5})
+
+	// Half a day old, so it ranks below the quiet blog unless the viewer likes its author
+	author, _ := bcSuite.createOtherUser("ranked_author@example.com")
+	defer bcSuite.deleteOtherUser(author)
+	halfDayAgo := time.Now().Add(-12 * time.Hour)
+	favourite := models.Blog{
+		BlogId:      utils.GenerateID(),
+		UserId:      author.UserId,
+		Title:       "Ranked favourite blog",
+		Text:        "From an author the viewer likes",
+		Status:      models.BlogStatusPublished,
+		PublishedAt: &halfDayAgo,
+		CreatedAt:   halfDayAgo,
+		UpdatedAt:   halfDayAgo,
+		CreatedBy:   author.FullName,
+		UpdatedBy:   author.FullName,
+	}
+	bcSuite.Require().NoError(bcSuite.db.Create(&favourite).Error)
+	defer bcSuite.db.Delete(&favourite)
+	like := models.Like{
+		LikeId:    utils.GenerateID(),
+		RefId:     favourite.BlogId,
+		UserId:    bcSuite.testUser.UserId,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: bcSuite.testUser.FullName,
+		UpdatedBy: bcSuite.testUser.FullName,
+	}
+	bcSuite.Require().NoError(bcSuite.db.Create(&like).Error)
+	defer bcSuite.db.Delete(&like)
+
+	// Blogs thousands of half-lives old are still scored rather than underflowing
+	ancient := bcSuite.createBlog("Ranked ancient blog")
+	defer bcSuite.db.Delete(&ancient)
+	decadeAgo := time.Now().AddDate(-10, 0, 0)
+	bcSuite.db.Model(&ancient).Updates(map[string]interface{}{"created_at": decadeAgo, "published_at": decadeAgo})
+
+	bcSuite.Require().NoError(blogs.NewBlogsService(bcSuite.db, bcSuite.authService).RecomputeScores())
+
+	var affinity models.UserAffinity
+	assert.NoError(bcSuite.db.Where("user_id = ? AND kind = ? AND target_id = ?", bcSuite.testUser.UserId, models.AffinityKindAuthor, author.UserId).First(&affinity).Error)
+	assert.Equal(1.0, affinity.Weight)
+
+	// positions returns where each blog appears in a ranked list, or -1
+	positions := func(path string) (int, map[string]int) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+bcSuite.authToken)
+		resp, err := bcSuite.app.Test(req, -1)
+		bcSuite.Require().NoError(err)
+		defer resp.Body.Close()
+
+		var page struct {
+			Data []models.Blog `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&page)
+		found := map[string]int{quiet.BlogId: -1, popular.BlogId: -1, favourite.BlogId: -1}
+		for i, blog := range page.Data {
+			if _, ok := found[blog.BlogId]; ok {
+				found[blog.BlogId] = i
+			}
+		}
+		return resp.StatusCode, found
+	}
+
+	status, trending := positions("/blogs/trending?window=24h&limit=100")
+	assert.Equal(http.StatusOK, status)
+	for _, position := range trending {
+		assert.NotEqual(-1, position)
+	}
+	assert.Less(trending[popular.BlogId], trending[quiet.BlogId])
+	assert.Less(trending[quiet.BlogId], trending[favourite.BlogId])
+
+	status, forYou := positions("/blogs/for-you?limit=100")
+	assert.Equal(http.StatusOK, status)
+	for _, position := range forYou {
+		assert.NotEqual(-1, position)
+	}
+	assert.Less(forYou[popular.BlogId], forYou[favourite.BlogId])
+	assert.Less(forYou[favourite.BlogId], forYou[quiet.BlogId])
+
+	status, recent := positions("/blogs/trending?window=1h&limit=100")
+	assert.Equal(http.StatusOK, status)
+	assert.Equal(-1, recent[favourite.BlogId])
+	assert.NotEqual(-1, recent[quiet.BlogId])
+
+	status, _ = positions("/blogs/trending?window=90d")
+	assert.Equal(http.StatusBadRequest, status)
+	status, _ = positions("/blogs/trending?window=soon")
+	assert.Equal(http.StatusBadRequest, status)
+}
@@ -0,0 +1,284 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const (
+	mockOidcClientID     = "phinex-test-client"
+	mockOidcClientSecret = "phinex-test-secret"
+	mockOidcRedirectURL  = "http://localhost:3000/auth/oidc/callback"
+)
+
+// mockOidcProvider is a minimal OpenID Connect provider: discovery, an
+// authorize endpoint that logs in a fixed user, a PKCE-checking token
+// endpoint and a JWKS.
+type mockOidcProvider struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	subject string
+	email   string
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newMockOidcProvider(subject, email string) *mockOidcProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	p := &mockOidcProvider{key: key, subject: subject, email: email, codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		code := base64.RawURLEncoding.EncodeToString(big.NewInt(time.Now().UnixNano()).Bytes())
+		p.mu.Lock()
+		p.codes[code] = query
+		p.mu.Unlock()
+		http.Redirect(w, r, query.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		r.ParseForm()
+
+		p.mu.Lock()
+		authorization, ok := p.codes[r.Form.Get("code")]
+		delete(p.codes, r.Form.Get("code"))
+		p.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if !ok || clientID != mockOidcClientID || clientSecret != mockOidcClientSecret || challenge != authorization.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            p.server.URL,
+			"aud":            mockOidcClientID,
+			"sub":            p.subject,
+			"email":          p.email,
+			"email_verified": true,
+			"name":           "Oidc Test User",
+			"nonce":          authorization.Get("nonce"),
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "mock-key"
+		signed, _ := idToken.SignedString(p.key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "mock", "token_type": "Bearer", "id_token": signed})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			}},
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+type OidcLoginSuite struct {
+	suite.Suite
+	app      *fiber.App
+	db       *gorm.DB
+	provider *mockOidcProvider
+}
+
+func TestOidcLogin(t *testing.T) {
+	suite.Run(t, &OidcLoginSuite{})
+}
+
+func (olSuite *OidcLoginSuite) SetupSuite() {
+	olSuite.provider = newMockOidcProvider("mock-subject-1", "oidc_user@example.com")
+	os.Setenv("OIDC_ISSUER_URL", olSuite.provider.server.URL)
+	os.Setenv("OIDC_CLIENT_ID", mockOidcClientID)
+	os.Setenv("OIDC_CLIENT_SECRET", mockOidcClientSecret)
+	os.Setenv("OIDC_REDIRECT_URL", mockOidcRedirectURL)
+
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		olSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	olSuite.db = db
+	olSuite.app = app.AppSetup(db)
+}
+
+func (olSuite *OidcLoginSuite) TearDownSuite() {
+	for _, key := range []string{"OIDC_ISSUER_URL", "OIDC_CLIENT_ID", "OIDC_CLIENT_SECRET", "OIDC_REDIRECT_URL"} {
+		os.Unsetenv(key)
+	}
+	olSuite.provider.server.Close()
+
+	// Clean up test data
+	if olSuite.db != nil {
+		olSuite.deleteUser(olSuite.provider.email)
+	}
+}
+
+// deleteUser removes the user with the given email and everything linked to it
+func (olSuite *OidcLoginSuite) deleteUser(email string) {
+	var user models.User
+	if olSuite.db.Where("email = ?", email).First(&user).Error == nil {
+		olSuite.db.Where("user_id = ?", user.UserId).Delete(&models.ExternalIdentity{})
+		olSuite.db.Where("user_id = ?", user.UserId).Delete(&models.RefreshToken{})
+		olSuite.db.Where("user_id = ?", user.UserId).Delete(&models.UsersStats{})
+		olSuite.db.Delete(&user)
+	}
+}
+
+// get sends a GET request to the app and returns the status code and body
+func (olSuite *OidcLoginSuite) get(path string) (int, []byte) {
+	resp, err := olSuite.app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+	olSuite.Require().NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	olSuite.Require().NoError(err)
+	return resp.StatusCode, body
+}
+
+// authorize starts a login and lets the mock provider log the user in,
+// returning the query string it redirects back with
+func (olSuite *OidcLoginSuite) authorize() url.Values {
+	status, body := olSuite.get("/auth/oidc/authorize")
+	olSuite.Require().Equal(http.StatusOK, status, string(body))
+	var response map[string]string
+	olSuite.Require().NoError(json.Unmarshal(body, &response))
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(response["authorizationUrl"])
+	olSuite.Require().NoError(err)
+	resp.Body.Close()
+	olSuite.Require().Equal(http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	olSuite.Require().NoError(err)
+	olSuite.Require().Equal(response["state"], location.Query().Get("state"))
+	return location.Query()
+}
+
+func (olSuite *OidcLoginSuite) TestLoginCreatesAndLinksUser() {
+	assert := olSuite.Assert()
+
+	callback := olSuite.authorize()
+	status, body := olSuite.get("/auth/oidc/callback?" + callback.Encode())
+	olSuite.Require().Equal(http.StatusOK, status, string(body))
+
+	var response map[string]interface{}
+	olSuite.Require().NoError(json.Unmarshal(body, &response))
+	assert.NotEmpty(response["token"])
+	assert.NotEmpty(response["refreshToken"])
+	userData := response["user"].(map[string]interface{})
+	assert.Equal(olSuite.provider.email, userData["email"])
+	assert.Empty(userData["password"])
+
+	// The callback cannot be replayed
+	status, _ = olSuite.get("/auth/oidc/callback?" + callback.Encode())
+	assert.Equal(http.StatusBadRequest, status)
+
+	// A second login reuses the linked user
+	status, body = olSuite.get("/auth/oidc/callback?" + olSuite.authorize().Encode())
+	olSuite.Require().Equal(http.StatusOK, status, string(body))
+	var second map[string]interface{}
+	olSuite.Require().NoError(json.Unmarshal(body, &second))
+	assert.Equal(userData["userId"], second["user"].(map[string]interface{})["userId"])
+
+	var identities int64
+	olSuite.db.Model(&models.ExternalIdentity{}).Where("subject = ?", olSuite.provider.subject).Count(&identities)
+	assert.Equal(int64(1), identities)
+}
+
+func (olSuite *OidcLoginSuite) TestLinkingUnverifiedUserReplacesPassword() {
+	assert := olSuite.Assert()
+
+	// Someone registers the address first but never verifies it
+	subject, email := olSuite.provider.subject, olSuite.provider.email
+	olSuite.provider.subject, olSuite.provider.email = "mock-subject-2", "oidc_squatted@example.com"
+	defer func() {
+		olSuite.deleteUser(olSuite.provider.email)
+		olSuite.provider.subject, olSuite.provider.email = subject, email
+	}()
+
+	hashedPassword, err := utils.HashData("squatter-password")
+	olSuite.Require().NoError(err)
+	squatted := models.User{
+		UserId:    utils.GenerateID(),
+		FullName:  "Squatter",
+		Email:     olSuite.provider.email,
+		Password:  hashedPassword,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	olSuite.Require().NoError(olSuite.db.Create(&squatted).Error)
+	olSuite.Require().NoError(olSuite.db.Create(&models.RefreshToken{
+		RefreshTokenId: utils.GenerateID(),
+		FamilyId:       utils.GenerateID(),
+		UserId:         squatted.UserId,
+		TokenHash:      utils.HashToken(utils.GenerateID()),
+		ExpiresAt:      time.Now().Add(time.Hour),
+		CreatedBy:      "test",
+		UpdatedBy:      "test",
+	}).Error)
+
+	status, body := olSuite.get("/auth/oidc/callback?" + olSuite.authorize().Encode())
+	olSuite.Require().Equal(http.StatusOK, status, string(body))
+
+	// The provider's owner gets the account, but not the squatter's password or sessions
+	var linked models.User
+	olSuite.Require().NoError(olSuite.db.Where("user_id = ?", squatted.UserId).First(&linked).Error)
+	assert.True(linked.Verified)
+	matches, _ := utils.MatchWithHashedData("squatter-password", linked.Password)
+	assert.False(matches)
+
+	var activeSessions int64
+	olSuite.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND created_by = ?", squatted.UserId, "test").Count(&activeSessions)
+	assert.Equal(int64(0), activeSessions)
+}
+
+func (olSuite *OidcLoginSuite) TestUnknownStateIsRejected() {
+	status, _ := olSuite.get("/auth/oidc/callback?code=anything&state=forged")
+	olSuite.Assert().Equal(http.StatusBadRequest, status)
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SearchControllerSuite struct {
+	suite.Suite
+	app       *fiber.App
+	db        *gorm.DB
+	testUser  *models.User
+	authToken string
+}
+
+func TestSearchController(t *testing.T) {
+	suite.Run(t, &SearchControllerSuite{})
+}
+
+func (scSuite *SearchControllerSuite) SetupSuite() {
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		scSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	scSuite.db = db
+	scSuite.app = app.AppSetup(db)
+
+	testUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     "search_user@example.com",
+		Password:  "password123",
+		FullName:  "Searchable Quokkaberg",
+		UserName:  "quokkaberg",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	scSuite.db.Create(&testUser)
+	scSuite.testUser = &testUser
+
+	tokenResponse, err := auth.NewAuthService(db).GetTokenByEmail(testUser.Email)
+	if err != nil {
+		scSuite.FailNowf("Failed to get auth token", "%v", err.Error())
+	}
+	scSuite.authToken = tokenResponse.Token
+}
+
+func (scSuite *SearchControllerSuite) TearDownSuite() {
+	// Clean up test data
+	if scSuite.db != nil {
+		scSuite.db.Where("user_id = ?", scSuite.testUser.UserId).Delete(&models.Comment{})
+		scSuite.db.Where("user_id = ?", scSuite.testUser.UserId).Delete(&models.Blog{})
+		scSuite.db.Where("user_id = ?", scSuite.testUser.UserId).Delete(&models.RefreshToken{})
+		scSuite.db.Delete(scSuite.testUser)
+	}
+}
+
+// search calls GET /search and returns the status code and decoded body
+func (scSuite *SearchControllerSuite) search(query url.Values) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodGet, "/search?"+query.Encode(), nil)
+	req.Header.Set("Authorization", "Bearer "+scSuite.authToken)
+	resp, err := scSuite.app.Test(req, -1)
+	scSuite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response
+}
+
+// createBlog inserts a blog owned by the test user
+func (scSuite *SearchControllerSuite) createBlog(title, text string, status models.BlogStatus) models.Blog {
+	blog := models.Blog{
+		BlogId:    utils.GenerateID(),
+		UserId:    scSuite.testUser.UserId,
+		Title:     title,
+		Text:      text,
+		Status:    status,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	scSuite.Require().NoError(scSuite.db.Create(&blog).Error)
+	return blog
+}
+
+func (scSuite *SearchControllerSuite) TestSearchBlogs() {
+	assert := scSuite.Assert()
+
+	titleMatch := scSuite.createBlog("Zygomorphic flowers", "A field guide.", models.BlogStatusPublished)
+	textMatch := scSuite.createBlog("A field guide", "Orchids have zygomorphic flowers.", models.BlogStatusPublished)
+	scSuite.createBlog("Zygomorphic draft", "Not published yet.", models.BlogStatusDraft)
+
+	// Title matches outrank text matches, prefixes match and drafts are left out
+	status, response := scSuite.search(url.Values{"q": {"zygomorph"}, "type": {"blogs"}})
+	assert.Equal(http.StatusOK, status)
+	assert.Nil(response["users"])
+	blogs := response["blogs"].(map[string]interface{})["data"].([]interface{})
+	scSuite.Require().Len(blogs, 2)
+	first := blogs[0].(map[string]interface{})
+	assert.Equal(titleMatch.BlogId, first["blogId"])
+	assert.Equal("<mark>Zygomorphic</mark> flowers", first["titleHighlight"])
+	assert.Equal(textMatch.BlogId, blogs[1].(map[string]interface{})["blogId"])
+	assert.Contains(blogs[1].(map[string]interface{})["snippet"], "<mark>zygomorphic</mark>")
+
+	// Markup in the source text comes back escaped; only the highlighting is HTML
+	scSuite.createBlog("Xenolithic <b>rocks</b>", `Xenolithic <img src=x onerror="alert(1)"> inclusions.`, models.BlogStatusPublished)
+	status, response = scSuite.search(url.Values{"q": {"xenolithic"}, "type": {"blogs"}})
+	assert.Equal(http.StatusOK, status)
+	blogs = response["blogs"].(map[string]interface{})["data"].([]interface{})
+	scSuite.Require().Len(blogs, 1)
+	escaped := blogs[0].(map[string]interface{})
+	assert.Equal("<mark>Xenolithic</mark> &lt;b&gt;rocks&lt;/b&gt;", escaped["titleHighlight"])
+	assert.NotContains(escaped["snippet"], "<img")
+	assert.Contains(escaped["snippet"], "&lt;img")
+}
+
+func (scSuite *SearchControllerSuite) TestSearchCommentsAndUsers() {
+	assert := scSuite.Assert()
+
+	blog := scSuite.createBlog("Comment search", "Host blog.", models.BlogStatusPublished)
+	comment := models.Comment{
+		CommentId: utils.GenerateID(),
+		RefId:     blog.BlogId,
+		UserId:    scSuite.testUser.UserId,
+		Text:      "Marsupials are underrated",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	scSuite.Require().NoError(scSuite.db.Create(&comment).Error)
+
+	status, response := scSuite.search(url.Values{"q": {"marsupial"}})
+	assert.Equal(http.StatusOK, status)
+	comments := response["comments"].(map[string]interface{})["data"].([]interface{})
+	scSuite.Require().Len(comments, 1)
+	assert.Equal(comment.CommentId, comments[0].(map[string]interface{})["commentId"])
+
+	// A misspelt name still finds the user
+	status, response = scSuite.search(url.Values{"q": {"quokaberg"}, "type": {"users"}})
+	assert.Equal(http.StatusOK, status)
+	users := response["users"].(map[string]interface{})["data"].([]interface{})
+	scSuite.Require().NotEmpty(users)
+	assert.Equal(scSuite.testUser.UserId, users[0].(map[string]interface{})["userId"])
+
+	status, _ = scSuite.search(url.Values{"q": {"!!!"}})
+	assert.Equal(http.StatusBadRequest, status)
+	status, _ = scSuite.search(url.Values{"q": {"quokka"}, "type": {"tags"}})
+	assert.Equal(http.StatusBadRequest, status)
+}
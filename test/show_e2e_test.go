@@ -0,0 +1,206 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/epsierra/phinex-blog-api/src/app"
+	"github.com/epsierra/phinex-blog-api/src/auth"
+	"github.com/epsierra/phinex-blog-api/src/database"
+	"github.com/epsierra/phinex-blog-api/src/models"
+	"github.com/epsierra/phinex-blog-api/src/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ShowControllerSuite struct {
+	suite.Suite
+	app       *fiber.App
+	db        *gorm.DB
+	testUser  *models.User
+	authToken string
+}
+
+func TestShowController(t *testing.T) {
+	suite.Run(t, &ShowControllerSuite{})
+}
+
+func (shSuite *ShowControllerSuite) SetupSuite() {
+	// Initialize database connection
+	db, err := database.NewDatabaseConnection()
+	if err != nil {
+		shSuite.FailNowf("Database Error", "%v", err.Error())
+	}
+	shSuite.db = db
+	shSuite.app = app.AppSetup(db)
+
+	testUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     "show_user@example.com",
+		Password:  "password123",
+		FullName:  "Show Host",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	shSuite.db.Create(&testUser)
+	shSuite.testUser = &testUser
+
+	tokenResponse, err := auth.NewAuthService(db).GetTokenByEmail(testUser.Email)
+	if err != nil {
+		shSuite.FailNowf("Failed to get auth token", "%v", err.Error())
+	}
+	shSuite.authToken = tokenResponse.Token
+}
+
+func (shSuite *ShowControllerSuite) TearDownSuite() {
+	// Clean up test data
+	if shSuite.db != nil {
+		shSuite.db.Where("user_id = ?", shSuite.testUser.UserId).Delete(&models.Show{})
+		shSuite.db.Where("user_id = ?", shSuite.testUser.UserId).Delete(&models.Blog{})
+		shSuite.db.Where("user_id = ?", shSuite.testUser.UserId).Delete(&models.RefreshToken{})
+		shSuite.db.Delete(shSuite.testUser)
+	}
+}
+
+// request calls the app as the test user and returns the status code and decoded body
+func (shSuite *ShowControllerSuite) request(method, path string, payload interface{}) (int, map[string]interface{}) {
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(payload)
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+shSuite.authToken)
+	resp, err := shSuite.app.Test(req, -1)
+	shSuite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response
+}
+
+// createBlog inserts a published blog owned by the test user
+func (shSuite *ShowControllerSuite) createBlog(title, audio string) models.Blog {
+	now := time.Now()
+	blog := models.Blog{
+		BlogId:      utils.GenerateID(),
+		UserId:      shSuite.testUser.UserId,
+		Title:       title,
+		Text:        "Show notes",
+		Html:        "<p>Show notes</p>\n",
+		Audio:       audio,
+		Status:      models.BlogStatusPublished,
+		PublishedAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   "test",
+		UpdatedBy:   "test",
+	}
+	shSuite.Require().NoError(shSuite.db.Create(&blog).Error)
+	return blog
+}
+
+func (shSuite *ShowControllerSuite) TestShowEpisodes() {
+	assert := shSuite.Assert()
+
+	status, _ := shSuite.request(http.MethodPost, "/shows", map[string]string{"title": "Bad category", "category": "Gardening"})
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, created := shSuite.request(http.MethodPost, "/shows", map[string]interface{}{
+		"title":      "The Test Show",
+		"artwork":    "https://example.com/artwork.jpg",
+		"category":   "Technology",
+		"explicit":   false,
+		"ownerEmail": "host@example.com",
+	})
+	shSuite.Require().Equal(http.StatusCreated, status)
+	show := created["data"].(map[string]interface{})
+	showId := show["showId"].(string)
+	assert.Equal("Show Host", show["author"])
+	assert.Equal("episodic", show["type"])
+
+	first := shSuite.createBlog("Episode one", "https://example.com/episode-1.mp3")
+	second := shSuite.createBlog("Episode two", "https://example.com/episode-2.m4a")
+	silent := shSuite.createBlog("Not an episode", "")
+
+	episodesPath := fmt.Sprintf("/shows/%s/episodes", showId)
+	status, added := shSuite.request(http.MethodPost, episodesPath, map[string]interface{}{"blogId": first.BlogId, "audioLength": 1234, "durationSeconds": 90})
+	shSuite.Require().Equal(http.StatusCreated, status)
+	assert.EqualValues(1, added["data"].(map[string]interface{})["episodeNumber"])
+	assert.Equal("audio/mpeg", added["data"].(map[string]interface{})["audioType"])
+
+	// Episodes are numbered after the last one in the season
+	status, added = shSuite.request(http.MethodPost, episodesPath, map[string]interface{}{"blogId": second.BlogId, "audioLength": 5678})
+	shSuite.Require().Equal(http.StatusCreated, status)
+	assert.EqualValues(2, added["data"].(map[string]interface{})["episodeNumber"])
+	assert.Equal("audio/x-m4a", added["data"].(map[string]interface{})["audioType"])
+
+	status, _ = shSuite.request(http.MethodPost, episodesPath, map[string]interface{}{"blogId": silent.BlogId})
+	assert.Equal(http.StatusBadRequest, status)
+	status, _ = shSuite.request(http.MethodPost, episodesPath, map[string]interface{}{"blogId": first.BlogId, "audioLength": 1})
+	assert.Equal(http.StatusConflict, status)
+	status, _ = shSuite.request(http.MethodPut, fmt.Sprintf("%s/%s", episodesPath, second.BlogId), map[string]interface{}{"episodeNumber": 1})
+	assert.Equal(http.StatusConflict, status)
+
+	status, found := shSuite.request(http.MethodGet, fmt.Sprintf("/shows/%s", showId), nil)
+	assert.Equal(http.StatusOK, status)
+	assert.Len(found["data"].(map[string]interface{})["episodes"], 2)
+
+	// The podcast feed is public
+	resp, err := shSuite.app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/feeds/shows/%s.rss", showId), nil), -1)
+	shSuite.Require().NoError(err)
+	feed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/rss+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(string(feed), `<itunes:category text="Technology"></itunes:category>`)
+	assert.Contains(string(feed), `<itunes:image href="https://example.com/artwork.jpg"></itunes:image>`)
+	assert.Contains(string(feed), "<podcast:guid>")
+	assert.Contains(string(feed), fmt.Sprintf(`<enclosure url="http://example.com/feeds/episodes/%s.mp3" length="1234" type="audio/mpeg"></enclosure>`, first.BlogId))
+	assert.Contains(string(feed), "<itunes:episode>2</itunes:episode>")
+	assert.Contains(string(feed), "<itunes:duration>90</itunes:duration>")
+
+	// Other RSS feeds carry the episode's audio with its stored size
+	resp, err = shSuite.app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/feeds/users/%s.rss", shSuite.testUser.UserId), nil), -1)
+	shSuite.Require().NoError(err)
+	authorFeed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Contains(string(authorFeed), `<enclosure url="https://example.com/episode-1.mp3" length="1234" type="audio/mpeg"></enclosure>`)
+
+	// Downloads redirect to the audio and are counted like views; HEAD requests are not
+	downloadPath := fmt.Sprintf("/feeds/episodes/%s.mp3", first.BlogId)
+	resp, err = shSuite.app.Test(httptest.NewRequest(http.MethodHead, downloadPath, nil), -1)
+	shSuite.Require().NoError(err)
+	assert.Equal(http.StatusFound, resp.StatusCode)
+	resp, err = shSuite.app.Test(httptest.NewRequest(http.MethodGet, downloadPath, nil), -1)
+	shSuite.Require().NoError(err)
+	assert.Equal(http.StatusFound, resp.StatusCode)
+	assert.Equal(first.Audio, resp.Header.Get("Location"))
+
+	var blog models.Blog
+	shSuite.db.Where("blog_id = ?", first.BlogId).First(&blog)
+	assert.EqualValues(1, blog.DownloadsCount)
+	var downloads int64
+	shSuite.db.Model(&models.Download{}).Where("ref_id = ?", first.BlogId).Count(&downloads)
+	assert.EqualValues(1, downloads)
+	shSuite.db.Where("ref_id = ?", first.BlogId).Delete(&models.Download{})
+
+	resp, err = shSuite.app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/feeds/episodes/%s.mp3", silent.BlogId), nil), -1)
+	shSuite.Require().NoError(err)
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	status, _ = shSuite.request(http.MethodDelete, fmt.Sprintf("%s/%s", episodesPath, second.BlogId), nil)
+	assert.Equal(http.StatusOK, status)
+	status, _ = shSuite.request(http.MethodDelete, fmt.Sprintf("/shows/%s", showId), nil)
+	assert.Equal(http.StatusOK, status)
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
@@ -27,6 +28,8 @@ func init() {
 	if err != nil {
 		log.Fatal("Error loading .env file", err)
 	}
+	// The suites sign in with email-only tokens, which the shipped .env keeps disabled
+	os.Setenv("AUTH_EMAIL_TOKEN_ENABLED", "true")
 }
 
 type UserControllerSuite struct {
@@ -266,6 +269,81 @@ func (ucSuite *UserControllerSuite) TestUpdateUser() {
 	assert.Equal(updatePayload["bio"], updatedUser.Bio)
 }
 
+func (ucSuite *UserControllerSuite) TestUpdateOtherUserIsForbidden() {
+	assert := ucSuite.Assert()
+
+	otherUser := models.User{
+		UserId:    utils.GenerateID(),
+		Email:     "update_other@example.com",
+		FullName:  "Other User",
+		Verified:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: "test",
+		UpdatedBy: "test",
+	}
+	ucSuite.Require().NoError(ucSuite.db.Create(&otherUser).Error)
+	defer ucSuite.db.Delete(&otherUser)
+	tokenResponse, err := auth.NewAuthService(ucSuite.db).GetTokenByEmail(otherUser.Email)
+	ucSuite.Require().NoError(err)
+
+	// Taking over another account's email would let a password reset take the account
+	jsonPayload, _ := json.Marshal(map[string]interface{}{"email": "attacker@example.com"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s", ucSuite.testUser.UserId), bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+tokenResponse.Token)
+	resp, err := ucSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+
+	var unchanged models.User
+	ucSuite.db.Where("user_id = ?", ucSuite.testUser.UserId).First(&unchanged)
+	assert.Equal(ucSuite.testUser.Email, unchanged.Email)
+}
+
+func (ucSuite *UserControllerSuite) TestChangingEmailRequiresVerification() {
+	assert := ucSuite.Assert()
+
+	user := models.User{
+		UserId:          utils.GenerateID(),
+		Email:           "change_email@example.com",
+		FullName:        "Changing User",
+		Verified:        true,
+		EmailIsVerified: true,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+		CreatedBy:       "test",
+		UpdatedBy:       "test",
+	}
+	ucSuite.Require().NoError(ucSuite.db.Create(&user).Error)
+	defer ucSuite.db.Delete(&user)
+	tokenResponse, err := auth.NewAuthService(ucSuite.db).GetTokenByEmail(user.Email)
+	ucSuite.Require().NoError(err)
+
+	jsonPayload, _ := json.Marshal(map[string]interface{}{"email": "changed_email@example.com"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s", user.UserId), bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+tokenResponse.Token)
+	resp, err := ucSuite.app.Test(req, -1)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	// The new address must be verified before the account can be used again
+	var updated models.User
+	ucSuite.Require().NoError(ucSuite.db.Where("user_id = ?", user.UserId).First(&updated).Error)
+	assert.Equal("changed_email@example.com", updated.Email)
+	assert.False(updated.Verified)
+	assert.False(updated.EmailIsVerified)
+
+	var pending int64
+	ucSuite.db.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND target = ?", user.UserId, models.OneTimeTokenPurposeEmailVerification, updated.Email).
+		Count(&pending)
+	assert.Equal(int64(1), pending)
+}
+
 func (ucSuite *UserControllerSuite) TestDeleteUser() {
 	assert := ucSuite.Assert()
 